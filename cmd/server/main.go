@@ -1,8 +1,32 @@
 package main
 
-import "github.com/alaleks/geospace/internal/server/app"
+import (
+	"os"
+
+	"github.com/alaleks/geospace/internal/server/app"
+)
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			app.Import(os.Args[2:])
+			return
+		case "export":
+			app.Export(os.Args[2:])
+			return
+		case "migrate":
+			app.Migrate(os.Args[2:])
+			return
+		case "config":
+			app.Config(os.Args[2:])
+			return
+		case "keys":
+			app.Keys(os.Args[2:])
+			return
+		}
+	}
+
 	server := app.New()
 	server.Run()
 }
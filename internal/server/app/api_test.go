@@ -0,0 +1,866 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/app/handlers"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/graph"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"github.com/alaleks/geospace/internal/server/openapi"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testApp is the application with all routes over the storage in memory.
+type testApp struct {
+	*App
+	t     *testing.T
+	store *memory.Store
+	auth  *authentication.Auth
+	logs  *observer.ObservedLogs // access log and errors of the handlers
+}
+
+// newTestApp creates the application with the cities of Italy and two cities named Rome,
+// the routing service is replaced by the stub returning 527 km.
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+
+	osrm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"code":"Ok","routes":[{"distance":527431.5}]}`))
+	}))
+	t.Cleanup(osrm.Close)
+
+	routing.Configure(osrm.URL, routing.DefaultTimeout)
+	t.Cleanup(func() { routing.Configure(routing.DefaultBaseURL, routing.DefaultTimeout) })
+
+	store := memory.New(
+		models.City{Name: "Rome", AlternativeNames: "Roma,", CountryCode: "IT", Country: "Italy",
+			Timezone: "Europe/Rome", Latitude: 41.89193, Longitude: 12.51133},
+		models.City{Name: "Milan", AlternativeNames: "Milano,", CountryCode: "IT", Country: "Italy",
+			Timezone: "Europe/Rome", Latitude: 45.46427, Longitude: 9.18951},
+		models.City{Name: "Tivoli", CountryCode: "IT", Country: "Italy",
+			Timezone: "Europe/Rome", Latitude: 41.96, Longitude: 12.8},
+		models.City{Name: "Rome", CountryCode: "US", Country: "United States",
+			Timezone: "America/New_York", Latitude: 34.25704, Longitude: -85.16467},
+	)
+
+	auth := authentication.Init(store, config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="})
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := graph.New(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	core, logs := observer.New(zap.InfoLevel)
+
+	app := &App{
+		cfg:    &config.Cfg{},
+		srv:    fiber.New(),
+		hdls:   handlers.New(store, auth),
+		auth:   auth,
+		api:    doc,
+		graph:  schema,
+		logger: zap.New(core).Sugar(),
+	}
+	app.RegRouters()
+
+	return &testApp{App: app, t: t, store: store, auth: auth, logs: logs}
+}
+
+// do sends the request and returns the status and the body of the response.
+// Body is sent as JSON, the token is sent as Bearer token if not empty.
+func (a *testApp) do(method, target, token string, body any) (int, []byte) {
+	a.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+
+		reader = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+
+	resp, err := a.srv.Test(req, -1)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return resp.StatusCode, b
+}
+
+// decode sends the request, checks the status and decodes the JSON response to v.
+func (a *testApp) decode(method, target, token string, body any, status int, v any) {
+	a.t.Helper()
+
+	code, b := a.do(method, target, token, body)
+	if code != status {
+		a.t.Fatalf("%s %s: expected status %d, got %d: %s", method, target, status, code, b)
+	}
+
+	if v != nil {
+		if err := json.Unmarshal(b, v); err != nil {
+			a.t.Fatalf("%s %s: %v: %s", method, target, err, b)
+		}
+	}
+}
+
+// register registers the user and returns the token.
+func (a *testApp) register(email string) string {
+	a.t.Helper()
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	a.decode(fiber.MethodPost, "/v1/register", "", map[string]string{
+		"name": "user", "email": email, "password": "secret",
+	}, fiber.StatusOK, &resp)
+
+	return resp.Token
+}
+
+// editor adds the user with the role editor and returns the token.
+func (a *testApp) editor() string {
+	a.t.Helper()
+
+	uid := a.store.AddUser(models.User{Name: "editor", Email: "editor@example.com", Role: models.RoleEditor})
+
+	token, err := a.auth.GetTokenJWT(uid)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	app := newTestApp(t)
+	app.register("user@example.com")
+
+	credentials := map[string]string{"email": "user@example.com", "password": "secret"}
+
+	if code, body := app.do(fiber.MethodPost, "/v1/register", "", map[string]string{
+		"name": "user", "email": "USER@example.com", "password": "secret",
+	}); code != fiber.StatusBadRequest || string(body) != database.ErrUserAlreadyExists.Error() {
+		t.Errorf("repeated registration: %d %s", code, body)
+	}
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	app.decode(fiber.MethodPost, "/v1/login", "", credentials, fiber.StatusOK, &login)
+
+	if code, _ := app.do(fiber.MethodGet, "/v1/api/reverse?lat=45&lon=9", login.Token, nil); code != fiber.StatusOK {
+		t.Errorf("token of the login is not accepted: %d", code)
+	}
+
+	credentials["password"] = "wrong"
+	if code, body := app.do(fiber.MethodPost, "/v1/login", "", credentials); code != fiber.StatusBadRequest ||
+		string(body) != handlers.ErrInvalidPassword.Error() {
+		t.Errorf("login with wrong password: %d %s", code, body)
+	}
+
+	if code, _ := app.do(fiber.MethodGet, "/v1/api/reverse?lat=45&lon=9", "", nil); code != fiber.StatusUnauthorized {
+		t.Errorf("request without token: expected 401, got %d", code)
+	}
+
+	if code, _ := app.do(fiber.MethodGet, "/v1/editor/suggestions", login.Token, nil); code != fiber.StatusForbidden {
+		t.Errorf("editor route for user: expected 403, got %d", code)
+	}
+}
+
+func TestAPICities(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register("user@example.com")
+
+	var dist handlers.DistanceResponse
+	app.decode(fiber.MethodGet, "/v1/api/distance?departure=Roma&destination=Milan", token, nil, fiber.StatusOK, &dist)
+
+	if dist.Departure.Name != "Rome" || dist.Destination.Name != "Milan" ||
+		dist.DistanceStraight != 478 || dist.DistanceRoad != 527 {
+		t.Errorf("distance: %+v", dist)
+	}
+
+	var nearby handlers.NearbyResponse
+	app.decode(fiber.MethodGet, "/v1/api/find-by-name?departure=Rome,%20Italy&distanceTo=50", token, nil,
+		fiber.StatusOK, &nearby)
+
+	if nearby.QtyNearby != 2 || nearby.Departure == nil || nearby.Departure.CountryCode != "IT" {
+		t.Errorf("find by name: %+v", nearby)
+	}
+
+	var byCoord handlers.NearbyResponse
+	app.decode(fiber.MethodGet, "/v1/api/find-by-coord?lat=41.9&lon=12.5&distanceTo=50", token, nil,
+		fiber.StatusOK, &byCoord)
+
+	if byCoord.QtyNearby != 2 || byCoord.Departure != nil {
+		t.Errorf("find by coordinates: %+v", byCoord)
+	}
+
+	var reverse handlers.ReverseResponse
+	app.decode(fiber.MethodGet, "/v1/api/reverse?lat=34.2&lon=-85.1", token, nil, fiber.StatusOK, &reverse)
+
+	if reverse.City.Name != "Rome" || reverse.City.CountryCode != "US" || reverse.Distance != 8 {
+		t.Errorf("reverse: %+v", reverse)
+	}
+
+	if code, _ := app.do(fiber.MethodGet, "/v1/api/distance?departure=Atlantis&destination=Milan", token, nil); code !=
+		fiber.StatusBadRequest {
+		t.Errorf("distance to unknown city: expected 400, got %d", code)
+	}
+
+	if code, body := app.do(fiber.MethodGet, "/v1/country", "", nil); code != fiber.StatusOK ||
+		string(body) != "IT: Italy,US: United States" {
+		t.Errorf("countries: %d %s", code, body)
+	}
+}
+
+func TestAPISuggestions(t *testing.T) {
+	app := newTestApp(t)
+	user, editor := app.register("user@example.com"), app.editor()
+
+	var created models.Suggestion
+	app.decode(fiber.MethodPost, "/v1/user/suggestions", user, map[string]any{
+		"city_id": 2, "alternative_names": "Milano,Милан,", "comment": "Russian name",
+	}, fiber.StatusCreated, &created)
+
+	if created.Status != models.SuggestionPending || created.Name != "Milan" {
+		t.Fatalf("created suggestion: %+v", created)
+	}
+
+	var pending []handlers.SuggestionReview
+	app.decode(fiber.MethodGet, "/v1/editor/suggestions", editor, nil, fiber.StatusOK, &pending)
+
+	if len(pending) != 1 || pending[0].Suggestion.ID != created.ID || len(pending[0].Changes) != 1 {
+		t.Fatalf("pending suggestions: %+v", pending)
+	}
+
+	var city models.City
+	app.decode(fiber.MethodPost, "/v1/editor/suggestions/1/approve", editor, map[string]string{"comment": "ok"},
+		fiber.StatusOK, &city)
+
+	if city.ID != 2 || city.AlternativeNames != "Milano,Милан," {
+		t.Errorf("approved city: %+v", city)
+	}
+
+	if code, _ := app.do(fiber.MethodPost, "/v1/editor/suggestions/1/reject", editor, nil); code != fiber.StatusConflict {
+		t.Errorf("rejection of the approved suggestion: expected 409, got %d", code)
+	}
+
+	var audit []models.Audit
+	app.decode(fiber.MethodGet, "/v1/editor/cities/2/audit", editor, nil, fiber.StatusOK, &audit)
+
+	if len(audit) != 1 || audit[0].Action != database.AuditSuggestionApproved {
+		t.Errorf("audit: %+v", audit)
+	}
+
+	var mine []models.Suggestion
+	app.decode(fiber.MethodGet, "/v1/user/suggestions", user, nil, fiber.StatusOK, &mine)
+
+	if len(mine) != 1 || mine[0].Status != models.SuggestionApproved {
+		t.Errorf("suggestions of the user: %+v", mine)
+	}
+}
+
+func TestAPIV2(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register("user@example.com")
+
+	var resp struct {
+		Data handlers.DistanceResponse `json:"data"`
+	}
+	app.decode(fiber.MethodGet, "/v2/distance?departure=Rome,%20IT&destination=Milano", token, nil, fiber.StatusOK, &resp)
+
+	if resp.Data.Departure.CountryCode != "IT" || resp.Data.DistanceStraight != 478 {
+		t.Errorf("distance: %+v", resp.Data)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		typ    string
+		status int
+	}{
+		{name: "Ambiguous", target: "/v2/distance?departure=Rome&destination=Milan",
+			typ: "urn:geospace:problem:ambiguous-city", status: fiber.StatusConflict},
+		{name: "Not found", target: "/v2/distance?departure=Atlantis&destination=Milan",
+			typ: "urn:geospace:problem:city-not-found", status: fiber.StatusNotFound},
+		{name: "Invalid", target: "/v2/nearby?lat=100&lon=0&distance=10",
+			typ: "urn:geospace:problem:invalid-parameter", status: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		var problem struct {
+			Type string `json:"type"`
+		}
+
+		code, body := app.do(fiber.MethodGet, tt.target, token, nil)
+		if err := json.Unmarshal(body, &problem); err != nil || code != tt.status || problem.Type != tt.typ {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.name, tt.status, tt.typ, code, body)
+		}
+	}
+}
+
+func TestAPIExport(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register("user@example.com")
+
+	var collection struct {
+		Features []struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	app.decode(fiber.MethodGet, "/v1/api/export?country=IT&bbox=12,41,13,42", token, nil, fiber.StatusOK, &collection)
+
+	names := make([]string, 0, len(collection.Features))
+	for _, feature := range collection.Features {
+		name, _ := feature.Properties["name"].(string)
+		names = append(names, name)
+	}
+
+	if strings.Join(names, ",") != "Rome,Tivoli" {
+		t.Errorf("exported cities: %v", names)
+	}
+}
+
+func TestAPIGraphQL(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register("user@example.com")
+
+	var result struct {
+		Data struct {
+			City struct {
+				Nearby []struct {
+					City struct {
+						Name string `json:"name"`
+					} `json:"city"`
+				} `json:"nearby"`
+			} `json:"city"`
+		} `json:"data"`
+		Errors []any `json:"errors"`
+	}
+	app.decode(fiber.MethodPost, "/graphql", token, graph.Request{
+		Query: `{ city(name: "Rome, Italy") { nearby(distance: 50) { city { name } } } }`,
+	}, fiber.StatusOK, &result)
+
+	if len(result.Errors) != 0 || len(result.Data.City.Nearby) != 1 || result.Data.City.Nearby[0].City.Name != "Tivoli" {
+		t.Errorf("GraphQL result: %+v", result)
+	}
+}
+
+func TestAPIMetrics(t *testing.T) {
+	app := newTestApp(t)
+	editor := app.editor()
+
+	app.do(fiber.MethodGet, "/v1/editor/suggestions/42", editor, nil)
+	app.do(fiber.MethodGet, "/v1/editor/suggestions", "", nil)
+	app.do(fiber.MethodGet, "/wp-admin/setup.php", "", nil)
+	app.do(fiber.MethodGet, "/v1/api/distance?departure=Rome,%20Italy&destination=Milan", editor, nil)
+
+	code, body := app.do(fiber.MethodGet, "/metrics", "", nil)
+	if code != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+
+	for _, want := range []string{
+		`geospace_http_requests_total{method="GET",route="/v1/editor/suggestions/:id",status="404"}`,
+		`geospace_http_requests_total{method="GET",route="/v1/editor",status="401"}`,
+		`geospace_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`geospace_http_request_duration_seconds_bucket{method="GET",route="/v1/api/distance"`,
+		`geospace_auth_failures_total{reason="missing_token"}`,
+		`geospace_routing_requests_total{result="success"}`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+
+	if strings.Contains(string(body), "/suggestions/42") || strings.Contains(string(body), "wp-admin") {
+		t.Error("metrics contain the raw paths of the requests")
+	}
+}
+
+func TestAPIAccessLog(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register("user@example.com")
+	app.logs.TakeAll()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/v1/api/reverse?lat=34.2&lon=-85.1", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(logging.HeaderRequestID, "client-42")
+
+	resp, err := app.srv.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	if id := resp.Header.Get(logging.HeaderRequestID); id != "client-42" {
+		t.Errorf("expected the id of the client, got %q", id)
+	}
+
+	entries := app.logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if entries[0].Message != "request" || fields["request_id"] != "client-42" || fields["route"] != "/v1/api/reverse" ||
+		fields["status"] != int64(fiber.StatusOK) || fields["user_id"] == nil || fields["ip"] == nil {
+		t.Errorf("unexpected line %+v", fields)
+	}
+
+	// the invalid id of the client is replaced, errors are written as warnings
+	req = httptest.NewRequest(fiber.MethodGet, "/v1/api/reverse?lat=34.2&lon=-85.1", nil)
+	req.Header.Set(logging.HeaderRequestID, "bad id\n")
+
+	resp, err = app.srv.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	if id := resp.Header.Get(logging.HeaderRequestID); len(id) != 32 {
+		t.Errorf("expected the new id, got %q", id)
+	}
+
+	entries = app.logs.TakeAll()
+	if len(entries) != 1 || entries[0].Level != zap.WarnLevel || entries[0].ContextMap()["status"] != int64(401) {
+		t.Errorf("unexpected lines %+v", entries)
+	}
+}
+
+func TestAPIHealth(t *testing.T) {
+	app := newTestApp(t)
+
+	readiness := func(want int) handlers.ReadinessResponse {
+		t.Helper()
+
+		code, body := app.do(fiber.MethodGet, "/readyz", "", nil)
+		if code != want {
+			t.Fatalf("expected %d, got %d: %s", want, code, body)
+		}
+
+		var resp handlers.ReadinessResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	if code, body := app.do(fiber.MethodGet, "/healthz", "", nil); code != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+
+	resp := readiness(fiber.StatusOK)
+	if resp.Status != handlers.StatusReady || resp.State != "serving" || len(resp.Components) != 3 {
+		t.Fatalf("unexpected readiness %+v", resp)
+	}
+
+	for _, component := range resp.Components {
+		if component.Status != handlers.StatusUp {
+			t.Errorf("component %s is %s: %s", component.Name, component.Status, component.Error)
+		}
+	}
+
+	// the routing service is not critical
+	routing.Configure("http://127.0.0.1:1", routing.DefaultTimeout)
+	resp = readiness(fiber.StatusOK)
+	if component := resp.Components[2]; component.Name != "routing" || component.Status != handlers.StatusDown || component.Critical {
+		t.Errorf("unexpected routing %+v", component)
+	}
+
+	// the address of the service is written to the log only
+	if component := resp.Components[2]; strings.Contains(component.Error, "127.0.0.1") {
+		t.Errorf("error of the routing is disclosed: %s", component.Error)
+	}
+
+	if entries := app.logs.FilterMessage("component is down").All(); len(entries) != 1 ||
+		!strings.Contains(entries[0].ContextMap()["error"].(string), "127.0.0.1") {
+		t.Errorf("error of the routing is not logged: %+v", entries)
+	}
+
+	app.hdls.SetState(handlers.StateImporting)
+	if resp := readiness(fiber.StatusServiceUnavailable); resp.State != "importing" {
+		t.Errorf("unexpected state %s", resp.State)
+	}
+
+	// the shutdown is final
+	app.hdls.SetState(handlers.StateShuttingDown)
+	app.hdls.SetState(handlers.StateServing)
+	if resp := readiness(fiber.StatusServiceUnavailable); resp.State != "shutting_down" {
+		t.Errorf("unexpected state %s", resp.State)
+	}
+
+	// the process is alive while it is stopping
+	if code, _ := app.do(fiber.MethodGet, "/healthz", "", nil); code != fiber.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+}
+
+func TestAPIReloadConfig(t *testing.T) {
+	app := newTestApp(t)
+	t.Cleanup(func() { _ = setLogLevel(config.Log{}) })
+
+	app.applyConfig(&config.Cfg{
+		CfgDatabase: config.CfgDatabase{Driver: config.DriverMemory},
+		App:         config.App{MaxRequest: 2, Expiration: 60},
+		Log:         config.Log{Level: "warn"},
+	})
+
+	warnings := app.logs.FilterMessage("changes of the configuration need the restart and are not applied").All()
+	if len(warnings) != 1 || fmt.Sprint(warnings[0].ContextMap()["sections"]) != "[database]" {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+
+	if app.cfg.CfgDatabase.Driver != "" || app.cfg.App.MaxRequest != 2 || logLevel.Level() != zap.WarnLevel {
+		t.Errorf("unexpected configuration %+v, level %s", app.cfg, logLevel.Level())
+	}
+
+	// the requests of the client are limited, the probes are not
+	for i, want := range []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests} {
+		if code, _ := app.do(fiber.MethodGet, "/v1/country", "", nil); code != want {
+			t.Errorf("request %d: expected %d, got %d", i+1, want, code)
+		}
+	}
+
+	if code, _ := app.do(fiber.MethodGet, "/healthz", "", nil); code != fiber.StatusOK {
+		t.Errorf("expected 200 of the probe, got %d", code)
+	}
+
+	// the invalid level rejects the whole configuration
+	app.applyConfig(&config.Cfg{App: config.App{MaxRequest: 1}, Log: config.Log{Level: "loud"}})
+
+	if app.cfg.App.MaxRequest != 2 || logLevel.Level() != zap.WarnLevel {
+		t.Errorf("invalid configuration is applied %+v", app.cfg.App)
+	}
+}
+
+func TestAPIJWKS(t *testing.T) {
+	app := newTestApp(t)
+	legacy := app.register("user@example.com")
+
+	// the key of EdDSA is added on reload, the tokens of secret_jwt are still valid
+	secure := config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg=",
+		Audience: []string{config.DefaultIssuer, "billing"}}
+
+	key, _, err := secure.RotateJWT(config.JWTAlgorithmEdDSA, authentication.Expiration, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.applyConfig(&config.Cfg{Secure: secure})
+
+	var jwks authentication.JWKS
+	app.decode(fiber.MethodGet, "/.well-known/jwks.json", "", nil, fiber.StatusOK, &jwks)
+
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != key.ID || jwks.Keys[0].Crv != "Ed25519" {
+		t.Fatalf("unexpected public keys %+v", jwks)
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	app.decode(fiber.MethodPost, "/v1/login", "", map[string]string{
+		"email": "user@example.com", "password": "secret",
+	}, fiber.StatusOK, &resp)
+
+	// the service verifies the token by the published key
+	public, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Parse(resp.Token, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["kid"] != key.ID {
+			return nil, fmt.Errorf("unexpected key %v", token.Header["kid"])
+		}
+
+		return ed25519.PublicKey(public), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if !claims.VerifyIssuer(config.DefaultIssuer, true) || !claims.VerifyAudience("billing", true) ||
+		claims["sub"] == "" || claims["iat"] == nil {
+		t.Errorf("unexpected claims %v", claims)
+	}
+
+	for _, token := range []string{legacy, resp.Token} {
+		if code, _ := app.do(fiber.MethodGet, "/v1/api/reverse?lat=41.9&lon=12.5", token, nil); code != fiber.StatusOK {
+			t.Errorf("expected 200 of the token, got %d", code)
+		}
+	}
+}
+
+// sentMails is the mailer keeping the sent messages.
+type sentMails []mailer.Message
+
+func (s *sentMails) Send(_ context.Context, msg mailer.Message) error {
+	*s = append(*s, msg)
+	return nil
+}
+
+// failingMailer is the mailer failing every message.
+type failingMailer struct{}
+
+func (failingMailer) Send(context.Context, mailer.Message) error {
+	return errors.New("smtp: 535 authentication failed at mail.internal")
+}
+
+// token returns the token of the link of the last message sent to the address.
+func (s sentMails) token(t *testing.T, to string) string {
+	t.Helper()
+
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i].To != to {
+			continue
+		}
+
+		_, token, found := strings.Cut(s[i].Body, "token=")
+		if !found {
+			t.Fatalf("link is not found in %q", s[i].Body)
+		}
+
+		token, _, _ = strings.Cut(token, "\n")
+
+		return token
+	}
+
+	t.Fatalf("no email is sent to %s", to)
+
+	return ""
+}
+
+func TestAPIEmailFlows(t *testing.T) {
+	app := newTestApp(t)
+
+	var mails sentMails
+	app.hdls.SetMailer(&mails, config.Mail{Backend: config.MailLog, BaseURL: "http://localhost"})
+
+	if code, _ := app.do(fiber.MethodPost, "/v1/register", "", map[string]string{
+		"name": "user", "email": "user@localhost", "password": "secret",
+	}); code != fiber.StatusBadRequest {
+		t.Errorf("expected 400 of the invalid email, got %d", code)
+	}
+
+	token := app.register("user@example.com")
+
+	// the data of all APIs is closed until the email is verified
+	targets := []string{"/v1/user/reverse?lat=41.9&lon=12.5", "/v1/api/reverse?lat=41.9&lon=12.5",
+		"/v2/reverse?lat=41.9&lon=12.5",
+		"/graphql?query=" + url.QueryEscape(`{ city(name: "Rome, Italy") { name } }`)}
+
+	for _, target := range targets {
+		if code, b := app.do(fiber.MethodGet, target, token, nil); code != fiber.StatusForbidden {
+			t.Errorf("%s: expected 403 of the unverified user, got %d: %s", target, code, b)
+		}
+	}
+
+	if code, _ := app.do(fiber.MethodPost, "/v1/email/resend", token, nil); code != fiber.StatusOK {
+		t.Errorf("expected 200 of the new link, got %d", code)
+	}
+
+	if len(mails) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(mails))
+	}
+
+	// the link of the first email is replaced by the new one
+	if code, _ := app.do(fiber.MethodGet, "/v1/email/verify?token="+mails[:1].token(t, "user@example.com"), "", nil); code != fiber.StatusBadRequest {
+		t.Errorf("expected 400 of the replaced link, got %d", code)
+	}
+
+	link := "/v1/email/verify?token=" + mails.token(t, "user@example.com")
+	if code, b := app.do(fiber.MethodGet, link, "", nil); code != fiber.StatusOK {
+		t.Fatalf("expected 200 of the link, got %d: %s", code, b)
+	}
+
+	if code, _ := app.do(fiber.MethodGet, link, "", nil); code != fiber.StatusBadRequest {
+		t.Errorf("expected 400 of the used link, got %d", code)
+	}
+
+	for _, target := range targets {
+		if code, b := app.do(fiber.MethodGet, target, token, nil); code != fiber.StatusOK {
+			t.Errorf("%s: expected 200 of the verified user, got %d: %s", target, code, b)
+		}
+	}
+
+	// the unknown email gets the same answer without the email
+	for _, email := range []string{"nobody@example.com", "user@example.com"} {
+		code, b := app.do(fiber.MethodPost, "/v1/password/forgot", "", map[string]string{"email": email})
+		if code != fiber.StatusOK || !strings.Contains(string(b), handlers.MsgResetSent) {
+			t.Errorf("%s: unexpected answer %d: %s", email, code, b)
+		}
+	}
+
+	// the link is sent off the request path
+	app.hdls.WaitMails()
+
+	if len(mails) != 3 {
+		t.Fatalf("expected 3 emails, got %d", len(mails))
+	}
+
+	reset := mails.token(t, "user@example.com")
+
+	// the session issued before the reset, iat has the precision of seconds
+	uid, err := app.auth.CheckToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": strconv.Itoa(uid), "iss": config.DefaultIssuer, "aud": config.DefaultIssuer,
+		"iat": time.Now().Add(-time.Minute).Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code, b := app.do(fiber.MethodGet, "/v1/password/reset?token="+reset, "", nil); code != fiber.StatusOK ||
+		!strings.Contains(string(b), "<form") {
+		t.Errorf("unexpected page %d: %s", code, b)
+	}
+
+	if code, _ := app.do(fiber.MethodPost, "/v1/password/reset", "", map[string]string{
+		"token": reset, "password": "changed",
+	}); code != fiber.StatusOK {
+		t.Fatalf("expected 200 of the reset, got %d", code)
+	}
+
+	if code, _ := app.do(fiber.MethodPost, "/v1/password/reset", "", map[string]string{
+		"token": reset, "password": "again",
+	}); code != fiber.StatusBadRequest {
+		t.Errorf("expected 400 of the used link, got %d", code)
+	}
+
+	for password, status := range map[string]int{"secret": fiber.StatusBadRequest, "changed": fiber.StatusOK} {
+		if code, _ := app.do(fiber.MethodPost, "/v1/login", "", map[string]string{
+			"email": "user@example.com", "password": password,
+		}); code != status {
+			t.Errorf("password %s: expected %d, got %d", password, status, code)
+		}
+	}
+
+	// the sessions issued before the reset are revoked
+	if code, _ := app.do(fiber.MethodGet, targets[0], session, nil); code != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 of the session issued before the reset, got %d", code)
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	app.decode(fiber.MethodPost, "/v1/login", "", map[string]string{
+		"email": "user@example.com", "password": "changed",
+	}, fiber.StatusOK, &resp)
+
+	if code, _ := app.do(fiber.MethodGet, targets[0], resp.Token, nil); code != fiber.StatusOK {
+		t.Errorf("expected 200 of the token issued after the reset, got %d", code)
+	}
+
+	// the failure of the mailer does not disclose the registered email
+	app.hdls.SetMailer(failingMailer{}, config.Mail{Backend: config.MailSMTP, BaseURL: "http://localhost"})
+
+	for _, email := range []string{"nobody@example.com", "user@example.com"} {
+		code, b := app.do(fiber.MethodPost, "/v1/password/forgot", "", map[string]string{"email": email})
+		if code != fiber.StatusOK || string(b) != handlers.MsgResetSent {
+			t.Errorf("%s: unexpected answer of the failed mailer %d: %s", email, code, b)
+		}
+	}
+
+	app.hdls.WaitMails()
+
+	if entries := app.logs.FilterMessage("email resetting the password is not sent").All(); len(entries) != 1 {
+		t.Errorf("failure of the mailer is not logged: %+v", entries)
+	}
+}
+
+func TestAPITokenClaims(t *testing.T) {
+	app := newTestApp(t)
+	app.register("user@example.com")
+
+	now := time.Now()
+	sign := func(claims jwt.MapClaims) string {
+		t.Helper()
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	// tokens of secret_jwt without kid are checked by the claims as well,
+	// the tokens of the previous versions without sub have only uid
+	for name, tt := range map[string]struct {
+		claims jwt.MapClaims
+		status int
+	}{
+		"valid": {jwt.MapClaims{"sub": "1", "iss": config.DefaultIssuer, "aud": config.DefaultIssuer,
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}, fiber.StatusOK},
+		"wrong audience": {jwt.MapClaims{"sub": "1", "iss": config.DefaultIssuer, "aud": "billing",
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}, fiber.StatusUnauthorized},
+		"wrong issuer": {jwt.MapClaims{"sub": "1", "iss": "other", "aud": config.DefaultIssuer,
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}, fiber.StatusUnauthorized},
+		"without iat": {jwt.MapClaims{"sub": "1", "iss": config.DefaultIssuer, "aud": config.DefaultIssuer,
+			"exp": now.Add(time.Hour).Unix()}, fiber.StatusUnauthorized},
+		"legacy": {jwt.MapClaims{"uid": 1, "exp": now.Add(time.Hour).Unix()}, fiber.StatusOK},
+	} {
+		if code, b := app.do(fiber.MethodGet, "/v1/api/reverse?lat=41.9&lon=12.5", sign(tt.claims), nil); code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, code, b)
+		}
+	}
+}
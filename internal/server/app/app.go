@@ -2,27 +2,55 @@
 package app
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/alaleks/geospace/internal/server/app/authentication"
 	"github.com/alaleks/geospace/internal/server/app/handlers"
+	"github.com/alaleks/geospace/internal/server/cache"
 	"github.com/alaleks/geospace/internal/server/config"
 	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/graph"
+	"github.com/alaleks/geospace/internal/server/importer"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/openapi"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/alaleks/geospace/internal/server/rpc"
+	"github.com/alaleks/geospace/internal/server/tracing"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	cfg    *config.Cfg        // configuration
-	srv    *fiber.App         // server
-	hdls   *handlers.Hdls     // handlers
-	logger *zap.SugaredLogger // zap logger
+	cfg    *config.Cfg          // configuration
+	srv    *fiber.App           // server
+	grpc   *grpc.Server         // gRPC server, nil if the port is not set
+	hdls   *handlers.Hdls       // handlers
+	auth   *authentication.Auth // authentication, its keys are replaced on reload
+	api    *openapi.Document    // OpenAPI document
+	graph  *graph.Graph         // GraphQL schema
+	logger *zap.SugaredLogger   // zap logger
+
+	shutdownTracing func(context.Context) error // flushes the spans on shutdown
+	db              database.Store              // storage
+	initialImport   bool                        // the cities are imported in the background by Run
+	limiter         *rateLimiter                // limit of the requests of the clients
+	tasks           sync.WaitGroup              // background tasks finished before the storage is closed
+	stopTasks       context.CancelFunc          // cancels the background tasks on shutdown
+	done            chan struct{}               // closed when the shutdown is finished
 }
 
 // New returns a pointer to a new App instance.
@@ -34,31 +62,93 @@ func New() *App {
 
 	app := &App{
 		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	// flags of the command line override the config file and the environment variables
+	flags := config.NewFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := config.New(logger, flags)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := setLogLevel(cfg.Log); err != nil {
+		logger.Fatal(err)
 	}
 
-	cfg, err := config.New(logger)
+	// spans of the requests, queries and routing are exported by the configuration
+	app.shutdownTracing, err = tracing.Init(cfg.Tracing, serviceName(cfg.App))
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	db, err := database.Connect(*cfg)
+	// distances by road are requested from the routing service of the configuration
+	routing.Configure(cfg.Routing.GetURL(), cfg.Routing.GetTimeout())
+
+	db, err := openStore(cfg)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
+	ctx := context.Background()
+
 	// migrate schemes of tables
-	db.Migrate()
+	if err := db.Migrate(ctx); err != nil {
+		logger.Fatal(err)
+	}
+
+	// import data to table if it is empty,
+	// the interrupted import is continued from the checkpoint at the next start
+	app.initialImport = !checkDataCities(ctx, db) || db.HasCheckpoint(ctx, importer.SourceName(cfg.Import))
 
-	// import data to table
-	err = importCities(db.SQLX)
+	// cities found by names and distances by road are cached
+	db, err = withCache(db, cfg.Cache)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// statistics of the pools of connections are exposed to the metrics
+	if reporter, ok := db.(database.PoolReporter); ok {
+		metrics.Registry.MustRegister(poolCollector{reporter: reporter})
+	}
+
+	// hits and misses of the cache are exposed to the metrics
+	if reporter, ok := db.(cache.Reporter); ok {
+		metrics.Registry.MustRegister(cacheCollector{reporter: reporter})
+	}
+
+	// parse the document describing routes
+	app.api, err = openapi.Load()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// create schema of GraphQL
+	app.graph, err = graph.New(db)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	// create server and handlers
 	app.cfg = cfg
+	app.db = db
 	app.createServer()
-	app.hdls = handlers.New(db, authentication.Init(db, cfg.Secure))
+	app.auth = authentication.Init(db, cfg.Secure)
+	app.hdls = handlers.New(db, app.auth)
+
+	// emails confirm the registration and reset the password
+	mail, err := mailer.New(cfg.Mail, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	app.hdls.SetMailer(mail, cfg.Mail)
+
+	if cfg.App.GRPCPort != "" {
+		app.grpc = rpc.New(db, app.auth)
+	}
 
 	return app
 }
@@ -68,26 +158,80 @@ func (app *App) Run() {
 	// run goroutine for catch os signals for shutdown server.
 	go app.catchSign()
 
+	// background tasks are stopped on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	app.stopTasks = cancel
+
+	// the server is not ready until the initial import is finished
+	if app.initialImport {
+		app.hdls.SetState(handlers.StateImporting)
+		app.tasks.Add(1)
+
+		go app.importOnStart(ctx, app.cfg.Import)
+	}
+
 	// register routes
 	app.RegRouters()
 
 	// use recovery from panic
 	app.srv.Use(recover.New())
 
+	// gRPC is served on its own port alongside the REST API
+	if app.grpc != nil {
+		lis, err := net.Listen("tcp", app.cfg.App.GRPCPort)
+		if err != nil {
+			app.logger.Fatal(err)
+		}
+
+		go func() {
+			if err := app.grpc.Serve(lis); err != nil {
+				app.logger.Fatal(err)
+			}
+		}()
+	}
+
 	// listen port
 	err := app.srv.Listen(app.cfg.App.Port)
 	if err != nil {
 		app.logger.Fatal(err)
 	}
+
+	// listening is stopped at the start of the shutdown, wait for its end
+	<-app.done
 }
 
 // RegRouters install routes for the given application.
 func (app *App) RegRouters() {
+	// requests are counted by the patterns of the routes
+	app.srv.Use(metrics.Middleware)
+	// requests are traced continuing the traces of the clients
+	app.srv.Use(tracing.Middleware)
+	// requests get the ids and are written to the access log
+	app.srv.Use(logging.Middleware(app.logger, app.cfg.Log))
+	// requests of the clients are limited by the configuration
+	if app.limiter == nil {
+		app.limiter = newRateLimiter(app.cfg.App)
+	}
+
+	app.srv.Use(app.limiter.Middleware)
+	// queries of the requests are aborted when the client disconnects
+	app.srv.Use(app.hdls.RequestContext)
+
 	// ping server
 	app.srv.Get("/ping", app.hdls.Ping)
+	// liveness and readiness probes
+	app.srv.Get("/healthz", app.hdls.Healthz)
+	app.srv.Get("/readyz", app.hdls.Readyz)
+	// metrics for Prometheus
+	app.srv.Get("/metrics", metrics.Handler())
+	// public keys verifying the tokens
+	app.srv.Get("/.well-known/jwks.json", app.hdls.GetJWKS)
+	// documentation
+	app.srv.Get("/openapi.json", app.api.Spec)
+	app.srv.Get("/docs", app.api.Docs)
 
-	// v1
-	v1 := app.srv.Group("/v1")
+	// v1, parameters of the requests are validated against the document
+	v1 := app.srv.Group("/v1", app.api.Validate(app.hdls.InvalidRequest))
 	// registration for the using application.
 	v1.Post("/register", app.hdls.SignUp)
 	// login for the using application.
@@ -96,51 +240,193 @@ func (app *App) RegRouters() {
 	v1.Get("/logout", app.hdls.Logout)
 	// get list countries
 	v1.Get("/country", app.hdls.GetCountry)
+	// confirmation of the email and reset of the password by the links of the emails
+	v1.Get("/email/verify", app.hdls.VerifyEmail)
+	v1.Post("/email/resend", app.hdls.CheckAuthentication, app.hdls.ResendVerification)
+	v1.Post("/password/forgot", app.hdls.ForgotPassword)
+	v1.Get("/password/reset", app.hdls.ResetPasswordForm)
+	v1.Post("/password/reset", app.hdls.ResetPassword)
 
-	// these routes available only auth user
-	user := v1.Group("/user", app.hdls.CheckAuthentication)
+	// these routes available only auth user with the confirmed email
+	user := v1.Group("/user", app.hdls.CheckAuthentication, app.hdls.CheckVerified)
 	user.Get("/distance", app.hdls.CalculateDistance)
 	user.Get("/find-by-name", app.hdls.FindObjectsNearByName)
 	user.Get("/find-by-coord", app.hdls.FindObjectsNearByCoord)
+	user.Get("/reverse", app.hdls.ReverseGeocode)
+	user.Get("/suggestions", app.hdls.ListUserSuggestions)
+	user.Post("/suggestions", app.hdls.CreateSuggestion)
+
+	// editor, these routes available only auth user with role editor
+	editor := v1.Group("/editor", app.hdls.CheckAuthentication, app.hdls.CheckEditor)
+	editor.Get("/suggestions", app.hdls.ListSuggestionsForReview)
+	editor.Get("/suggestions/:id", app.hdls.GetSuggestionForReview)
+	editor.Post("/suggestions/:id/approve", app.hdls.ApproveSuggestion)
+	editor.Post("/suggestions/:id/reject", app.hdls.RejectSuggestion)
+	editor.Get("/cities/:id/audit", app.hdls.GetCityAudit)
+	editor.Get("/database/pools", app.hdls.GetPoolStats)
+	editor.Get("/cache", app.hdls.GetCacheStats)
 
-	// api, these routes available only auth user
-	api := v1.Group("/api", app.hdls.CheckAuthentication)
+	// api, these routes available only auth user with the confirmed email
+	api := v1.Group("/api", app.hdls.CheckAuthentication, app.hdls.CheckVerified)
 	api.Get("/distance", app.hdls.CalculateDistanceAPI)
 	api.Get("/find-by-name", app.hdls.FindObjectsNearByNameAPI)
 	api.Get("/find-by-coord", app.hdls.FindObjectsNearByCoordAPI)
+	api.Get("/reverse", app.hdls.ReverseGeocodeAPI)
+	api.Get("/export", app.hdls.ExportCities)
+
+	// v2, errors are sent as application/problem+json
+	v2 := app.srv.Group("/v2", app.hdls.Problems, app.api.Validate(app.hdls.InvalidRequestV2))
+	v2.Post("/register", app.hdls.SignUpV2)
+	v2.Post("/login", app.hdls.LoginV2)
+	// these routes available only auth user with the confirmed email
+	v2.Get("/distance", app.hdls.Authenticate, app.hdls.CheckVerifiedV2, app.hdls.DistanceV2)
+	v2.Get("/nearby", app.hdls.Authenticate, app.hdls.CheckVerifiedV2, app.hdls.NearbyV2)
+	v2.Get("/reverse", app.hdls.Authenticate, app.hdls.CheckVerifiedV2, app.hdls.ReverseV2)
+
+	// GraphQL, available only auth user with the confirmed email
+	app.srv.Get("/graphql", app.hdls.CheckAuthentication, app.hdls.CheckVerified, app.graph.Handler)
+	app.srv.Post("/graphql", app.hdls.CheckAuthentication, app.hdls.CheckVerified, app.graph.Handler)
 }
 
-// catchSign will catch SIGINT, SIGHUP, SIGQUIT and SIGTERM and shutdown the server.
+// catchSign will catch the signals: SIGHUP and SIGUSR1 reload the configuration,
+// SIGINT, SIGQUIT and SIGTERM shutdown the server.
 func (app *App) catchSign() {
 	termSignals := make(chan os.Signal, 1)
 	reloadSignals := make(chan os.Signal, 1)
 
 	signal.Notify(termSignals,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
-	signal.Notify(reloadSignals, syscall.SIGUSR1)
+	signal.Notify(reloadSignals,
+		syscall.SIGHUP,
+		syscall.SIGUSR1)
 
 	for {
 		select {
 		case <-termSignals:
 			fmt.Printf("%s shutdown\n", app.cfg.App.Name)
-			err := app.srv.Shutdown()
-			if err != nil {
-				app.logger.Fatal(err)
-			}
+			app.shutdown()
+
+			return
 		case <-reloadSignals:
-			fmt.Printf("%s shutdown\n", app.cfg.App.Name)
-			err := app.srv.Shutdown()
-			if err != nil {
-				app.logger.Fatal(err)
-			}
+			app.reload()
 		}
 	}
 }
 
+// shutdown stops the server gracefully: the server becomes not ready, the requests
+// in flight are finished within the timeout of the configuration and the emails
+// in the background are sent, then the storage is closed and the spans and the lines of the log are flushed.
+func (app *App) shutdown() {
+	defer close(app.done)
+
+	app.drain()
+
+	// the interrupted import is continued from the checkpoint at the next start
+	if app.stopTasks != nil {
+		app.stopTasks()
+	}
+
+	timeout := app.cfg.App.GetShutdownTimeout()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		app.stopGRPC(timeout)
+	}()
+
+	if err := app.srv.ShutdownWithTimeout(timeout); err != nil {
+		app.logger.Error(err)
+	}
+
+	wg.Wait()
+	app.tasks.Wait()
+	app.hdls.WaitMails()
+
+	if err := app.db.Close(); err != nil {
+		app.logger.Error(err)
+	}
+
+	app.stopTracing()
+
+	_ = app.logger.Sync()
+}
+
+// importOnStart imports the cities into the empty table or continues
+// the interrupted import, the server becomes ready when it is finished.
+func (app *App) importOnStart(ctx context.Context, cfg config.Import) {
+	defer app.tasks.Done()
+
+	_, err := importCities(ctx, app.db, cfg, app.logger, importer.Options{Resumable: true})
+	if err != nil {
+		if ctx.Err() != nil {
+			app.logger.Warn("import is interrupted by the shutdown, it is continued at the next start")
+			return
+		}
+
+		app.logger.Fatal(err)
+	}
+
+	app.hdls.SetState(handlers.StateServing)
+}
+
+// drain marks the server as not ready and waits the delay of the shutdown,
+// the requests are served until the load balancer stops sending them.
+func (app *App) drain() {
+	app.hdls.SetState(handlers.StateShuttingDown)
+	time.Sleep(app.cfg.App.GetShutdownDelay())
+}
+
+// stopTracing flushes the spans not exported yet.
+func (app *App) stopTracing() {
+	if app.shutdownTracing == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.shutdownTracing(ctx); err != nil {
+		app.logger.Error(err)
+	}
+}
+
+// serviceName returns the name of the service in the traces.
+func serviceName(cfg config.App) string {
+	if cfg.Name == "" {
+		return "geospace"
+	}
+
+	return cfg.Name
+}
+
+// stopGRPC stops the gRPC server gracefully, the calls
+// not finished within the timeout are cancelled.
+func (app *App) stopGRPC(timeout time.Duration) {
+	if app.grpc == nil {
+		return
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		app.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		app.grpc.Stop()
+	}
+}
+
 // createServer performs initialization a new server.
 func (app *App) createServer() {
 	app.srv = fiber.New(fiber.Config{
@@ -148,9 +434,16 @@ func (app *App) createServer() {
 	})
 }
 
+// logLevel is the minimum level of the lines of the loggers, it is set
+// by the configuration after the logger is created.
+var logLevel = zap.NewAtomicLevel()
+
 // createLogger performs initialization a new logger.
+// Lines are not sampled by zap, the access log has its own sample ratio.
 func createLogger() (*zap.SugaredLogger, error) {
 	cfgZap := zap.NewProductionConfig()
+	cfgZap.Level = logLevel
+	cfgZap.Sampling = nil
 	cfgZap.EncoderConfig.TimeKey = "timestamp"
 	cfgZap.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("02.01.2006 15:04:05")
 	cfgZap.EncoderConfig.StacktraceKey = ""
@@ -162,3 +455,15 @@ func createLogger() (*zap.SugaredLogger, error) {
 
 	return logger.Sugar(), nil
 }
+
+// setLogLevel sets the minimum level of the lines of the loggers by the configuration.
+func setLogLevel(cfg config.Log) error {
+	level, err := zapcore.ParseLevel(cfg.GetLevel())
+	if err != nil {
+		return err
+	}
+
+	logLevel.SetLevel(level)
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/app/handlers"
+	"github.com/alaleks/geospace/internal/server/cache"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/graph"
+	"github.com/alaleks/geospace/internal/server/openapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestRoutesDocumented fails if a route registered in RegRouters
+// is missing from the OpenAPI document.
+func TestRoutesDocumented(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := graph.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{
+		cfg:   &config.Cfg{},
+		srv:   fiber.New(),
+		hdls:  handlers.New(nil, nil),
+		api:   doc,
+		graph: schema,
+	}
+	app.RegRouters()
+
+	routes := app.srv.GetRoutes(true)
+	if len(routes) == 0 {
+		t.Fatal("no routes are registered")
+	}
+
+	for _, route := range routes {
+		// HEAD is registered by fiber for every GET route
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		if !doc.Has(route.Method, route.Path) {
+			t.Errorf("route %s %s is not described in openapi.yaml", route.Method, route.Path)
+		}
+	}
+}
+
+func TestCacheCollector(t *testing.T) {
+	db := cache.NewStore(memory.New(models.City{Name: "Rome", CountryCode: "IT", Country: "Italy"}),
+		cache.NewMetered(cache.NewLRU(10, time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.FindCity(context.Background(), "Rome"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(cacheCollector{reporter: db})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]float64)
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if label := m.GetLabel(); len(label) == 1 && label[0].GetValue() == "city" {
+				values[family.GetName()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	if values["geospace_cache_hits_total"] != 2 || values["geospace_cache_misses_total"] != 1 {
+		t.Errorf("unexpected metrics of the cache %v", values)
+	}
+}
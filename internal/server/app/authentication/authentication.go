@@ -2,13 +2,18 @@
 package authentication
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/alaleks/geospace/internal/server/config"
 	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
 	"github.com/golang-jwt/jwt"
 	"github.com/golang-module/dongle"
 )
@@ -19,54 +24,183 @@ const (
 
 // typical errors
 var (
-	ErrInvalidClaim = errors.New("invalid token claim")
+	ErrInvalidClaim  = errors.New("invalid token claim")
+	ErrInvalidIssuer = errors.New("invalid issuer or audience of the token")
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrUnknownKey    = errors.New("unknown key of the token")
+	ErrTokenRevoked  = errors.New("token is revoked by the change of the password")
 )
 
-// Auth contains db instance, cipher and secret key for JWT.
+// Auth contains the users, cipher and secret key for JWT.
 type Auth struct {
-	db        *database.DB
-	cipher    *dongle.Cipher
-	secretJWT string
+	db   database.Users
+	keys atomic.Pointer[keys] // replaced on the reload of the configuration
+}
+
+// keys are the keys of the authentication.
+type keys struct {
+	cipher    *dongle.Cipher    // cipher of the new passwords
+	previous  []*dongle.Cipher  // previous ciphers decrypting the passwords not encrypted by cipher yet
+	signingID string            // id of the key signing the new tokens, SecretJWT signs them if it is empty
+	jwtKeys   map[string]jwtKey // keys of JWT by the ids
+	ids       []string          // ids of the keys of JWT in the order of the configuration
+	secretJWT []byte            // verifies the tokens without the id of the key
+	issuer    string            // issuer of the tokens
+	audience  []string          // audience of the tokens, one of them must be in the token
+	apiKeys   []string
+}
+
+// jwtKey is the key of JWT of the algorithm.
+type jwtKey struct {
+	method jwt.SigningMethod
+	sign   interface{} // []byte of HS256, *rsa.PrivateKey of RS256 or ed25519.PrivateKey of EdDSA
+	verify interface{} // []byte of HS256, *rsa.PublicKey of RS256 or ed25519.PublicKey of EdDSA
+}
+
+// newJWTKey returns the key of JWT by the configuration.
+func newJWTKey(cfgKey config.JWTKey) (jwtKey, error) {
+	if cfgKey.GetAlgorithm() == config.JWTAlgorithmHS256 {
+		secret := []byte(cfgKey.GetJWTKeySecret())
+		return jwtKey{method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+	}
+
+	private, err := cfgKey.GetPrivateKey()
+	if err != nil {
+		return jwtKey{}, err
+	}
+
+	key := jwtKey{method: jwt.SigningMethodRS256, sign: private, verify: private.Public()}
+	if cfgKey.GetAlgorithm() == config.JWTAlgorithmEdDSA {
+		key.method = jwt.SigningMethodEdDSA
+	}
+
+	return key, nil
 }
 
 // Init performs initialization pointer of the Auth instance.
-func Init(db *database.DB, cfgSecure config.Secure) *Auth {
-	cipher := dongle.NewCipher()
-	cipher.SetMode(dongle.CBC)              // CBC、CFB、OFB、CTR、ECB
-	cipher.SetPadding(dongle.PKCS7)         // No、Empty、Zero、PKCS5、PKCS7、AnsiX923、ISO97971
-	cipher.SetKey(cfgSecure.GetKeyCipher()) // key must from 1 to 56 bytes
-	cipher.SetIV(cfgSecure.GetIVCipher())   // iv must be 8 bytes
+func Init(db database.Users, cfgSecure config.Secure) *Auth {
+	a := &Auth{db: db}
+	a.SetKeys(cfgSecure)
+
+	return a
+}
+
+// SetKeys replaces the keys by the configuration, the requests
+// in flight are finished with the previous keys.
+func (a *Auth) SetKeys(cfgSecure config.Secure) {
+	k := &keys{
+		cipher:    newCipher(cfgSecure.GetKeyCipher(), cfgSecure.GetIVCipher()),
+		jwtKeys:   make(map[string]jwtKey, len(cfgSecure.JWTKeys)),
+		secretJWT: []byte(cfgSecure.GetSecretJWT()),
+		issuer:    cfgSecure.GetIssuer(),
+		audience:  cfgSecure.GetAudience(),
+		apiKeys:   cfgSecure.APIKeys,
+	}
 
-	return &Auth{
-		db:        db,
-		cipher:    cipher,
-		secretJWT: cfgSecure.GetSecretJWT(),
+	for _, c := range cfgSecure.PreviousCiphers {
+		k.previous = append(k.previous, newCipher(c.GetKeyCipher(), c.GetIVCipher()))
 	}
+
+	for i, key := range cfgSecure.JWTKeys {
+		if i == 0 {
+			k.signingID = key.ID
+		}
+
+		// the keys are validated with the configuration, the invalid key
+		// is unknown and the tokens are not signed by it
+		if jwtKey, err := newJWTKey(key); err == nil {
+			k.jwtKeys[key.ID] = jwtKey
+			k.ids = append(k.ids, key.ID)
+		}
+	}
+
+	a.keys.Store(k)
+}
+
+// newCipher returns the cipher of the passwords.
+func newCipher(key, iv string) *dongle.Cipher {
+	cipher := dongle.NewCipher()
+	cipher.SetMode(dongle.CBC)      // CBC、CFB、OFB、CTR、ECB
+	cipher.SetPadding(dongle.PKCS7) // No、Empty、Zero、PKCS5、PKCS7、AnsiX923、ISO97971
+	cipher.SetKey(key)              // key must from 1 to 56 bytes
+	cipher.SetIV(iv)                // iv must be 8 bytes
+
+	return cipher
 }
 
 // CheckPass performs check password from user with password from database
-// and returns false if passwords do not match.
+// and returns false if passwords do not match. The password encrypted
+// by the previous key of the cipher is checked too.
 func (a *Auth) CheckPass(passFromUser, passFromDB string) bool {
+	k := a.keys.Load()
+
+	if checkPass(k.cipher, passFromUser, passFromDB) {
+		return true
+	}
+
+	for _, cipher := range k.previous {
+		if checkPass(cipher, passFromUser, passFromDB) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsCurrentPass reports whether the password from database is encrypted
+// by the current key of the cipher, the password of the user must be checked before.
+func (a *Auth) IsCurrentPass(passFromUser, passFromDB string) bool {
+	return checkPass(a.keys.Load().cipher, passFromUser, passFromDB)
+}
+
+// checkPass checks the password from user with the password from database encrypted by the cipher.
+// The password encrypted by another key has the invalid padding, dongle panics on it.
+func checkPass(cipher *dongle.Cipher, passFromUser, passFromDB string) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
 	return strings.TrimSpace(passFromUser) ==
-		dongle.Decrypt.FromHexString(passFromDB).ByBlowfish(a.cipher).ToString()
+		dongle.Decrypt.FromHexString(passFromDB).ByBlowfish(cipher).ToString()
 }
 
 // EncryptPass performs encrypt password from user.
 func (a *Auth) EncryptPass(pass string) string {
-	return dongle.Encrypt.FromString(pass).ByBlowfish(a.cipher).ToHexString()
+	return dongle.Encrypt.FromString(pass).ByBlowfish(a.keys.Load().cipher).ToHexString()
 }
 
 // GetTokenJWT performs generate token jwt for user.
+// The token is signed by the first key of JWT, its id is set in the header kid.
+// The token has the standard claims sub, iss, aud, iat and exp, the claim uid is
+// kept for the tokens checked by the previous versions.
 func (a *Auth) GetTokenJWT(uid int) (string, error) {
-	tokenByte := jwt.New(jwt.SigningMethodHS256)
+	k := a.keys.Load()
+
+	key := jwtKey{method: jwt.SigningMethodHS256, sign: k.secretJWT}
+	if k.signingID != "" {
+		var ok bool
+		if key, ok = k.jwtKeys[k.signingID]; !ok {
+			return "", fmt.Errorf("%w %q", ErrUnknownKey, k.signingID)
+		}
+	}
 
 	now := time.Now().UTC()
-	claims := tokenByte.Claims.(jwt.MapClaims)
+	tokenByte := jwt.NewWithClaims(key.method, jwt.MapClaims{
+		"uid": uid,
+		"sub": strconv.Itoa(uid),
+		"iss": k.issuer,
+		"aud": k.audience,
+		"iat": now.Unix(),
+		"exp": now.Add(Expiration).Unix(),
+	})
 
-	claims["uid"] = uid
-	claims["exp"] = now.Add(Expiration).Unix()
+	if k.signingID != "" {
+		tokenByte.Header["kid"] = k.signingID
+	}
 
-	tokenString, err := tokenByte.SignedString([]byte(a.secretJWT))
+	tokenString, err := tokenByte.SignedString(key.sign)
 	if err != nil {
 		return tokenString, err
 	}
@@ -74,23 +208,143 @@ func (a *Auth) GetTokenJWT(uid int) (string, error) {
 	return tokenString, nil
 }
 
-// CheckToken perfoms validate jwt token.
-func (a *Auth) CheckToken(token string) error {
+// CheckToken perfoms validate jwt token and returns id of the user.
+// The token is verified by the key of its header kid, by SecretJWT if there is no kid.
+// The tokens with sub must have the issuer and one of the audiences of the configuration,
+// the tokens without sub are issued by the previous versions: they are accepted only
+// without kid, signed by SecretJWT with HS256 and must have uid and exp.
+func (a *Auth) CheckToken(token string) (int, error) {
+	uid, _, err := a.checkToken(token)
+	return uid, err
+}
+
+// CheckTokenUser checks the token by CheckToken and returns the user of the token.
+// The tokens issued before the last change of the password are revoked,
+// the tokens of the previous versions without iat are revoked by any change.
+func (a *Auth) CheckTokenUser(ctx context.Context, token string) (models.User, error) {
+	uid, issuedAt, err := a.checkToken(token)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := a.db.GetUserByID(ctx, uid)
+	if err != nil {
+		return user, err
+	}
+
+	if issuedAt < user.PasswordChangedAt {
+		return user, ErrTokenRevoked
+	}
+
+	return user, nil
+}
+
+// checkToken validates the token and returns id of the user and the time of the issue,
+// 0 if the token has no iat.
+func (a *Auth) checkToken(token string) (int, int64, error) {
+	k := a.keys.Load()
+
 	tokenByte, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := jwtToken.Header["kid"].(string)
+		if kid == "" {
+			if jwtToken.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("unexpected signing method: %s", jwtToken.Header["alg"])
+			}
+
+			if len(k.secretJWT) == 0 {
+				return nil, ErrUnknownKey
+			}
+
+			return k.secretJWT, nil
+		}
+
+		key, ok := k.jwtKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+		}
+
+		// the algorithm is taken from the key, not from the token
+		if jwtToken.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %s", jwtToken.Header["alg"])
 		}
 
-		return []byte(a.secretJWT), nil
+		return key.verify, nil
 	})
 	if err != nil {
-		return err
+		var vErr *jwt.ValidationError
+		if errors.As(err, &vErr) && errors.Is(vErr.Inner, ErrUnknownKey) {
+			return 0, 0, vErr.Inner
+		}
+
+		return 0, 0, err
 	}
 
-	_, ok := tokenByte.Claims.(jwt.MapClaims)
+	claims, ok := tokenByte.Claims.(jwt.MapClaims)
 	if !ok || !tokenByte.Valid {
-		return ErrInvalidClaim
+		return 0, 0, ErrInvalidClaim
+	}
+
+	issuedAt, _ := claims["iat"].(float64)
+
+	// the tokens of the previous versions are signed by SecretJWT (HS256 without kid)
+	// and have only uid and exp, the tokens of the keys with the id must have sub
+	if _, ok := claims["sub"]; !ok {
+		kid, _ := tokenByte.Header["kid"].(string)
+		if kid != "" || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+			return 0, 0, ErrInvalidClaim
+		}
+
+		uid, ok := claims["uid"].(float64)
+		if !ok {
+			return 0, 0, ErrInvalidClaim
+		}
+
+		return int(uid), int64(issuedAt), nil
+	}
+
+	uid, err := k.checkClaims(claims)
+
+	return uid, int64(issuedAt), err
+}
+
+// checkClaims checks the standard claims of the token and returns id of the user from sub.
+func (k *keys) checkClaims(claims jwt.MapClaims) (int, error) {
+	if !claims.VerifyIssuer(k.issuer, true) || !k.verifyAudience(claims) {
+		return 0, ErrInvalidIssuer
+	}
+
+	if !claims.VerifyIssuedAt(time.Now().Unix(), true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return 0, ErrInvalidClaim
+	}
+
+	sub, _ := claims["sub"].(string)
+
+	uid, err := strconv.Atoi(sub)
+	if err != nil {
+		return 0, ErrInvalidClaim
+	}
+
+	return uid, nil
+}
+
+// verifyAudience checks that the token has one of the audiences.
+func (k *keys) verifyAudience(claims jwt.MapClaims) bool {
+	for _, aud := range k.audience {
+		if claims.VerifyAudience(aud, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckAPIKey checks the API key of the service against the keys of the configuration.
+func (a *Auth) CheckAPIKey(key string) error {
+	for _, k := range a.keys.Load().apiKeys {
+		if k != "" && subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return nil
+		}
 	}
 
-	return nil
+	return ErrInvalidAPIKey
 }
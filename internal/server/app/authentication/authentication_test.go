@@ -0,0 +1,217 @@
+package authentication_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestKeyRotation(t *testing.T) {
+	secure := config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="}
+	auth := authentication.Init(memory.New(), secure)
+
+	legacy, err := auth.GetTokenJWT(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := auth.EncryptPass("pass")
+
+	// the first key signs the new tokens, secret_jwt and the previous cipher are kept
+	key, _, err := secure.RotateJWT(config.JWTAlgorithmHS256, authentication.Expiration, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secure.RotateCipher()
+	auth.SetKeys(secure)
+
+	token, err := auth.GetTokenJWT(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for want, token := range map[int]string{1: legacy, 2: token} {
+		if uid, err := auth.CheckToken(token); err != nil || uid != want {
+			t.Errorf("expected uid %d, got %d, %v", want, uid, err)
+		}
+	}
+
+	if !auth.CheckPass("pass", password) || auth.IsCurrentPass("pass", password) {
+		t.Error("password of the previous cipher is not checked")
+	}
+
+	if password = auth.EncryptPass("pass"); !auth.IsCurrentPass("pass", password) {
+		t.Error("password is not encrypted by the current cipher")
+	}
+
+	// the removed keys do not verify the tokens
+	secure.JWTKeys, secure.SecretJWT = secure.JWTKeys[1:], ""
+	auth.SetKeys(secure)
+
+	if _, err := auth.CheckToken(legacy); !errors.Is(err, authentication.ErrUnknownKey) {
+		t.Errorf("expected unknown key of the token without id, got %v", err)
+	}
+
+	if _, err := auth.CheckToken(token); !errors.Is(err, authentication.ErrUnknownKey) {
+		t.Errorf("expected unknown key %s, got %v", key.ID, err)
+	}
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	for _, tt := range []struct {
+		algorithm string
+		kty       string
+	}{
+		{algorithm: config.JWTAlgorithmRS256, kty: "RSA"},
+		{algorithm: config.JWTAlgorithmEdDSA, kty: "OKP"},
+	} {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			secure := config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="}
+
+			key, _, err := secure.RotateJWT(tt.algorithm, authentication.Expiration, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			auth := authentication.Init(nil, secure)
+
+			token, err := auth.GetTokenJWT(7)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if uid, err := auth.CheckToken(token); err != nil || uid != 7 {
+				t.Errorf("expected uid 7, got %d, %v", uid, err)
+			}
+
+			jwks := auth.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != key.ID || jwks.Keys[0].Kty != tt.kty ||
+				jwks.Keys[0].Alg != tt.algorithm {
+				t.Errorf("unexpected public keys %+v", jwks)
+			}
+
+			// the token of another audience is rejected by the same keys
+			secure.Audience = []string{"billing"}
+			auth.SetKeys(secure)
+
+			if _, err := auth.CheckToken(token); !errors.Is(err, authentication.ErrInvalidIssuer) {
+				t.Errorf("expected invalid audience, got %v", err)
+			}
+
+			secure.Audience = []string{"billing", config.DefaultIssuer}
+			secure.Issuer = "accounts"
+			auth.SetKeys(secure)
+
+			if _, err := auth.CheckToken(token); !errors.Is(err, authentication.ErrInvalidIssuer) {
+				t.Errorf("expected invalid issuer, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWKSWithoutSecrets(t *testing.T) {
+	secure := config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="}
+	if _, _, err := secure.RotateJWT(config.JWTAlgorithmHS256, authentication.Expiration, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if jwks := authentication.Init(nil, secure).JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("the keys of HS256 are published %+v", jwks)
+	}
+}
+
+func TestLegacyTokens(t *testing.T) {
+	secure := config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="}
+
+	key, _, err := secure.RotateJWT(config.JWTAlgorithmHS256, authentication.Expiration, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keySecret, err := base64.StdEncoding.DecodeString(key.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := authentication.Init(nil, secure)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	sign := func(method jwt.SigningMethod, kid string, secret []byte, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		s, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	}
+
+	if uid, err := auth.CheckToken(sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{"uid": 1, "exp": exp})); err != nil || uid != 1 {
+		t.Errorf("expected uid 1 of the legacy token, got %d, %v", uid, err)
+	}
+
+	for name, token := range map[string]string{
+		"without exp":     sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{"uid": 1}),
+		"expired":         sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{"uid": 1, "exp": time.Now().Add(-time.Hour).Unix()}),
+		"with kid":        sign(jwt.SigningMethodHS256, key.ID, keySecret, jwt.MapClaims{"uid": 1, "exp": exp}),
+		"signed by HS512": sign(jwt.SigningMethodHS512, "", []byte("secret"), jwt.MapClaims{"uid": 1, "exp": exp}),
+	} {
+		if _, err := auth.CheckToken(token); err == nil {
+			t.Errorf("legacy token %s is accepted", name)
+		}
+	}
+}
+
+func TestRevokedTokens(t *testing.T) {
+	store := memory.New()
+	uid := store.AddUser(models.User{Name: "user", Email: "user@example.com"})
+	auth := authentication.Init(store, config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="})
+
+	issued, err := auth.GetTokenJWT(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the token of the previous versions without iat
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid": uid, "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{issued, legacy} {
+		if user, err := auth.CheckTokenUser(context.Background(), token); err != nil || user.UID != uid {
+			t.Fatalf("unexpected user %+v: %v", user, err)
+		}
+	}
+
+	changedAt := time.Now().Add(time.Minute).Unix()
+	if err := store.ChangePassword(context.Background(), uid, "changed", changedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{issued, legacy} {
+		if _, err := auth.CheckTokenUser(context.Background(), token); !errors.Is(err, authentication.ErrTokenRevoked) {
+			t.Errorf("expected ErrTokenRevoked, got %v", err)
+		}
+
+		// the token itself is valid
+		if _, err := auth.CheckToken(token); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/alaleks/geospace/pkg/genkey"
+)
+
+// sizeEmailToken is the size of the token of the link sent to the user by email.
+const sizeEmailToken = 32
+
+// NewEmailToken returns the random token of the link sent to the user by email
+// and its hash stored in the database.
+func NewEmailToken() (token, hash string) {
+	token = genkey.Create(sizeEmailToken)
+
+	return token, HashEmailToken(token)
+}
+
+// HashEmailToken returns the hash of the token, only the hashes are stored,
+// so the tokens of the leaked database do not reset the passwords.
+func HashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
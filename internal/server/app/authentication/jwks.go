@@ -0,0 +1,59 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is the public key of JWT by RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`           // RSA or OKP
+	Use string `json:"use"`           // sig
+	Alg string `json:"alg"`           // RS256 or EdDSA
+	Kid string `json:"kid"`           // id of the key in the header kid of the token
+	Crv string `json:"crv,omitempty"` // Ed25519
+	N   string `json:"n,omitempty"`   // modulus of RSA
+	E   string `json:"e,omitempty"`   // exponent of RSA
+	X   string `json:"x,omitempty"`   // public key of Ed25519
+}
+
+// JWKS is the set of the public keys verifying the tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the asymmetric keys of JWT, the services
+// verify the tokens by them. The keys of HS256 are secret and are not published.
+func (a *Auth) JWKS() JWKS {
+	k := a.keys.Load()
+	set := JWKS{Keys: make([]JWK, 0, len(k.ids))}
+
+	for _, kid := range k.ids {
+		key := k.jwtKeys[kid]
+		jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: kid}
+
+		switch public := key.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeJWK(public.N.Bytes())
+			jwk.E = encodeJWK(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encodeJWK(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// encodeJWK encodes the value of the key by base64url without the padding.
+func encodeJWK(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
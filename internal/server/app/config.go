@@ -0,0 +1,43 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"gopkg.in/yaml.v2"
+)
+
+// Config runs the command of the configuration: "print" prints the effective
+// configuration made of the config file, the environment variables and the flags
+// with the passwords and the keys redacted.
+func Config(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		log.Fatal("specify the action of the configuration: print")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	flags := config.NewFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	b, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.Found() {
+		fmt.Printf("# config file %s\n", cfg.Path())
+	} else {
+		fmt.Printf("# config file %s is not found, it is created on the first run\n", cfg.Path())
+	}
+
+	fmt.Print(string(b))
+}
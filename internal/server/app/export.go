@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/exporter"
+)
+
+// Export runs the command of export of the cities to the file or stdout.
+func Export(args []string) {
+	logger, err := createLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	flags := config.NewFlags(fs)
+	formatName := fs.String("format", exporter.FormatGeoJSON,
+		"Format of the export: "+strings.Join(exporter.Formats(), ", "))
+	out := fs.String("out", "", "Path to the output file, stdout by default")
+	country := fs.String("country", "", "Country code or name of the country")
+	timezone := fs.String("timezone", "", "Name of the timezone")
+	bbox := fs.String("bbox", "", "Bounding box: min longitude,min latitude,max longitude,max latitude")
+	_ = fs.Parse(args)
+
+	format, err := exporter.Lookup(*formatName)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	box, err := exporter.ParseBBox(*bbox)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	cfg, err := config.New(logger, flags)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := setLogLevel(cfg.Log); err != nil {
+		logger.Fatal(err)
+	}
+
+	db, err := openStore(cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	defer db.Close()
+
+	w := os.Stdout
+	if *out != "" {
+		w, err = os.Create(*out)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		defer w.Close()
+	}
+
+	// the export is stopped by interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	count, err := exporter.Run(ctx, db, database.CityFilter{
+		Country:  *country,
+		Timezone: *timezone,
+		BBox:     box,
+	}, format, w)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if *out != "" {
+		fmt.Printf("exported %d cities to %s\n", count, *out)
+	}
+}
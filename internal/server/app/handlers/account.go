@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errors of the accounts
+var (
+	ErrInvalidEmail = errors.New("email has invalid format")
+	ErrNotVerified  = errors.New("email is not verified, open the link sent to it or request the new one")
+	ErrMailDisabled = errors.New("emails are not sent by the server")
+)
+
+// mailTimeout limits the email sent off the request path.
+const mailTimeout = time.Minute
+
+// messages of the accounts
+var (
+	MsgVerified         = "email is verified"
+	MsgAlreadyVerified  = "email is already verified"
+	MsgVerificationSent = "link confirming the email is sent"
+	MsgResetSent        = "if the email is registered, the link resetting the password is sent to it"
+	MsgPasswordReset    = "password is changed"
+)
+
+// SetMailer sets the mailer of the emails confirming the email and resetting
+// the password. Without the mailer the users are verified at registration
+// and the password cannot be reset.
+func (h *Hdls) SetMailer(m mailer.Mailer, cfg config.Mail) {
+	h.mailer = m
+	h.mail = cfg
+}
+
+// createUser creates the user, the user is verified at once if the emails are not sent.
+// The failure of the email does not fail the registration, the link can be requested again.
+func (h *Hdls) createUser(c *fiber.Ctx, name, email, password string) (int, error) {
+	uid, err := h.db.CreateUser(c.UserContext(), name, email, h.auth.EncryptPass(password), h.mailer == nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if h.mailer != nil {
+		if err := h.sendVerification(c, uid, email); err != nil {
+			logging.Logger(c).Errorw("email confirming the registration is not sent", "error", err)
+		}
+	}
+
+	return uid, nil
+}
+
+// sendVerification sends the link confirming the email to the user.
+func (h *Hdls) sendVerification(c *fiber.Ctx, uid int, email string) error {
+	return h.sendToken(c.UserContext(), uid, email, models.TokenVerifyEmail, h.mail.GetVerifyTTL(),
+		"Confirm your email",
+		"Confirm the email of your account of geospace by the link:\n\n%s/v1/email/verify?token=%s\n\n"+
+			"The link is valid for %s.\n")
+}
+
+// sendToken stores the new token of the purpose and sends the link with it to the user,
+// the text is formatted with the address of the server, the token and the lifetime.
+func (h *Hdls) sendToken(ctx context.Context, uid int, email, purpose string, ttl time.Duration, subject, text string) error {
+	token, hash := authentication.NewEmailToken()
+	now := time.Now()
+
+	err := h.db.CreateUserToken(ctx, models.UserToken{
+		Hash:      hash,
+		Purpose:   purpose,
+		UID:       uid,
+		ExpiresAt: now.Add(ttl).Unix(),
+		CreatedAt: now.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: subject,
+		Body:    fmt.Sprintf(text, h.mail.GetBaseURL(), url.QueryEscape(token), ttl),
+	})
+}
+
+// VerifyEmail confirms the email of the user by the token of the link.
+func (h *Hdls) VerifyEmail(c *fiber.Ctx) error {
+	uid, err := h.db.ConsumeUserToken(c.UserContext(), models.TokenVerifyEmail,
+		authentication.HashEmailToken(c.Query("token")))
+	if err != nil {
+		return h.errorToken(c, err)
+	}
+
+	if err := h.db.VerifyUser(c.UserContext(), uid); err != nil {
+		return h.errorToken(c, err)
+	}
+
+	return c.SendString(MsgVerified)
+}
+
+// ResendVerification sends the new link confirming the email of the authenticated user,
+// the previous link is not valid anymore.
+func (h *Hdls) ResendVerification(c *fiber.Ctx) error {
+	uid, _ := c.Locals(localUID).(int)
+
+	user, err := h.db.GetUserByID(c.UserContext(), uid)
+	if err != nil {
+		return h.errorToken(c, err)
+	}
+
+	switch {
+	case user.Verified:
+		return c.SendString(MsgAlreadyVerified)
+	case h.mailer == nil:
+		return c.Status(fiber.StatusServiceUnavailable).SendString(ErrMailDisabled.Error())
+	}
+
+	if err := h.sendVerification(c, uid, user.Email); err != nil {
+		return h.errorToken(c, err)
+	}
+
+	return c.SendString(MsgVerificationSent)
+}
+
+// ForgotPassword sends the link resetting the password to the email of the user.
+// The response is the same whether the email is registered or not, so the link is
+// sent off the request path and its failure is only logged: the time of the response
+// or the error would disclose the registered email.
+func (h *Hdls) ForgotPassword(c *fiber.Ctx) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return h.errorBadRequest(c, err)
+	}
+
+	if h.mailer == nil {
+		return c.Status(fiber.StatusServiceUnavailable).SendString(ErrMailDisabled.Error())
+	}
+
+	user, err := h.db.GetUser(c.UserContext(), req.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.SendString(MsgResetSent)
+	}
+
+	if err != nil {
+		return h.errorToken(c, err)
+	}
+
+	logger := logging.Logger(c)
+
+	h.mails.Add(1)
+
+	go func() {
+		defer h.mails.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+
+		err := h.sendToken(ctx, user.UID, user.Email, models.TokenResetPassword, h.mail.GetResetTTL(),
+			"Reset your password",
+			"The password of your account of geospace can be changed by the link:\n\n"+
+				"%s/v1/password/reset?token=%s\n\nThe link is valid for %s. "+
+				"Ignore this email if you did not ask to reset the password.\n")
+		if err != nil {
+			logger.Errorw("email resetting the password is not sent", "error", err)
+		}
+	}()
+
+	return c.SendString(MsgResetSent)
+}
+
+// WaitMails waits for the emails sent off the request path.
+func (h *Hdls) WaitMails() {
+	h.mails.Wait()
+}
+
+// resetForm is the page of the link resetting the password, it sends the new password
+// with the token of the link to ResetPassword.
+var resetForm = template.Must(template.New("reset").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Reset the password</title></head>
+<body>
+<form method="post" action="/v1/password/reset">
+<input type="hidden" name="token" value="{{.}}">
+<label>New password <input type="password" name="password" required></label>
+<button type="submit">Change the password</button>
+</form>
+</body>
+</html>
+`))
+
+// ResetPasswordForm returns the page of the link resetting the password.
+func (h *Hdls) ResetPasswordForm(c *fiber.Ctx) error {
+	c.Type("html", "utf-8")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+
+	return resetForm.Execute(c, c.Query("token"))
+}
+
+// ResetPassword replaces the password of the user by the token of the link and revokes
+// the tokens of the user issued before, the email of the user is verified as well.
+// The body is JSON or the form of ResetPasswordForm.
+func (h *Hdls) ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Token    string `json:"token" form:"token"`
+		Password string `json:"password" form:"password"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return h.errorBadRequest(c, err)
+	}
+
+	if req.Password == "" {
+		return h.errorBadRequest(c, fmt.Errorf("password cannot be empty"))
+	}
+
+	uid, err := h.db.ConsumeUserToken(c.UserContext(), models.TokenResetPassword,
+		authentication.HashEmailToken(req.Token))
+	if err != nil {
+		return h.errorToken(c, err)
+	}
+
+	// the tokens issued before are revoked, the new token is issued by the login
+	err = h.db.ChangePassword(c.UserContext(), uid, h.auth.EncryptPass(req.Password), time.Now().Unix())
+	if err != nil {
+		return h.errorToken(c, err)
+	}
+
+	if err := h.db.VerifyUser(c.UserContext(), uid); err != nil {
+		return h.errorToken(c, err)
+	}
+
+	return c.SendString(MsgPasswordReset)
+}
+
+// CheckVerified checks that the authenticated user has confirmed the email.
+// It must be used after CheckAuthentication.
+func (h *Hdls) CheckVerified(c *fiber.Ctx) error {
+	if err := h.checkVerified(c); err != nil {
+		return h.errorApiRequest(c, fiber.StatusForbidden, err)
+	}
+
+	return c.Next()
+}
+
+// CheckVerifiedV2 is CheckVerified of v2, the error is sent as the problem.
+// It must be used after Authenticate.
+func (h *Hdls) CheckVerifiedV2(c *fiber.Ctx) error {
+	if err := h.checkVerified(c); err != nil {
+		return err
+	}
+
+	return c.Next()
+}
+
+// checkVerified returns ErrNotVerified if the email of the authenticated user is not confirmed.
+func (h *Hdls) checkVerified(c *fiber.Ctx) error {
+	user, _ := c.Locals(localUser).(models.User)
+	if !user.Verified {
+		metrics.AuthFailure(metrics.AuthUnverified)
+		return ErrNotVerified
+	}
+
+	return nil
+}
+
+// errorToken sends 400 if the token is invalid or expired, 500 for other errors,
+// they are logged and not sent to the client: they are the errors of the database or the mailer.
+func (h *Hdls) errorToken(c *fiber.Ctx, err error) error {
+	if errors.Is(err, database.ErrTokenNotFound) {
+		return h.errorBadRequest(c, err)
+	}
+
+	logging.Logger(c).Errorw("request failed", "error", err.Error())
+
+	return c.Status(fiber.StatusInternalServerError).SendString(fiber.ErrInternalServerError.Message)
+}
@@ -6,8 +6,11 @@ import (
 	"strings"
 
 	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/exporter"
+	"github.com/alaleks/geospace/internal/server/logging"
 	"github.com/alaleks/geospace/pkg/distance"
 	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/errgroup"
 )
 
 // RespCity represents a data for response list near a citу,
@@ -17,8 +20,142 @@ type RespCity struct {
 	Distance int `json:"distance"`
 }
 
+// DistanceResponse represents the distance between two cities.
+type DistanceResponse struct {
+	Departure        models.City `json:"departure"`
+	Destination      models.City `json:"destination"`
+	DistanceStraight int         `json:"distance_straight"`
+	DistanceRoad     int         `json:"distance_road,omitempty"`
+}
+
+// NearbyResponse represents the cities near the city of departure or the coordinates.
+type NearbyResponse struct {
+	Departure    *models.City `json:"departure,omitempty"`
+	CitiesNearby []RespCity   `json:"cities_nearby"`
+	DistanceTo   int          `json:"distance_to"`
+	QtyNearby    int          `json:"qty_nearby"`
+}
+
+// ReverseResponse represents the city nearest to the coordinates.
+type ReverseResponse struct {
+	City     models.City `json:"city"`
+	Distance int         `json:"distance"`
+}
+
+func (r DistanceResponse) features() []exporter.Feature {
+	return []exporter.Feature{
+		cityFeature(r.Departure, map[string]any{"role": "departure"}),
+		cityFeature(r.Destination, map[string]any{
+			"role":              "destination",
+			"distance_straight": r.DistanceStraight,
+			"distance_road":     r.DistanceRoad,
+		}),
+	}
+}
+
+func (r DistanceResponse) table() [][]string {
+	header := append([]string{"role"}, cityHeader...)
+	header = append(header, "distance_straight", "distance_road")
+
+	departure := append([]string{"departure"}, cityRow(r.Departure)...)
+	departure = append(departure, "0", "0")
+
+	destination := append([]string{"destination"}, cityRow(r.Destination)...)
+	destination = append(destination, strconv.Itoa(r.DistanceStraight), strconv.Itoa(r.DistanceRoad))
+
+	return [][]string{header, departure, destination}
+}
+
+func (r DistanceResponse) text() string {
+	text := fmt.Sprintf("distance between %s, %s and %s, %s by straight line %d km",
+		r.Departure.Name, r.Departure.Country,
+		r.Destination.Name, r.Destination.Country, r.DistanceStraight)
+
+	if r.DistanceRoad != 0 {
+		text += fmt.Sprintf(" / by road %d km", r.DistanceRoad)
+	}
+
+	return text
+}
+
+func (r NearbyResponse) features() []exporter.Feature {
+	features := make([]exporter.Feature, 0, len(r.CitiesNearby))
+	for _, city := range r.CitiesNearby {
+		features = append(features, cityFeature(city.City, map[string]any{"distance": city.Distance}))
+	}
+
+	return features
+}
+
+func (r NearbyResponse) table() [][]string {
+	rows := make([][]string, 0, len(r.CitiesNearby)+1)
+	rows = append(rows, append(append([]string{}, cityHeader...), "distance"))
+
+	for _, city := range r.CitiesNearby {
+		rows = append(rows, append(cityRow(city.City), strconv.Itoa(city.Distance)))
+	}
+
+	return rows
+}
+
+func (r NearbyResponse) text() string {
+	text := fmt.Sprintf("There are %d cities at a distance %d km", r.QtyNearby, r.DistanceTo)
+	if r.Departure != nil {
+		text += fmt.Sprintf(" from %s, %s", r.Departure.Name, r.Departure.Country)
+	}
+
+	cities := make([]string, 0, len(r.CitiesNearby))
+	for _, city := range r.CitiesNearby {
+		cities = append(cities, fmt.Sprintf("%s, %s (%d km)", city.Name, city.Country, city.Distance))
+	}
+
+	if len(cities) > 0 {
+		text += fmt.Sprintf("\nList:\n%s", strings.Join(cities, ", "))
+	}
+
+	return text
+}
+
+func (r ReverseResponse) features() []exporter.Feature {
+	return []exporter.Feature{cityFeature(r.City, map[string]any{"distance": r.Distance})}
+}
+
+func (r ReverseResponse) table() [][]string {
+	return [][]string{
+		append(append([]string{}, cityHeader...), "distance"),
+		append(cityRow(r.City), strconv.Itoa(r.Distance)),
+	}
+}
+
+func (r ReverseResponse) text() string {
+	return fmt.Sprintf("the nearest city is %s, %s (%d km)", r.City.Name, r.City.Country, r.Distance)
+}
+
 // CalculateDistanceAPI performs a distance between two cities.
 func (h *Hdls) CalculateDistanceAPI(c *fiber.Ctx) error {
+	return h.calculateDistance(c, FormatJSON)
+}
+
+// FindObjectsNearByNameAPI performs search for all objects at a distance
+// until n km from the object passed in the query.
+func (h *Hdls) FindObjectsNearByNameAPI(c *fiber.Ctx) error {
+	return h.findObjectsNearByName(c, FormatJSON)
+}
+
+// FindObjectsNearByCoordAPI performs search for all objects at a distance
+// until n km from coordinates passed in the query.
+func (h *Hdls) FindObjectsNearByCoordAPI(c *fiber.Ctx) error {
+	return h.findObjectsNearByCoord(c, FormatJSON)
+}
+
+// ReverseGeocodeAPI performs search for the city nearest to coordinates passed in the query.
+func (h *Hdls) ReverseGeocodeAPI(c *fiber.Ctx) error {
+	return h.reverseGeocode(c, FormatJSON)
+}
+
+// calculateDistance performs a distance between two cities
+// and renders it in the negotiated format.
+func (h *Hdls) calculateDistance(c *fiber.Ctx, fallback string) error {
 	var (
 		departure   = c.Query("departure")
 		destination = c.Query("destination")
@@ -34,55 +171,39 @@ func (h *Hdls) CalculateDistanceAPI(c *fiber.Ctx) error {
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
-	var (
-		response struct {
-			Departure        models.City `json:"departure"`
-			Destination      models.City `json:"destination"`
-			DistanceStraight int         `json:"distance_straight"`
-			DistanceRoad     int         `json:"distance_road,omitempty"`
-		}
-		chErr             = make(chan error, 1)
-		cityDepartureCh   = make(chan models.City, 1)
-		cityDestinationCh = make(chan models.City, 1)
-	)
+	var response DistanceResponse
 
-	// run concurrently findind
-	go h.db.FindCityConc(departure, chErr, cityDepartureCh)
-	go h.db.FindCityConc(destination, chErr, cityDestinationCh)
-
-	// in cycle will be wait when search is done
-	// at the error, immediately return the request error
-	for {
-		select {
-		case err := <-chErr:
-			err = fmt.Errorf("error find city in db: %v", err)
-			return h.errorApiRequest(c, fiber.StatusBadRequest, err)
-		case response.Departure = <-cityDepartureCh:
-			continue
-		case response.Destination = <-cityDestinationCh:
-			continue
-		default:
-			// keep going until we get the data
-			if response.Departure == (models.City{}) || response.Destination == (models.City{}) {
-				continue
-			}
-
-			response.DistanceRoad, _ = h.getDistancebyRoad(
-				response.Departure.Longitude, response.Departure.Latitude,
-				response.Destination.Longitude, response.Destination.Latitude)
-
-			response.DistanceStraight = int(distance.CalcGreatCircle(
-				response.Departure.Latitude, response.Departure.Longitude,
-				response.Destination.Latitude, response.Destination.Longitude))
-
-			return c.JSON(response)
-		}
-	}
-}
-
-// FindObjectsNearByNameAP performs search for all objects at a distance
-// until n km from the object passed in the query.
-func (h *Hdls) FindObjectsNearByNameAPI(c *fiber.Ctx) error {
+	// cities are searched concurrently, the first error cancels the other search
+	g, ctx := errgroup.WithContext(c.UserContext())
+
+	g.Go(func() (err error) {
+		response.Departure, err = h.db.FindCity(ctx, departure)
+		return err
+	})
+	g.Go(func() (err error) {
+		response.Destination, err = h.db.FindCity(ctx, destination)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		err = fmt.Errorf("error find city in db: %v", err)
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	response.DistanceRoad, _ = h.getDistancebyRoad(c.UserContext(),
+		response.Departure.Longitude, response.Departure.Latitude,
+		response.Destination.Longitude, response.Destination.Latitude)
+
+	response.DistanceStraight = int(distance.CalcGreatCircle(
+		response.Departure.Latitude, response.Departure.Longitude,
+		response.Destination.Latitude, response.Destination.Longitude))
+
+	return h.render(c, fallback, response)
+}
+
+// findObjectsNearByName performs search for all objects at a distance
+// until n km from the object passed in the query and renders them in the negotiated format.
+func (h *Hdls) findObjectsNearByName(c *fiber.Ctx, fallback string) error {
 	var (
 		departure  = c.Query("departure")
 		distanceTo = c.Query("distanceTo")
@@ -104,7 +225,7 @@ func (h *Hdls) FindObjectsNearByNameAPI(c *fiber.Ctx) error {
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
-	ciyDeparture, cities, err := h.db.FindObjectsNearByName(departure, dist)
+	ciyDeparture, cities, err := h.db.FindObjectsNearByName(c.UserContext(), departure, dist)
 	if err != nil {
 		err = fmt.Errorf("error finding cities nearby in database: %v", err)
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
@@ -119,42 +240,25 @@ func (h *Hdls) FindObjectsNearByNameAPI(c *fiber.Ctx) error {
 		})
 	}
 
-	response := struct {
-		CitiesNearby []RespCity  `json:"cities_nearby"`
-		Departure    models.City `json:"departure"`
-		DistanceTo   int         `json:"distance_to"`
-		QtyNearby    int         `json:"qty_nearby"`
-	}{
-		Departure:    ciyDeparture,
+	return h.render(c, fallback, NearbyResponse{
+		Departure:    &ciyDeparture,
 		DistanceTo:   dist,
 		QtyNearby:    len(respCities),
 		CitiesNearby: respCities,
-	}
-
-	return c.JSON(response)
+	})
 }
 
-// FindObjectsNearByCoordAPI performs search for all objects at a distance
-// until n km from coordinates passed in the query.
-func (h *Hdls) FindObjectsNearByCoordAPI(c *fiber.Ctx) error {
-	var (
-		latStr     = c.Query("lat")
-		lonStr     = c.Query("lon")
-		distanceTo = c.Query("distanceTo")
-	)
-
+// findObjectsNearByCoord performs search for all objects at a distance
+// until n km from coordinates passed in the query and renders them in the negotiated format.
+func (h *Hdls) findObjectsNearByCoord(c *fiber.Ctx, fallback string) error {
+	distanceTo := c.Query("distanceTo")
 	if strings.TrimSpace(distanceTo) == "" {
 		err := fmt.Errorf("distanceTo %v", ErrEmptyParam)
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
-	if strings.TrimSpace(latStr) == "" {
-		err := fmt.Errorf("lat %v", ErrEmptyParam)
-		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
-	}
-
-	if strings.TrimSpace(lonStr) == "" {
-		err := fmt.Errorf("lon %v", ErrEmptyParam)
+	lat, lon, err := parseCoord(c)
+	if err != nil {
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
@@ -164,19 +268,7 @@ func (h *Hdls) FindObjectsNearByCoordAPI(c *fiber.Ctx) error {
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
-	if err != nil {
-		err = fmt.Errorf("error convert lat to decimal number: %v", err)
-		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
-	}
-
-	lon, err := strconv.ParseFloat(lonStr, 64)
-	if err != nil {
-		err = fmt.Errorf("error convert lon to decimal number: %v", err)
-		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
-	}
-
-	cities, err := h.db.FindObjectsNearByCoord(lat, lon, dist)
+	cities, err := h.db.FindObjectsNearByCoord(c.UserContext(), lat, lon, dist)
 	if err != nil {
 		err = fmt.Errorf("error finding cities nearby in database: %v", err)
 		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
@@ -190,21 +282,67 @@ func (h *Hdls) FindObjectsNearByCoordAPI(c *fiber.Ctx) error {
 		})
 	}
 
-	response := struct {
-		CitiesNearby []RespCity `json:"cities_nearby"`
-		DistanceTo   int        `json:"distance_to"`
-		QtyNearby    int        `json:"qty_nearby"`
-	}{
+	return h.render(c, fallback, NearbyResponse{
 		DistanceTo:   dist,
 		QtyNearby:    len(respCities),
 		CitiesNearby: respCities,
+	})
+}
+
+// reverseGeocode performs search for the city nearest to coordinates
+// passed in the query and renders it in the negotiated format.
+func (h *Hdls) reverseGeocode(c *fiber.Ctx, fallback string) error {
+	lat, lon, err := parseCoord(c)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	city, err := h.db.FindNearestCity(c.UserContext(), lat, lon)
+	if err != nil {
+		err = fmt.Errorf("error finding the nearest city in database: %v", err)
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
 	}
 
-	return c.JSON(response)
+	return h.render(c, fallback, ReverseResponse{
+		City:     city,
+		Distance: int(distance.CalcGreatCircle(lat, lon, city.Latitude, city.Longitude)),
+	})
+}
+
+// parseCoord parses the parameters lat and lon of the query.
+func parseCoord(c *fiber.Ctx) (float64, float64, error) {
+	var (
+		latStr = c.Query("lat")
+		lonStr = c.Query("lon")
+	)
+
+	if strings.TrimSpace(latStr) == "" {
+		return 0, 0, fmt.Errorf("lat %v", ErrEmptyParam)
+	}
+
+	if strings.TrimSpace(lonStr) == "" {
+		return 0, 0, fmt.Errorf("lon %v", ErrEmptyParam)
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error convert lat to decimal number: %v", err)
+	}
+
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error convert lon to decimal number: %v", err)
+	}
+
+	return lat, lon, nil
 }
 
 // errorApiRequest performs send status code and message error.
 func (h *Hdls) errorApiRequest(c *fiber.Ctx, code int, err error) error {
+	if code >= fiber.StatusInternalServerError {
+		logging.Logger(c).Errorw("request failed", "error", err.Error())
+	}
+
 	errReq := struct {
 		Message string `json:"message"`
 		Code    int    `json:"code"`
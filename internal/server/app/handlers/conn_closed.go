@@ -0,0 +1,37 @@
+//go:build linux || darwin || freebsd
+
+package handlers
+
+import (
+	"net"
+	"syscall"
+)
+
+// connClosed checks whether the client has closed the connection. The socket is
+// peeked without blocking, so the data of the next request stays in the socket.
+func connClosed(conn net.Conn) bool {
+	if tlsConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	var closed bool
+
+	_ = raw.Control(func(fd uintptr) {
+		var buf [1]byte
+
+		n, _, err := syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = n == 0 && err == nil
+	})
+
+	return closed
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd
+
+package handlers
+
+import "net"
+
+// connClosed always returns false, closing of the connection
+// is not detected on this platform.
+func connClosed(net.Conn) bool {
+	return false
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// pollInterval is the interval of the checks whether the client has closed the connection.
+const pollInterval = 100 * time.Millisecond
+
+// connWatcher checks the connections of the requests in flight by one goroutine
+// and cancels the contexts of the requests whose clients have closed the connection.
+// The goroutine runs only while there are requests to watch.
+type connWatcher struct {
+	mu      sync.Mutex
+	conns   map[net.Conn]context.CancelFunc
+	running bool
+}
+
+// add starts watching the connection of the request.
+func (w *connWatcher) add(conn net.Conn, cancel context.CancelFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conns == nil {
+		w.conns = make(map[net.Conn]context.CancelFunc)
+	}
+
+	w.conns[conn] = cancel
+
+	if !w.running {
+		w.running = true
+		go w.poll()
+	}
+}
+
+// remove stops watching the connection after the request is finished.
+func (w *connWatcher) remove(conn net.Conn) {
+	w.mu.Lock()
+	delete(w.conns, conn)
+	w.mu.Unlock()
+}
+
+// poll checks the watched connections until there are none left.
+func (w *connWatcher) poll() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		w.mu.Lock()
+
+		if len(w.conns) == 0 {
+			w.running = false
+			w.mu.Unlock()
+
+			return
+		}
+
+		for conn, cancel := range w.conns {
+			if connClosed(conn) {
+				cancel()
+				delete(w.conns, conn)
+			}
+		}
+
+		w.mu.Unlock()
+	}
+}
+
+// RequestContext sets the context of the request, which is canceled when the client
+// closes the connection. The context is passed to the queries to the database and
+// other services, so the work for the gone clients is aborted. The shutdown of the
+// server does not cancel it, the requests in flight are finished within the timeout
+// of the shutdown. Closing of the connection is detected only on Linux, macOS and FreeBSD.
+func (h *Hdls) RequestContext(c *fiber.Ctx) error {
+	ctx, cancel := context.WithCancel(c.UserContext())
+	defer cancel()
+
+	conn := c.Context().Conn()
+
+	h.conns.add(conn, cancel)
+	defer h.conns.remove(conn)
+
+	c.SetUserContext(ctx)
+
+	return c.Next()
+}
@@ -0,0 +1,104 @@
+//go:build linux || darwin || freebsd
+
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequestContext(t *testing.T) {
+	h := New(nil, nil)
+	canceled := make(chan error, 1)
+
+	app := fiber.New()
+	app.Use(h.RequestContext)
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		select {
+		case <-c.UserContext().Done():
+			canceled <- c.UserContext().Err()
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+		}
+
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _ = app.Listener(ln) }()
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Fprintf(conn, "GET /slow HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+	// the client disconnects without waiting for the response
+	time.Sleep(2 * pollInterval)
+	conn.Close()
+
+	select {
+	case err := <-canceled:
+		if err == nil {
+			t.Error("context of the request is not canceled after the client disconnected")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler did not return")
+	}
+}
+
+func TestRequestContextShutdown(t *testing.T) {
+	h := New(nil, nil)
+	started, canceled := make(chan struct{}), make(chan error, 1)
+
+	app := fiber.New()
+	app.Use(h.RequestContext)
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+
+		select {
+		case <-c.UserContext().Done():
+			canceled <- c.UserContext().Err()
+		case <-time.After(5 * pollInterval):
+			canceled <- nil
+		}
+
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _ = app.Listener(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET /slow HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	<-started
+
+	// the request in flight is drained by the shutdown
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-canceled; err != nil {
+		t.Errorf("context of the request is canceled by the shutdown: %v", err)
+	}
+}
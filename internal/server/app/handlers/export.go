@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/exporter"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportCities streams the cities selected by the filter in the requested format:
+// geojson (by default), csv, kml or ndjson. Cities can be filtered by parameters
+// country (code or name), timezone and bbox (min lon, min lat, max lon, max lat).
+func (h *Hdls) ExportCities(c *fiber.Ctx) error {
+	format, err := exporter.Lookup(c.Query("format", exporter.FormatGeoJSON))
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	box, err := exporter.ParseBBox(c.Query("bbox"))
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	filter := database.CityFilter{
+		Country:  strings.TrimSpace(c.Query("country")),
+		Timezone: strings.TrimSpace(c.Query("timezone")),
+		BBox:     box,
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="cities`+format.Extension+`"`)
+
+	// the body is written after the handler returns, row by row from the database,
+	// so the context of the request is done by then, the export is stopped
+	// by the failed write when the client disconnects; the status is sent already,
+	// the failure is only logged and the document is left without its end
+	logger := logging.Logger(c)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		count, err := exporter.Run(context.Background(), h.db, filter, format, w)
+		if err != nil {
+			logger.Errorw("export of the cities is interrupted", "error", err, "cities", count)
+		}
+	})
+
+	return nil
+}
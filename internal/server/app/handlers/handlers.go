@@ -3,14 +3,23 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/cache"
+	"github.com/alaleks/geospace/internal/server/config"
 	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/routing"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -21,8 +30,17 @@ var (
 	ErrInvalidAuthentication = errors.New("permission denied, user are not authorization")
 	ErrEmptyParam            = errors.New("parameter cannot be empty")
 	ErrFindCity              = errors.New("city in not found")
-	ErrNotAvailable          = errors.New("no access to service")
-	ErrEmptyResults          = errors.New("was get empty results")
+	ErrNotAvailable          = routing.ErrNotAvailable
+	ErrEmptyResults          = routing.ErrEmptyResults
+	ErrPermissionDenied      = errors.New("permission denied, action is available only to editors")
+
+	errNoCities = errors.New("dataset of the cities is empty")
+)
+
+// keys of the authenticated user stored in the locals of the request
+const (
+	localUID  = "uid"  // id of the user
+	localUser = "user" // models.User read at the authentication
 )
 
 // messages
@@ -31,19 +49,22 @@ var (
 	MsgPing   = "all systems work properly :-)"
 )
 
-// Hdls represents the handlers and includes db instance.
+// Hdls represents the handlers and includes the storage.
 type Hdls struct {
-	db    *database.DB
-	auth  *authentication.Auth
-	agent *fiber.Agent
+	db     database.Store
+	auth   *authentication.Auth
+	mailer mailer.Mailer // nil if the emails are not sent
+	mail   config.Mail
+	state  atomic.Int32   // state of the server for the readiness, StateServing by default
+	conns  connWatcher    // connections of the requests in flight checked for closing
+	mails  sync.WaitGroup // emails sent off the request path
 }
 
 // New creates a new pointer Hdls instance.
-func New(db *database.DB, auth *authentication.Auth) *Hdls {
+func New(db database.Store, auth *authentication.Auth) *Hdls {
 	return &Hdls{
-		db:    db,
-		auth:  auth,
-		agent: fiber.AcquireAgent(),
+		db:   db,
+		auth: auth,
 	}
 }
 
@@ -62,13 +83,13 @@ func (h *Hdls) SignUp(c *fiber.Ctx) error {
 	switch {
 	case user.Email == "":
 		return h.errorBadRequest(c, fmt.Errorf("email cannot be empty"))
-	case !strings.Contains(user.Email, "@"):
-		return h.errorBadRequest(c, fmt.Errorf("email has invalid format"))
+	case !mailer.ValidAddress(user.Email):
+		return h.errorBadRequest(c, ErrInvalidEmail)
 	case user.Password == "":
 		return h.errorBadRequest(c, fmt.Errorf("password cannot be empty"))
 	}
 
-	uid, err := h.db.CreateUser(user.Name, user.Email, h.auth.EncryptPass(user.Password))
+	uid, err := h.createUser(c, user.Name, user.Email, user.Password)
 	if err != nil {
 		return h.errorBadRequest(c, err)
 	}
@@ -107,15 +128,19 @@ func (h *Hdls) Login(c *fiber.Ctx) error {
 		return h.errorBadRequest(c, fmt.Errorf("password cannot be empty"))
 	}
 
-	userDB, err := h.db.GetUser(user.Email)
+	userDB, err := h.db.GetUser(c.UserContext(), user.Email)
 	if err != nil {
+		metrics.AuthFailure(metrics.AuthUnknownUser)
 		return h.errorBadRequest(c, ErrUserNotExists)
 	}
 
 	if !h.auth.CheckPass(user.Password, userDB.Password) {
+		metrics.AuthFailure(metrics.AuthInvalidPassword)
 		return h.errorBadRequest(c, ErrInvalidPassword)
 	}
 
+	h.reencryptPass(c, userDB, user.Password)
+
 	token, err := h.auth.GetTokenJWT(userDB.UID)
 	if err != nil {
 		return h.errorBadRequest(c, err)
@@ -132,16 +157,29 @@ func (h *Hdls) Login(c *fiber.Ctx) error {
 
 // GetCountry returns list country with country code.
 func (h *Hdls) GetCountry(c *fiber.Ctx) error {
-	var countries []string
-
-	err := h.db.SQLX.Select(&countries,
-		`SELECT DISTINCT CONCAT(country_code, ": ", country) AS country 
-		FROM cities WHERE country <> "" ORDER BY country;`)
+	countries, err := h.db.ListCountries(c.UserContext())
 	if err != nil {
 		return h.errorBadRequest(c, err)
 	}
 
-	return c.SendString(strings.Join(countries, ","))
+	list := make([]string, 0, len(countries))
+	for _, country := range countries {
+		list = append(list, country.Code+": "+country.Name)
+	}
+
+	return c.SendString(strings.Join(list, ","))
+}
+
+// reencryptPass encrypts the password of the user by the current key of the cipher
+// if it was encrypted by the previous one, the failure does not fail the login.
+func (h *Hdls) reencryptPass(c *fiber.Ctx, user models.User, pass string) {
+	if h.auth.IsCurrentPass(pass, user.Password) {
+		return
+	}
+
+	if err := h.db.UpdatePassword(c.UserContext(), user.UID, h.auth.EncryptPass(pass)); err != nil {
+		logging.Logger(c).Errorw("password is not encrypted by the current key", "error", err)
+	}
 }
 
 // Logout performs exit user.
@@ -155,10 +193,28 @@ func (h *Hdls) Logout(c *fiber.Ctx) error {
 	return c.SendString(MsgLogout)
 }
 
+// GetJWKS returns the public keys verifying the tokens, the services cache them
+// and fetch again when the token has the unknown id of the key.
+func (h *Hdls) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(h.auth.JWKS())
+}
+
 // CheckAuthentication checks token validity.
 // Token can be provided in Cookie access_token
 // or in Header Authorization as Bearer token.
 func (h *Hdls) CheckAuthentication(c *fiber.Ctx) error {
+	if err := h.authenticate(c); err != nil {
+		return h.errorAuth(c, err)
+	}
+
+	return c.Next()
+}
+
+// authenticate checks the token of the request and stores the user and its id in the locals.
+// The tokens issued before the last change of the password are rejected.
+func (h *Hdls) authenticate(c *fiber.Ctx) error {
 	var token string
 
 	if strings.HasPrefix(c.Get("Authorization"), "Bearer ") {
@@ -168,11 +224,36 @@ func (h *Hdls) CheckAuthentication(c *fiber.Ctx) error {
 	}
 
 	if strings.TrimSpace(token) == "" {
-		return h.errorAuth(c, ErrInvalidAuthentication)
+		metrics.AuthFailure(metrics.AuthMissingToken)
+		return ErrInvalidAuthentication
+	}
+
+	user, err := h.auth.CheckTokenUser(c.UserContext(), token)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		metrics.AuthFailure(metrics.AuthUnknownUser)
+		return ErrInvalidAuthentication
+	case err != nil:
+		metrics.AuthFailure(metrics.AuthInvalidToken)
+		return ErrInvalidAuthentication
 	}
 
-	if err := h.auth.CheckToken(token); err != nil {
-		return h.errorAuth(c, ErrInvalidAuthentication)
+	c.Locals(localUID, user.UID)
+	c.Locals(localUser, user)
+	logging.SetUser(c, user.UID)
+
+	return nil
+}
+
+// CheckEditor checks that the authenticated user has the editor role.
+// It must be used after CheckAuthentication.
+func (h *Hdls) CheckEditor(c *fiber.Ctx) error {
+	uid, _ := c.Locals(localUID).(int)
+
+	user, err := h.db.GetUserByID(c.UserContext(), uid)
+	if err != nil || user.Role != models.RoleEditor {
+		metrics.AuthFailure(metrics.AuthForbidden)
+		return h.errorApiRequest(c, fiber.StatusForbidden, ErrPermissionDenied)
 	}
 
 	return c.Next()
@@ -180,7 +261,8 @@ func (h *Hdls) CheckAuthentication(c *fiber.Ctx) error {
 
 // Ping performs check work server.
 func (h *Hdls) Ping(c *fiber.Ctx) error {
-	if err := h.db.SQLX.Ping(); err != nil {
+	if err := h.db.Ping(c.UserContext()); err != nil {
+		logging.Logger(c).Errorw("database is down", "error", err.Error())
 		return c.Status(fiber.StatusInternalServerError).
 			SendString(fmt.Errorf("database is down: %v", err).Error())
 	}
@@ -188,6 +270,26 @@ func (h *Hdls) Ping(c *fiber.Ctx) error {
 	return c.SendString(MsgPing)
 }
 
+// GetPoolStats returns the statistics of the pools of connections to the database servers.
+func (h *Hdls) GetPoolStats(c *fiber.Ctx) error {
+	reporter, ok := h.db.(database.PoolReporter)
+	if !ok {
+		return c.JSON([]database.PoolStats{})
+	}
+
+	return c.JSON(reporter.PoolStats())
+}
+
+// GetCacheStats returns the hits and misses of the cache.
+func (h *Hdls) GetCacheStats(c *fiber.Ctx) error {
+	reporter, ok := h.db.(cache.Reporter)
+	if !ok {
+		return c.JSON(map[string]cache.Stats{})
+	}
+
+	return c.JSON(reporter.CacheStats())
+}
+
 // errorBadRequest performs send status code 400 and error.
 func (h *Hdls) errorBadRequest(c *fiber.Ctx, err error) error {
 	return c.Status(fiber.StatusBadRequest).SendString(err.Error())
@@ -199,66 +301,6 @@ func (h *Hdls) errorAuth(c *fiber.Ctx, err error) error {
 }
 
 // getDistancebyRoad getting distance between two points by road using api OpenStreetMap.
-func (h *Hdls) getDistancebyRoad(lon1, lat1, lon2, lat2 float64) (int, error) {
-	url := fmt.Sprintf("http://router.project-osrm.org/route/v1/driving/%f,%f;%f,%f?overview=false",
-		lon1, lat1, lon2, lat2)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	var (
-		chErr = make(chan error, 1)
-		dist  = make(chan float64, 1)
-	)
-
-	go func() {
-		req := h.agent.Request()
-		req.Header.SetMethod(fiber.MethodGet)
-		req.SetRequestURI(url)
-
-		if err := h.agent.Parse(); err != nil {
-			chErr <- err
-			return
-		}
-
-		code, body, _ := h.agent.Bytes()
-		if code != 200 {
-			chErr <- ErrNotAvailable
-			return
-		}
-
-		var response struct {
-			Code   string `json:"code"`
-			Routes []struct {
-				Distance float64 `json:"distance"`
-			}
-		}
-
-		err := json.Unmarshal(body, &response)
-		if err != nil {
-			chErr <- err
-			return
-		}
-
-		if response.Code != "Ok" {
-			chErr <- err
-			return
-		}
-
-		if len(response.Routes) == 0 {
-			chErr <- ErrEmptyResults
-			return
-		}
-
-		dist <- response.Routes[0].Distance
-	}()
-
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	case err := <-chErr:
-		return 0, err
-	case result := <-dist:
-		return int(result / 1000), nil
-	}
+func (h *Hdls) getDistancebyRoad(ctx context.Context, lon1, lat1, lon2, lat2 float64) (int, error) {
+	return routing.Distance(ctx, lon1, lat1, lon2, lat2)
 }
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/gofiber/fiber/v2"
+)
+
+// states of the server for the readiness
+const (
+	StateServing      int32 = iota // the server serves the requests
+	StateImporting                 // the initial import of the cities is running
+	StateShuttingDown              // the server is stopping, new requests must go to other instances
+)
+
+// statuses of the readiness and the components
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusUp       = "up"
+	StatusDown     = "down"
+)
+
+// checkTimeout is the maximum time of the check of one component.
+const checkTimeout = 2 * time.Second
+
+// stateNames are the names of the states in the response of the readiness.
+var stateNames = map[int32]string{
+	StateServing:      "serving",
+	StateImporting:    "importing",
+	StateShuttingDown: "shutting_down",
+}
+
+// Component is the result of the check of the component of the server.
+type Component struct {
+	Name     string `json:"name"`            // database, replica address, cities or routing
+	Status   string `json:"status"`          // StatusUp or StatusDown
+	Critical bool   `json:"critical"`        // the server is not ready if the critical component is down
+	Error    string `json:"error,omitempty"` // fixed reason of the failure, the cause is written to the log
+}
+
+// ReadinessResponse is the response of the readiness.
+type ReadinessResponse struct {
+	Status     string      `json:"status"` // StatusReady or StatusNotReady
+	State      string      `json:"state"`  // serving, importing or shutting_down
+	Components []Component `json:"components"`
+}
+
+// SetState sets the state of the server, the server is ready only in StateServing.
+// The shutdown is final, the state is not changed after it.
+func (h *Hdls) SetState(state int32) {
+	for {
+		old := h.state.Load()
+		if old == StateShuttingDown || h.state.CompareAndSwap(old, state) {
+			return
+		}
+	}
+}
+
+// Healthz reports that the process is alive, it does not check the dependencies,
+// so the process is not restarted when the database is down.
+func (h *Hdls) Healthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": StatusUp})
+}
+
+// Readyz reports whether the server can serve the requests. The primary database
+// and the loaded cities are critical, the read replicas and the routing service
+// are not: the reads fall back to the primary and the distance by road is optional.
+// The server is not ready while the initial import runs and during the shutdown.
+func (h *Hdls) Readyz(c *fiber.Ctx) error {
+	state := h.state.Load()
+	components := h.checkComponents(c)
+
+	resp := ReadinessResponse{
+		Status:     StatusReady,
+		State:      stateNames[state],
+		Components: components,
+	}
+
+	if state != StateServing {
+		resp.Status = StatusNotReady
+	}
+
+	for _, component := range components {
+		if component.Critical && component.Status != StatusUp {
+			resp.Status = StatusNotReady
+		}
+	}
+
+	if resp.Status != StatusReady {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+
+	return c.JSON(resp)
+}
+
+// checkComponents checks the components concurrently. The probe is not authenticated,
+// so the errors of the drivers are written to the log and the response has the fixed
+// reasons only, the addresses of the servers and the queries are not disclosed.
+func (h *Hdls) checkComponents(c *fiber.Ctx) []Component {
+	ctx, cancel := context.WithTimeout(c.UserContext(), checkTimeout)
+	defer cancel()
+
+	checks := []struct {
+		name     string
+		critical bool
+		reason   string
+		check    func(context.Context) error
+	}{
+		{name: "database", critical: true, reason: "database is not available", check: h.db.Ping},
+		{name: "cities", critical: true, reason: "dataset of the cities is not loaded", check: h.checkCities},
+		{name: "routing", reason: "routing service is not available", check: routing.Ping},
+	}
+
+	components := make([]Component, len(checks))
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func(i int, check func(context.Context) error) {
+			defer wg.Done()
+
+			errs[i] = check(ctx)
+		}(i, check.check)
+	}
+
+	wg.Wait()
+
+	for i, check := range checks {
+		components[i] = Component{Name: check.name, Status: StatusUp, Critical: check.critical}
+
+		if errs[i] != nil {
+			components[i].Status, components[i].Error = StatusDown, check.reason
+			logging.Logger(c).Warnw("component is down", "component", check.name, "error", errs[i])
+		}
+	}
+
+	// replicas are checked by the storage in the background and named by the number,
+	// their addresses are in /v1/editor/database/pools
+	if reporter, ok := h.db.(database.PoolReporter); ok {
+		n := 0
+
+		for _, pool := range reporter.PoolStats() {
+			if pool.Role != database.RoleReplica {
+				continue
+			}
+
+			n++
+
+			component := Component{Name: fmt.Sprintf("replica %d", n), Status: StatusUp}
+			if !pool.Healthy {
+				component.Status, component.Error = StatusDown, "health check failed"
+			}
+
+			components = append(components, component)
+		}
+	}
+
+	return components
+}
+
+// checkCities checks that the dataset of the cities is loaded.
+func (h *Hdls) checkCities(ctx context.Context) error {
+	count, err := h.db.CountCities(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errNoCities
+	}
+
+	return nil
+}
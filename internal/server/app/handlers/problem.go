@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/logging"
+	"github.com/alaleks/geospace/internal/server/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEProblem is the media type of the errors of v2 (RFC 7807).
+const MIMEProblem = "application/problem+json"
+
+// prefix of the types of the problems
+const problemTypePrefix = "urn:geospace:problem:"
+
+// ErrInvalidParam is returned when the parameter of the request has invalid value.
+var ErrInvalidParam = errors.New("parameter is invalid")
+
+// Problem represents the error of v2 in the format of RFC 7807.
+type Problem struct {
+	Type       string        `json:"type"`
+	Title      string        `json:"title"`
+	Detail     string        `json:"detail,omitempty"`
+	Instance   string        `json:"instance,omitempty"`
+	Candidates []models.City `json:"candidates,omitempty"` // cities matching the ambiguous name
+	Status     int           `json:"status"`
+}
+
+// problemTypes maps the domain errors to the problems.
+var problemTypes = []struct {
+	err    error
+	name   string
+	title  string
+	status int
+}{
+	{database.ErrCityNotFound, "city-not-found", "City is not found", fiber.StatusNotFound},
+	{ErrNotAvailable, "provider-unavailable", "External provider is not available", fiber.StatusServiceUnavailable},
+	{ErrEmptyParam, "invalid-parameter", "Invalid parameter", fiber.StatusBadRequest},
+	{ErrInvalidParam, "invalid-parameter", "Invalid parameter", fiber.StatusBadRequest},
+	{openapi.ErrInvalidRequest, "invalid-parameter", "Invalid parameter", fiber.StatusBadRequest},
+	{ErrNotAcceptable, "not-acceptable", "Format is not acceptable", fiber.StatusNotAcceptable},
+	{ErrInvalidAuthentication, "unauthorized", "Unauthorized", fiber.StatusUnauthorized},
+	{ErrUserNotExists, "invalid-credentials", "Invalid credentials", fiber.StatusUnauthorized},
+	{ErrInvalidPassword, "invalid-credentials", "Invalid credentials", fiber.StatusUnauthorized},
+	{database.ErrUserAlreadyExists, "user-exists", "User already exists", fiber.StatusConflict},
+	{ErrPermissionDenied, "forbidden", "Forbidden", fiber.StatusForbidden},
+	{ErrNotVerified, "email-not-verified", "Email is not verified", fiber.StatusForbidden},
+}
+
+// Problems converts the errors returned by the handlers of v2 to application/problem+json.
+// Errors unknown to the application are reported as internal errors without details,
+// so messages of the database do not leak to clients.
+func (h *Hdls) Problems(c *fiber.Ctx) error {
+	err := c.Next()
+	if err == nil {
+		return nil
+	}
+
+	problem := newProblem(err)
+	problem.Instance = c.Path()
+
+	// details of the unexpected errors are not sent to the client
+	if problem.Status >= fiber.StatusInternalServerError {
+		logging.Logger(c).Errorw("request failed", "error", err.Error())
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, MIMEProblem)
+
+	return c.Status(problem.Status).Send(body)
+}
+
+// InvalidRequest sends the error of validation of the request to v1.
+func (h *Hdls) InvalidRequest(c *fiber.Ctx, err error) error {
+	return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+}
+
+// InvalidRequestV2 passes the error of validation of the request
+// to Problems to be sent as the problem.
+func (h *Hdls) InvalidRequestV2(_ *fiber.Ctx, err error) error {
+	return err
+}
+
+// newProblem returns the problem describing the error.
+func newProblem(err error) Problem {
+	var (
+		ambiguous *database.AmbiguousCityError
+		fiberErr  *fiber.Error
+	)
+
+	switch {
+	case errors.As(err, &ambiguous):
+		return Problem{
+			Type:       problemTypePrefix + "ambiguous-city",
+			Title:      "Name of the city is ambiguous",
+			Status:     fiber.StatusConflict,
+			Detail:     err.Error(),
+			Candidates: ambiguous.Candidates,
+		}
+	case errors.As(err, &fiberErr):
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(fiberErr.Code),
+			Status: fiberErr.Code,
+			Detail: fiberErr.Message,
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(fiber.StatusNotFound),
+			Status: fiber.StatusNotFound,
+		}
+	}
+
+	for _, pt := range problemTypes {
+		if errors.Is(err, pt.err) {
+			return Problem{
+				Type:   problemTypePrefix + pt.name,
+				Title:  pt.title,
+				Status: pt.status,
+				Detail: err.Error(),
+			}
+		}
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(fiber.StatusInternalServerError),
+		Status: fiber.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/exporter"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// formats of the responses, can be requested by the parameter format
+const (
+	FormatJSON    = "json"
+	FormatGeoJSON = "geojson"
+	FormatCSV     = "csv"
+	FormatMsgPack = "msgpack"
+	FormatText    = "text"
+)
+
+// media types of the formats
+const (
+	MIMEGeoJSON  = "application/geo+json"
+	MIMECSV      = "text/csv"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEXMsgPack = "application/x-msgpack"
+)
+
+// ErrNotAcceptable is returned when the requested format is not supported.
+var ErrNotAcceptable = errors.New("format of the response is not supported")
+
+// mediaTypes contains the media types of the formats in order of preference.
+var mediaTypes = []struct {
+	format string
+	mime   string
+}{
+	{FormatJSON, fiber.MIMEApplicationJSON},
+	{FormatGeoJSON, MIMEGeoJSON},
+	{FormatCSV, MIMECSV},
+	{FormatMsgPack, MIMEMsgPack},
+	{FormatMsgPack, MIMEXMsgPack},
+	{FormatText, fiber.MIMETextPlain},
+}
+
+// cityHeader is the header of the columns of the city in CSV responses.
+var cityHeader = []string{
+	"city_id", "name", "name_ascii", "country_code", "country",
+	"timezone", "latitude", "longitude",
+}
+
+// response is a response of the API which can be rendered in every format.
+// JSON and MessagePack are encoded from the struct itself.
+type response interface {
+	// features returns the cities of the response as GeoJSON point features.
+	features() []exporter.Feature
+	// table returns the rows of the CSV document including header.
+	table() [][]string
+	// text returns the response in a human readable form.
+	text() string
+}
+
+// featureCollection represents the GeoJSON FeatureCollection.
+type featureCollection struct {
+	Type     string             `json:"type"`
+	Features []exporter.Feature `json:"features"`
+}
+
+// negotiate returns the format of the response. The parameter format takes
+// precedence over the header Accept, fallback is used when the client accepts any format.
+func negotiate(c *fiber.Ctx, fallback string) (string, error) {
+	if format := c.Query("format"); format != "" {
+		for _, mt := range mediaTypes {
+			if mt.format == format {
+				return format, nil
+			}
+		}
+
+		return "", fmt.Errorf("%w: %s", ErrNotAcceptable, format)
+	}
+
+	// the fallback goes first, so it is chosen for */* and for the empty header
+	offers := make([]string, 0, len(mediaTypes))
+	for _, mt := range mediaTypes {
+		if mt.format == fallback {
+			offers = append([]string{mt.mime}, offers...)
+		} else {
+			offers = append(offers, mt.mime)
+		}
+	}
+
+	accepted := c.Accepts(offers...)
+	for _, mt := range mediaTypes {
+		if mt.mime == accepted {
+			return mt.format, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNotAcceptable, c.Get(fiber.HeaderAccept))
+}
+
+// render sends the response in the format negotiated with the client.
+func (h *Hdls) render(c *fiber.Ctx, fallback string, resp response) error {
+	c.Vary(fiber.HeaderAccept)
+
+	format, err := negotiate(c, fallback)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusNotAcceptable, err)
+	}
+
+	return encode(c, format, resp, resp)
+}
+
+// encode sends the response in the format. JSON and MessagePack encode data,
+// which is either the response itself or the response wrapped in the envelope.
+func encode(c *fiber.Ctx, format string, resp response, data any) error {
+	switch format {
+	case FormatGeoJSON:
+		body, err := json.Marshal(featureCollection{Type: "FeatureCollection", Features: resp.features()})
+		if err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, MIMEGeoJSON)
+
+		return c.Send(body)
+	case FormatCSV:
+		var buf bytes.Buffer
+
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(resp.table()); err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, MIMECSV+"; charset=utf-8")
+
+		return c.Send(buf.Bytes())
+	case FormatMsgPack:
+		var buf bytes.Buffer
+
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, MIMEMsgPack)
+
+		return c.Send(buf.Bytes())
+	case FormatText:
+		return c.SendString(resp.text())
+	default:
+		return c.JSON(data)
+	}
+}
+
+// cityFeature converts the city to the GeoJSON feature with additional properties.
+func cityFeature(city models.City, properties map[string]any) exporter.Feature {
+	feature := exporter.NewFeature(city)
+	for k, v := range properties {
+		feature.Properties[k] = v
+	}
+
+	return feature
+}
+
+// cityRow converts the city to the CSV row in order of cityHeader.
+func cityRow(city models.City) []string {
+	return []string{
+		strconv.Itoa(city.ID), city.Name, city.NameASCII, city.CountryCode, city.Country,
+		city.Timezone, strconv.FormatFloat(city.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(city.Longitude, 'f', -1, 64),
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var nearby = NearbyResponse{
+	Departure: &models.City{ID: 1, Name: "Rome", Country: "Italy", Latitude: 41.89193, Longitude: 12.51133},
+	CitiesNearby: []RespCity{
+		{City: models.City{ID: 2, Name: "Tivoli", Country: "Italy", Latitude: 41.95952, Longitude: 12.80160}, Distance: 25},
+		{City: models.City{ID: 3, Name: "Frascati", Country: "Italy", Latitude: 41.80816, Longitude: 12.67560}, Distance: 16},
+	},
+	DistanceTo: 30,
+	QtyNearby:  2,
+}
+
+func TestRender(t *testing.T) {
+	h := &Hdls{}
+
+	srv := fiber.New()
+	srv.Get("/api", func(c *fiber.Ctx) error { return h.render(c, FormatJSON, nearby) })
+	srv.Get("/user", func(c *fiber.Ctx) error { return h.render(c, FormatText, nearby) })
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		code        int
+		contentType string
+		check       func(t *testing.T, body []byte)
+	}{
+		{
+			name: "JSON by default", target: "/api", code: fiber.StatusOK,
+			contentType: fiber.MIMEApplicationJSON,
+			check: func(t *testing.T, body []byte) {
+				var resp NearbyResponse
+				if err := json.Unmarshal(body, &resp); err != nil || resp.QtyNearby != 2 {
+					t.Errorf("invalid JSON %s: %v", body, err)
+				}
+			},
+		},
+		{
+			name: "Text by default", target: "/user", accept: "*/*", code: fiber.StatusOK,
+			contentType: fiber.MIMETextPlain,
+			check: func(t *testing.T, body []byte) {
+				if !strings.HasPrefix(string(body), "There are 2 cities at a distance 30 km from Rome, Italy") {
+					t.Errorf("invalid text: %s", body)
+				}
+			},
+		},
+		{
+			name: "GeoJSON by Accept", target: "/user", accept: "application/geo+json", code: fiber.StatusOK,
+			contentType: MIMEGeoJSON,
+			check: func(t *testing.T, body []byte) {
+				var fc featureCollection
+				if err := json.Unmarshal(body, &fc); err != nil || len(fc.Features) != 2 {
+					t.Fatalf("invalid GeoJSON %s: %v", body, err)
+				}
+
+				if fc.Features[0].Properties["distance"] != float64(25) {
+					t.Errorf("invalid distance: %v", fc.Features[0].Properties["distance"])
+				}
+			},
+		},
+		{
+			name: "CSV by parameter", target: "/api?format=csv", accept: fiber.MIMEApplicationJSON,
+			code: fiber.StatusOK, contentType: MIMECSV + "; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+				if err != nil || len(rows) != 3 || rows[2][1] != "Frascati" || rows[2][8] != "16" {
+					t.Errorf("invalid CSV %q: %v", rows, err)
+				}
+			},
+		},
+		{
+			name: "MessagePack", target: "/api", accept: "application/x-msgpack", code: fiber.StatusOK,
+			contentType: MIMEMsgPack,
+			check: func(t *testing.T, body []byte) {
+				var resp map[string]any
+				if err := msgpack.Unmarshal(body, &resp); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, ok := resp["cities_nearby"]; !ok {
+					t.Errorf("keys must be named as in JSON: %v", resp)
+				}
+			},
+		},
+		{
+			name: "Unknown parameter", target: "/api?format=xml", code: fiber.StatusNotAcceptable,
+			contentType: fiber.MIMEApplicationJSON,
+		},
+		{
+			name: "Not acceptable", target: "/api", accept: "image/png", code: fiber.StatusNotAcceptable,
+			contentType: fiber.MIMEApplicationJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+
+			resp, err := srv.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, resp.StatusCode)
+			}
+
+			if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("expected content type %s, got %s", tt.contentType, ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+		})
+	}
+}
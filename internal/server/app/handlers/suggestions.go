@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// typical errors of suggestions
+var (
+	ErrInvalidLatitude    = errors.New("latitude must be between -90 and 90")
+	ErrInvalidLongitude   = errors.New("longitude must be between -180 and 180")
+	ErrInvalidCountryCode = errors.New("country code must contain 2 letters")
+	ErrEmptySuggestion    = errors.New("suggestion does not change the city")
+)
+
+// SuggestionReview represents a suggestion with the current state
+// of the city and the list of changes.
+type SuggestionReview struct {
+	Current    *models.City         `json:"current"`
+	Changes    []models.FieldChange `json:"changes"`
+	Suggestion models.Suggestion    `json:"suggestion"`
+}
+
+// CreateSuggestion provides submitting a correction of the city
+// or a new city by the user. For a correction city_id must be passed
+// and only changed fields can be filled.
+func (h *Hdls) CreateSuggestion(c *fiber.Ctx) error {
+	var req struct {
+		Latitude         *float64 `json:"latitude"`
+		Longitude        *float64 `json:"longitude"`
+		Name             string   `json:"name"`
+		NameASCII        string   `json:"name_ascii"`
+		AlternativeNames string   `json:"alternative_names"`
+		CountryCode      string   `json:"country_code"`
+		Country          string   `json:"country"`
+		Timezone         string   `json:"timezone"`
+		Comment          string   `json:"comment"`
+		CityID           int      `json:"city_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	var current models.City
+
+	if req.CityID != 0 {
+		city, err := h.db.GetCity(c.UserContext(), req.CityID)
+		if err != nil {
+			return h.errorApiRequest(c, fiber.StatusNotFound, database.ErrCityNotFound)
+		}
+
+		current = city
+	} else {
+		switch {
+		case strings.TrimSpace(req.Name) == "":
+			return h.errorApiRequest(c, fiber.StatusBadRequest, fmt.Errorf("name %v", ErrEmptyParam))
+		case strings.TrimSpace(req.CountryCode) == "":
+			return h.errorApiRequest(c, fiber.StatusBadRequest, fmt.Errorf("country_code %v", ErrEmptyParam))
+		case req.Latitude == nil:
+			return h.errorApiRequest(c, fiber.StatusBadRequest, fmt.Errorf("latitude %v", ErrEmptyParam))
+		case req.Longitude == nil:
+			return h.errorApiRequest(c, fiber.StatusBadRequest, fmt.Errorf("longitude %v", ErrEmptyParam))
+		}
+	}
+
+	// fields which were not passed stay the same as in the current city
+	proposed := current
+	overlay := func(dst *string, src string) {
+		if strings.TrimSpace(src) != "" {
+			*dst = strings.TrimSpace(src)
+		}
+	}
+
+	overlay(&proposed.Name, req.Name)
+	overlay(&proposed.NameASCII, req.NameASCII)
+	overlay(&proposed.AlternativeNames, req.AlternativeNames)
+	overlay(&proposed.CountryCode, strings.ToUpper(req.CountryCode))
+	overlay(&proposed.Country, req.Country)
+	overlay(&proposed.Timezone, req.Timezone)
+
+	if req.Latitude != nil {
+		proposed.Latitude = *req.Latitude
+	}
+
+	if req.Longitude != nil {
+		proposed.Longitude = *req.Longitude
+	}
+
+	switch {
+	case proposed.Latitude < -90 || proposed.Latitude > 90:
+		return h.errorApiRequest(c, fiber.StatusBadRequest, ErrInvalidLatitude)
+	case proposed.Longitude < -180 || proposed.Longitude > 180:
+		return h.errorApiRequest(c, fiber.StatusBadRequest, ErrInvalidLongitude)
+	case len(proposed.CountryCode) != 2:
+		return h.errorApiRequest(c, fiber.StatusBadRequest, ErrInvalidCountryCode)
+	}
+
+	suggestion := models.Suggestion{
+		UID:              c.Locals(localUID).(int),
+		CityID:           req.CityID,
+		Name:             proposed.Name,
+		NameASCII:        proposed.NameASCII,
+		AlternativeNames: proposed.AlternativeNames,
+		CountryCode:      proposed.CountryCode,
+		Country:          proposed.Country,
+		Timezone:         proposed.Timezone,
+		Latitude:         proposed.Latitude,
+		Longitude:        proposed.Longitude,
+		Comment:          strings.TrimSpace(req.Comment),
+	}
+
+	if req.CityID != 0 {
+		changes := suggestion.Diff(current)
+		if len(changes) == 0 {
+			return h.errorApiRequest(c, fiber.StatusBadRequest, ErrEmptySuggestion)
+		}
+
+		// only changed fields are applied on the approval,
+		// so edits of other fields made meanwhile are kept
+		fields := make([]string, 0, len(changes))
+		for _, ch := range changes {
+			fields = append(fields, ch.Field)
+		}
+
+		suggestion.Fields = strings.Join(fields, ",")
+	}
+
+	sid, err := h.db.CreateSuggestion(c.UserContext(), suggestion)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+
+	suggestion, err = h.db.GetSuggestion(c.UserContext(), sid)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(suggestion)
+}
+
+// ListUserSuggestions returns the suggestions of the current user.
+func (h *Hdls) ListUserSuggestions(c *fiber.Ctx) error {
+	suggestions, err := h.db.ListSuggestions(c.UserContext(), c.Query("status"), c.Locals(localUID).(int))
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(suggestions)
+}
+
+// ListSuggestionsForReview returns the suggestions with the differences
+// against the current cities. By default only pending suggestions are returned.
+func (h *Hdls) ListSuggestionsForReview(c *fiber.Ctx) error {
+	suggestions, err := h.db.ListSuggestions(c.UserContext(), c.Query("status", models.SuggestionPending), 0)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+
+	reviews := make([]SuggestionReview, 0, len(suggestions))
+	for _, s := range suggestions {
+		reviews = append(reviews, h.reviewSuggestion(c.UserContext(), s))
+	}
+
+	return c.JSON(reviews)
+}
+
+// GetSuggestionForReview returns the suggestion with the difference
+// against the current city.
+func (h *Hdls) GetSuggestionForReview(c *fiber.Ctx) error {
+	sid, err := c.ParamsInt("id")
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	s, err := h.db.GetSuggestion(c.UserContext(), sid)
+	if err != nil {
+		return h.errorSuggestion(c, err)
+	}
+
+	return c.JSON(h.reviewSuggestion(c.UserContext(), s))
+}
+
+// ApproveSuggestion applies the suggestion to the city data.
+func (h *Hdls) ApproveSuggestion(c *fiber.Ctx) error {
+	sid, comment, err := h.parseReview(c)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	city, err := h.db.ApproveSuggestion(c.UserContext(), sid, c.Locals(localUID).(int), comment)
+	if err != nil {
+		return h.errorSuggestion(c, err)
+	}
+
+	return c.JSON(city)
+}
+
+// RejectSuggestion rejects the suggestion without changes of the city data.
+func (h *Hdls) RejectSuggestion(c *fiber.Ctx) error {
+	sid, comment, err := h.parseReview(c)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	err = h.db.RejectSuggestion(c.UserContext(), sid, c.Locals(localUID).(int), comment)
+	if err != nil {
+		return h.errorSuggestion(c, err)
+	}
+
+	s, err := h.db.GetSuggestion(c.UserContext(), sid)
+	if err != nil {
+		return h.errorSuggestion(c, err)
+	}
+
+	return c.JSON(s)
+}
+
+// GetCityAudit returns the audit trail of the city.
+func (h *Hdls) GetCityAudit(c *fiber.Ctx) error {
+	cid, err := c.ParamsInt("id")
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusBadRequest, err)
+	}
+
+	records, err := h.db.ListAudit(c.UserContext(), database.AuditEntityCity, cid)
+	if err != nil {
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(records)
+}
+
+// reviewSuggestion compares the suggestion with the current city.
+func (h *Hdls) reviewSuggestion(ctx context.Context, s models.Suggestion) SuggestionReview {
+	review := SuggestionReview{Suggestion: s}
+
+	var current models.City
+	if s.CityID != 0 {
+		city, err := h.db.GetCity(ctx, s.CityID)
+		if err == nil {
+			current = city
+			review.Current = &city
+		}
+	}
+
+	review.Changes = s.Diff(current)
+
+	return review
+}
+
+// parseReview returns id of the suggestion and comment of the editor.
+func (h *Hdls) parseReview(c *fiber.Ctx) (int, string, error) {
+	sid, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, "", err
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return 0, "", err
+		}
+	}
+
+	return sid, strings.TrimSpace(req.Comment), nil
+}
+
+// errorSuggestion performs send status code depending on the error of suggestion.
+func (h *Hdls) errorSuggestion(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, database.ErrSuggestionNotFound), errors.Is(err, database.ErrCityNotFound):
+		return h.errorApiRequest(c, fiber.StatusNotFound, err)
+	case errors.Is(err, database.ErrSuggestionReviewed):
+		return h.errorApiRequest(c, fiber.StatusConflict, err)
+	default:
+		return h.errorApiRequest(c, fiber.StatusInternalServerError, err)
+	}
+}
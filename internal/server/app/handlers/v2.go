@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/pkg/distance"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Envelope wraps the data of the successful responses of v2.
+type Envelope struct {
+	Data any `json:"data"`
+}
+
+// SignUpRequest represents the request of registration.
+type SignUpRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginRequest represents the request of authentication.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// TokenResponse contains the token of the user.
+type TokenResponse struct {
+	Token string `json:"token"`
+}
+
+// DistanceRequest represents the request of the distance between two cities.
+// If Road is set, the distance by road is required and the request fails
+// when the routing provider is not available.
+type DistanceRequest struct {
+	Departure   string `query:"departure"`
+	Destination string `query:"destination"`
+	Road        bool   `query:"road"`
+}
+
+// NearbyRequest represents the request of the cities near the city of departure
+// or near the coordinates.
+type NearbyRequest struct {
+	Lat       *float64 `query:"lat"`
+	Lon       *float64 `query:"lon"`
+	Departure string   `query:"departure"`
+	Distance  int      `query:"distance"`
+}
+
+// ReverseRequest represents the request of the city nearest to the coordinates.
+type ReverseRequest struct {
+	Lat *float64 `query:"lat"`
+	Lon *float64 `query:"lon"`
+}
+
+func (r SignUpRequest) validate() error {
+	if err := validateCredentials(r.Email, r.Password); err != nil {
+		return err
+	}
+
+	if !mailer.ValidAddress(r.Email) {
+		return fmt.Errorf("%w: %v", ErrInvalidParam, ErrInvalidEmail)
+	}
+
+	return nil
+}
+
+func (r LoginRequest) validate() error {
+	return validateCredentials(r.Email, r.Password)
+}
+
+func (r DistanceRequest) validate() error {
+	switch {
+	case strings.TrimSpace(r.Departure) == "":
+		return fmt.Errorf("departure %w", ErrEmptyParam)
+	case strings.TrimSpace(r.Destination) == "":
+		return fmt.Errorf("destination %w", ErrEmptyParam)
+	}
+
+	return nil
+}
+
+func (r NearbyRequest) validate() error {
+	switch {
+	case r.Distance <= 0:
+		return fmt.Errorf("%w: distance must be a positive number of km", ErrInvalidParam)
+	case strings.TrimSpace(r.Departure) != "":
+		return nil
+	}
+
+	return validateCoord(r.Lat, r.Lon)
+}
+
+func (r ReverseRequest) validate() error {
+	return validateCoord(r.Lat, r.Lon)
+}
+
+// SignUpV2 provides registration a new user.
+func (h *Hdls) SignUpV2(c *fiber.Ctx) error {
+	var req SignUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParam, err)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	uid, err := h.createUser(c, req.Name, req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	token, err := h.auth.GetTokenJWT(uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Envelope{Data: TokenResponse{Token: token}})
+}
+
+// LoginV2 provides authentification user.
+func (h *Hdls) LoginV2(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParam, err)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	user, err := h.db.GetUser(c.UserContext(), req.Email)
+	if err != nil {
+		metrics.AuthFailure(metrics.AuthUnknownUser)
+		return ErrUserNotExists
+	}
+
+	if !h.auth.CheckPass(req.Password, user.Password) {
+		metrics.AuthFailure(metrics.AuthInvalidPassword)
+		return ErrInvalidPassword
+	}
+
+	h.reencryptPass(c, user, req.Password)
+
+	token, err := h.auth.GetTokenJWT(user.UID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(Envelope{Data: TokenResponse{Token: token}})
+}
+
+// Authenticate checks token validity like CheckAuthentication,
+// but returns the error to be rendered as the problem.
+func (h *Hdls) Authenticate(c *fiber.Ctx) error {
+	if err := h.authenticate(c); err != nil {
+		return err
+	}
+
+	return c.Next()
+}
+
+// DistanceV2 performs a distance between two cities.
+func (h *Hdls) DistanceV2(c *fiber.Ctx) error {
+	var req DistanceRequest
+	if err := parseQuery(c, &req); err != nil {
+		return err
+	}
+
+	departure, err := h.db.ResolveCity(c.UserContext(), req.Departure)
+	if err != nil {
+		return fmt.Errorf("departure: %w", err)
+	}
+
+	destination, err := h.db.ResolveCity(c.UserContext(), req.Destination)
+	if err != nil {
+		return fmt.Errorf("destination: %w", err)
+	}
+
+	resp := DistanceResponse{
+		Departure:   departure,
+		Destination: destination,
+		DistanceStraight: int(distance.CalcGreatCircle(
+			departure.Latitude, departure.Longitude,
+			destination.Latitude, destination.Longitude)),
+	}
+
+	resp.DistanceRoad, err = h.getDistancebyRoad(c.UserContext(), departure.Longitude, departure.Latitude,
+		destination.Longitude, destination.Latitude)
+	if err != nil && req.Road {
+		return fmt.Errorf("%w: distance by road: %v", ErrNotAvailable, err)
+	}
+
+	return h.respond(c, resp)
+}
+
+// NearbyV2 performs search for all cities at a distance until n km
+// from the city of departure or from the coordinates.
+func (h *Hdls) NearbyV2(c *fiber.Ctx) error {
+	var req NearbyRequest
+	if err := parseQuery(c, &req); err != nil {
+		return err
+	}
+
+	resp := NearbyResponse{DistanceTo: req.Distance}
+
+	lat, lon := deref(req.Lat), deref(req.Lon)
+	if strings.TrimSpace(req.Departure) != "" {
+		departure, err := h.db.ResolveCity(c.UserContext(), req.Departure)
+		if err != nil {
+			return fmt.Errorf("departure: %w", err)
+		}
+
+		resp.Departure = &departure
+		lat, lon = departure.Latitude, departure.Longitude
+	}
+
+	cities, err := h.db.FindObjectsNearByCoord(c.UserContext(), lat, lon, req.Distance)
+	if err != nil {
+		return err
+	}
+
+	resp.CitiesNearby = make([]RespCity, 0, len(cities))
+	for _, city := range cities {
+		resp.CitiesNearby = append(resp.CitiesNearby, RespCity{
+			city,
+			int(distance.CalcGreatCircle(lat, lon, city.Latitude, city.Longitude)),
+		})
+	}
+
+	resp.QtyNearby = len(resp.CitiesNearby)
+
+	return h.respond(c, resp)
+}
+
+// ReverseV2 performs search for the city nearest to the coordinates.
+func (h *Hdls) ReverseV2(c *fiber.Ctx) error {
+	var req ReverseRequest
+	if err := parseQuery(c, &req); err != nil {
+		return err
+	}
+
+	lat, lon := deref(req.Lat), deref(req.Lon)
+
+	city, err := h.db.FindNearestCity(c.UserContext(), lat, lon)
+	if err != nil {
+		return err
+	}
+
+	return h.respond(c, ReverseResponse{
+		City:     city,
+		Distance: int(distance.CalcGreatCircle(lat, lon, city.Latitude, city.Longitude)),
+	})
+}
+
+// respond sends the response of v2 in the negotiated format,
+// JSON and MessagePack are wrapped in the envelope.
+func (h *Hdls) respond(c *fiber.Ctx, resp response) error {
+	c.Vary(fiber.HeaderAccept)
+
+	format, err := negotiate(c, FormatJSON)
+	if err != nil {
+		return err
+	}
+
+	return encode(c, format, resp, Envelope{Data: resp})
+}
+
+// parseQuery parses the query to the request and validates it.
+func parseQuery(c *fiber.Ctx, req interface{ validate() error }) error {
+	if err := c.QueryParser(req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParam, err)
+	}
+
+	return req.validate()
+}
+
+// validateCredentials checks email and password of the user.
+func validateCredentials(email, password string) error {
+	switch {
+	case email == "":
+		return fmt.Errorf("email %w", ErrEmptyParam)
+	case !strings.Contains(email, "@"):
+		return fmt.Errorf("%w: email has invalid format", ErrInvalidParam)
+	case password == "":
+		return fmt.Errorf("password %w", ErrEmptyParam)
+	}
+
+	return nil
+}
+
+// validateCoord checks that coordinates are set and are in range.
+func validateCoord(lat, lon *float64) error {
+	switch {
+	case lat == nil:
+		return fmt.Errorf("lat %w", ErrEmptyParam)
+	case lon == nil:
+		return fmt.Errorf("lon %w", ErrEmptyParam)
+	case *lat < -90 || *lat > 90:
+		return fmt.Errorf("%w: lat must be in range [-90, 90]", ErrInvalidParam)
+	case *lon < -180 || *lon > 180:
+		return fmt.Errorf("%w: lon must be in range [-180, 180]", ErrInvalidParam)
+	}
+
+	return nil
+}
+
+// deref returns the value of the pointer or zero for nil.
+func deref(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
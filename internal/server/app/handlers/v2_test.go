@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestProblems(t *testing.T) {
+	store := memory.New()
+	uid := store.AddUser(models.User{Name: "user", Email: "user@example.com", Verified: true})
+
+	h := New(nil, authentication.Init(store, config.Secure{SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg="}))
+
+	token, err := h.auth.GetTokenJWT(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := fiber.New()
+	v2 := srv.Group("/v2", h.Problems)
+	v2.Get("/nearby", h.Authenticate, h.NearbyV2)
+	v2.Get("/reverse", h.Authenticate, h.ReverseV2)
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		typ    string
+		status int
+	}{
+		{name: "No token", target: "/v2/reverse?lat=1&lon=1", typ: problemTypePrefix + "unauthorized",
+			status: fiber.StatusUnauthorized},
+		{name: "Invalid token", target: "/v2/reverse?lat=1&lon=1", token: "invalid",
+			typ: problemTypePrefix + "unauthorized", status: fiber.StatusUnauthorized},
+		{name: "Missing coordinate", target: "/v2/reverse?lat=1", token: token,
+			typ: problemTypePrefix + "invalid-parameter", status: fiber.StatusBadRequest},
+		{name: "Not a number", target: "/v2/reverse?lat=north&lon=1", token: token,
+			typ: problemTypePrefix + "invalid-parameter", status: fiber.StatusBadRequest},
+		{name: "Out of range", target: "/v2/reverse?lat=91&lon=1", token: token,
+			typ: problemTypePrefix + "invalid-parameter", status: fiber.StatusBadRequest},
+		{name: "Missing distance", target: "/v2/nearby?departure=Rome", token: token,
+			typ: problemTypePrefix + "invalid-parameter", status: fiber.StatusBadRequest},
+		{name: "Unknown route", target: "/v2/unknown", typ: "about:blank", status: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			}
+
+			resp, err := srv.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer resp.Body.Close()
+
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != MIMEProblem {
+				t.Errorf("expected content type %s, got %s", MIMEProblem, ct)
+			}
+
+			var problem Problem
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.status || problem.Status != tt.status || problem.Type != tt.typ {
+				t.Errorf("expected %d %s, got %d %+v", tt.status, tt.typ, resp.StatusCode, problem)
+			}
+
+			if problem.Instance != req.URL.Path {
+				t.Errorf("expected instance %s, got %s", req.URL.Path, problem.Instance)
+			}
+		})
+	}
+}
+
+func TestNewProblem(t *testing.T) {
+	candidates := []models.City{{ID: 1, Name: "Paris", Country: "France"}, {ID: 2, Name: "Paris", Country: "United States"}}
+
+	tests := []struct {
+		err        error
+		name       string
+		status     int
+		candidates int
+		detail     bool
+	}{
+		{name: "City not found", err: fmt.Errorf("departure: %w", database.ErrCityNotFound),
+			status: fiber.StatusNotFound, detail: true},
+		{name: "Ambiguous city", err: fmt.Errorf("departure: %w", &database.AmbiguousCityError{Candidates: candidates}),
+			status: fiber.StatusConflict, candidates: 2, detail: true},
+		{name: "Provider is down", err: fmt.Errorf("%w: timeout", ErrNotAvailable),
+			status: fiber.StatusServiceUnavailable, detail: true},
+		{name: "No rows", err: sql.ErrNoRows, status: fiber.StatusNotFound},
+		{name: "Database error", err: errors.New("Error 1146: Table 'geospace.cities' doesn't exist"),
+			status: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := newProblem(tt.err)
+
+			if problem.Status != tt.status || len(problem.Candidates) != tt.candidates {
+				t.Errorf("unexpected problem %+v", problem)
+			}
+
+			if (problem.Detail != "") != tt.detail {
+				t.Errorf("unexpected detail %q", problem.Detail)
+			}
+		})
+	}
+}
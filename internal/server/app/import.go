@@ -1,101 +1,137 @@
 package app
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
+	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"strings"
-	"time"
+	"syscall"
 
 	"github.com/alaleks/geospace/internal/server/config"
-	"github.com/gen2brain/go-unarr"
-	"github.com/jmoiron/sqlx"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/importer"
+	"go.uber.org/zap"
 )
 
-const (
-	samplePath   = "/sample/"    // folder containing the sample file for import
-	arhiveName   = "cities.zip"  // name archive of cities
-	jsonFilename = "cities.json" // file name containing cities
-)
-
-// CityRaw represents a struct for data from json.
-type CityRaw struct {
-	Name             string   `json:"name"`
-	NameASCII        string   `json:"ascii_name"`
-	CountryCode      string   `json:"country_code"`
-	CountryName      string   `json:"label_en"`
-	Timezone         string   `json:"timezone"`
-	AlternativeNames []string `json:"alternate_names"`
-	Coordinates      struct {
-		Lon float64 `json:"lon"`
-		Lat float64 `json:"lat"`
-	} `json:"coordinates"`
-}
-
-// importCities performs transfer data of cities on database.
-func importCities(db *sqlx.DB) error {
-	// if data exists skip import of cities
-	if checkDataCities(db) {
-		return nil
-	}
-
-	rootDir, err := config.GetRootDir()
+// Import runs the command of import of the dataset of cities.
+// Parameters of the dataset are taken from the configuration
+// and can be overridden by the flags of the command.
+func Import(args []string) {
+	logger, err := createLogger()
 	if err != nil {
-		return err
+		log.Fatal(err)
 	}
 
-	a, err := unarr.NewArchive(rootDir + samplePath + arhiveName)
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	flags := config.NewFlags(fs)
+	format := fs.String("format", "",
+		"Format of the dataset: "+strings.Join(importer.Formats(), ", ")+", the format of the configuration by default")
+	path := fs.String("path", "", "Path to the dataset file")
+	altNames := fs.String("alternate-names", "", "Path to the GeoNames alternateNames file")
+	countries := fs.String("countries", "", "Path to the GeoNames countryInfo file")
+	delimiter := fs.String("delimiter", "", "Delimiter of the CSV file")
+	source := fs.String("source", "", "Name of the dataset, the format name by default")
+	batchSize := fs.Int("batch-size", 0, "Quantity of the cities written to the database at once")
+	dryRun := fs.Bool("dry-run", false, "Show the results of the import without saving changes")
+	resumable := fs.Bool("resumable", false,
+		"Commit every batch, so the interrupted import can be continued by the same command")
+	_ = fs.Parse(args)
+
+	cfg, err := config.New(logger, flags)
 	if err != nil {
-		return err
+		logger.Fatal(err)
 	}
 
-	defer a.Close()
+	if err := setLogLevel(cfg.Log); err != nil {
+		logger.Fatal(err)
+	}
 
-	// extract data from archive
-	_, err = a.Extract(rootDir + samplePath)
+	// parameters of the dataset set by the flags override the configuration
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "format":
+			cfg.Import.Format = *format
+		case "path":
+			cfg.Import.Path = *path
+		case "alternate-names":
+			cfg.Import.AlternateNames = *altNames
+		case "countries":
+			cfg.Import.Countries = *countries
+		case "delimiter":
+			cfg.Import.Delimiter = *delimiter
+		case "source":
+			cfg.Import.Source = *source
+		case "batch-size":
+			cfg.Import.BatchSize = *batchSize
+		}
+	})
+
+	db, err := openStore(cfg)
 	if err != nil {
-		return err
+		logger.Fatal(err)
 	}
 
-	file, err := os.Open(rootDir + samplePath + jsonFilename)
-	if err != nil {
-		return err
+	defer db.Close()
+
+	// the import is stopped by interrupt, the resumable import
+	// is continued from the last checkpoint by the same command
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := db.Migrate(ctx); err != nil {
+		logger.Fatal(err)
 	}
 
-	var cities []CityRaw
+	// the cities cached in Redis by the running instances are purged after the import,
+	// otherwise they are expired by the time to live
+	if cfg.Cache.GetBackend() == config.CacheRedis {
+		if db, err = withCache(db, cfg.Cache); err != nil {
+			logger.Warnf("cache is not purged: %v", err)
+		}
+	}
 
-	err = json.NewDecoder(file).Decode(&cities)
+	result, err := importCities(ctx, db, cfg.Import, logger, importer.Options{
+		DryRun:    *dryRun,
+		Resumable: *resumable,
+	})
 	if err != nil {
-		return err
+		logger.Fatal(err)
 	}
 
-	// import data to cities table
-	tx := db.MustBegin()
-	for _, v := range cities {
-		tx.MustExec(tx.Rebind(`INSERT INTO cities (name, name_ascii, 
-			alternative_names, country_code, country, 
-			timezone, latitude, longitude, created_at) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`), v.Name, v.NameASCII, strings.Join(v.AlternativeNames, ","),
-			v.CountryCode, v.CountryName, v.Timezone, v.Coordinates.Lat, v.Coordinates.Lon, time.Now().Unix())
+	for _, rowErr := range result.Errors {
+		fmt.Println(rowErr.Error())
 	}
-	err = tx.Commit()
+
+	if *dryRun {
+		fmt.Printf("dry run, changes are not saved\n")
+	}
+
+	fmt.Printf("source %s: %s\n", importer.SourceName(cfg.Import), result.Stats)
+}
+
+// importCities performs synchronization of the cities in database with the dataset.
+// Source, batch size and logger of the options are taken from the configuration.
+func importCities(ctx context.Context, db database.CityImporter, cfg config.Import, logger *zap.SugaredLogger,
+	opts importer.Options,
+) (importer.Result, error) {
+	format, err := importer.New(cfg)
 	if err != nil {
-		return err
+		return importer.Result{}, err
 	}
 
-	// remove json file
-	os.Remove(rootDir + samplePath + jsonFilename)
+	opts.Source = importer.SourceName(cfg)
+	opts.BatchSize = cfg.BatchSize
+	opts.Logger = logger
 
-	return nil
+	return importer.Run(ctx, db, format, opts)
 }
 
 // checkDataCities performs a check exist data in table cities.
-func checkDataCities(db *sqlx.DB) bool {
-	var res int
-	err := db.Get(&res, `SELECT COUNT(*) FROM cities`)
-
-	if res == 0 || err != nil {
-		return false
-	}
+func checkDataCities(ctx context.Context, db database.Cities) bool {
+	count, err := db.CountCities(ctx)
 
-	return true
+	return err == nil && count > 0
 }
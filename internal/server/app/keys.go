@@ -0,0 +1,72 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/config"
+)
+
+// Keys runs the command of the keys: "rotate" adds the new key signing JWT,
+// the previous keys verify the tokens until they expire and are removed by
+// the next rotation. With -cipher the key of the cipher of the passwords is
+// replaced too, the passwords are encrypted by the new key at the login.
+// The running servers take the keys on reload (SIGHUP).
+func Keys(args []string) {
+	if len(args) == 0 || args[0] != "rotate" {
+		log.Fatal("specify the action of the keys: rotate")
+	}
+
+	fs := flag.NewFlagSet("keys rotate", flag.ExitOnError)
+	flags := config.NewFlags(fs)
+	cipher := fs.Bool("cipher", false, "Replace the key of the cipher of the passwords too")
+	_ = fs.Parse(args[1:])
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		key     config.JWTKey
+		removed []string
+	)
+
+	// the algorithm may be set out of the file of the keys
+	algorithm := cfg.Secure.GetJWTAlgorithm()
+
+	path, err := cfg.RotateKeys(func(s *config.Secure) (err error) {
+		key, removed, err = s.RotateJWT(algorithm, authentication.Expiration, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if *cipher {
+			s.RotateCipher()
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("new key of JWT %s (%s) is written to %s\n", key.ID, key.GetAlgorithm(), path)
+
+	for _, id := range removed {
+		if id == "" {
+			id = "secret_jwt"
+		}
+
+		fmt.Printf("expired key of JWT %s is removed\n", id)
+	}
+
+	if *cipher {
+		fmt.Println("key of the cipher is replaced, the previous one is kept in previous_ciphers")
+	}
+
+	fmt.Println("reload the servers by SIGHUP to apply the keys")
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// rateLimiter limits the requests of one client by the configuration,
+// the limit is replaced on the reload of the configuration.
+type rateLimiter struct {
+	handler atomic.Pointer[fiber.Handler] // nil if the limit is disabled
+}
+
+// newRateLimiter returns the limiter of the requests by the configuration.
+func newRateLimiter(cfg config.App) *rateLimiter {
+	rl := new(rateLimiter)
+	rl.configure(cfg)
+
+	return rl
+}
+
+// configure replaces the limit, the counters of the clients are reset.
+// The limit is disabled if max_request is not set.
+func (rl *rateLimiter) configure(cfg config.App) {
+	if cfg.MaxRequest <= 0 {
+		rl.handler.Store(nil)
+		return
+	}
+
+	expiration := time.Duration(cfg.Expiration) * time.Second
+	if expiration <= 0 {
+		expiration = time.Second
+	}
+
+	handler := limiter.New(limiter.Config{
+		Max:        cfg.MaxRequest,
+		Expiration: expiration,
+		// probes and metrics are not limited
+		Next: func(c *fiber.Ctx) bool {
+			switch c.Path() {
+			case "/healthz", "/readyz", "/metrics":
+				return true
+			}
+
+			return false
+		},
+	})
+	rl.handler.Store(&handler)
+}
+
+// Middleware limits the requests of the client by the ip.
+func (rl *rateLimiter) Middleware(c *fiber.Ctx) error {
+	handler := rl.handler.Load()
+	if handler == nil {
+		return c.Next()
+	}
+
+	return (*handler)(c)
+}
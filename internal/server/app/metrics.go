@@ -0,0 +1,90 @@
+package app
+
+import (
+	"github.com/alaleks/geospace/internal/server/cache"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// descriptions of the metrics of the pools of connections
+var (
+	poolOpenDesc = prometheus.NewDesc("geospace_db_pool_open_connections",
+		"Quantity of the open connections of the pool.", []string{"name", "role"}, nil)
+	poolInUseDesc = prometheus.NewDesc("geospace_db_pool_in_use_connections",
+		"Quantity of the connections of the pool in use.", []string{"name", "role"}, nil)
+	poolIdleDesc = prometheus.NewDesc("geospace_db_pool_idle_connections",
+		"Quantity of the idle connections of the pool.", []string{"name", "role"}, nil)
+	poolMaxOpenDesc = prometheus.NewDesc("geospace_db_pool_max_open_connections",
+		"Maximum quantity of the open connections of the pool, 0 if unlimited.", []string{"name", "role"}, nil)
+	poolWaitCountDesc = prometheus.NewDesc("geospace_db_pool_wait_count_total",
+		"Quantity of the connections waited for.", []string{"name", "role"}, nil)
+	poolWaitDurationDesc = prometheus.NewDesc("geospace_db_pool_wait_duration_seconds_total",
+		"Time waited for the connections.", []string{"name", "role"}, nil)
+	poolHealthyDesc = prometheus.NewDesc("geospace_db_pool_healthy",
+		"1 if the database server passed the last health check.", []string{"name", "role"}, nil)
+)
+
+// descriptions of the metrics of the cache
+var (
+	cacheHitsDesc = prometheus.NewDesc("geospace_cache_hits_total",
+		"Quantity of the lookups found in the cache.", []string{"kind"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("geospace_cache_misses_total",
+		"Quantity of the lookups missing in the cache.", []string{"kind"}, nil)
+)
+
+// poolCollector exposes the statistics of the pools of connections
+// to the database servers, they are read on every scrape.
+type poolCollector struct {
+	reporter database.PoolReporter
+}
+
+// Describe sends the descriptions of the metrics of the pools.
+func (pc poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolMaxOpenDesc
+	ch <- poolWaitCountDesc
+	ch <- poolWaitDurationDesc
+	ch <- poolHealthyDesc
+}
+
+// Collect sends the current statistics of the pools.
+func (pc poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range pc.reporter.PoolStats() {
+		healthy := 0.0
+		if s.Healthy {
+			healthy = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(s.Open), s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(s.InUse), s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.Idle), s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(s.MaxOpen), s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolWaitCountDesc, prometheus.CounterValue,
+			float64(s.WaitCount), s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolWaitDurationDesc, prometheus.CounterValue,
+			float64(s.WaitDuration)/1000, s.Name, s.Role)
+		ch <- prometheus.MustNewConstMetric(poolHealthyDesc, prometheus.GaugeValue, healthy, s.Name, s.Role)
+	}
+}
+
+// cacheCollector exposes the hits and misses of the cache
+// by the kinds of the entries, they are read on every scrape.
+type cacheCollector struct {
+	reporter cache.Reporter
+}
+
+// Describe sends the descriptions of the metrics of the cache.
+func (cc cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+}
+
+// Collect sends the current counters of the cache.
+func (cc cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for kind, s := range cc.reporter.CacheStats() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(s.Hits), kind)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(s.Misses), kind)
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+)
+
+// Migrate runs the command of the migrations of the schema of the database:
+// "up" applies the pending migrations, "down" reverts the latest ones,
+// "status" prints the version of the schema and the states of the migrations.
+func Migrate(args []string) {
+	logger, err := createLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(args) == 0 {
+		logger.Fatal("specify the action of the migrations: up, down or status")
+	}
+
+	action := args[0]
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	flags := config.NewFlags(fs)
+	steps := fs.Int("steps", 1, "Quantity of the latest migrations reverted by down")
+	_ = fs.Parse(args[1:])
+
+	cfg, err := config.New(logger, flags)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := setLogLevel(cfg.Log); err != nil {
+		logger.Fatal(err)
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	defer store.Close()
+
+	db, ok := store.(database.Migrator)
+	if !ok {
+		logger.Fatalf("storage %s has no schema", cfg.CfgDatabase.GetDriver())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch action {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		printMigrations("applied", applied)
+
+		if err != nil {
+			logger.Fatal(err)
+		}
+	case "down":
+		if *steps <= 0 {
+			logger.Fatal("steps must be positive")
+		}
+
+		reverted, err := db.MigrateDown(ctx, *steps)
+		printMigrations("reverted", reverted)
+
+		if err != nil {
+			logger.Fatal(err)
+		}
+	case "status":
+		status, err := db.MigrationStatus(ctx)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		printSchemaStatus(status)
+	default:
+		logger.Fatalf("unknown action of the migrations %q, use up, down or status", action)
+	}
+}
+
+// printMigrations prints the migrations applied or reverted by the command.
+func printMigrations(verb string, migrations []database.MigrationState) {
+	if len(migrations) == 0 {
+		fmt.Printf("no migrations %s\n", verb)
+		return
+	}
+
+	for _, m := range migrations {
+		fmt.Printf("%s %s\n", verb, m.Name)
+	}
+}
+
+// printSchemaStatus prints the version of the schema and the states of the migrations.
+func printSchemaStatus(status database.SchemaStatus) {
+	fmt.Printf("schema version: %d, latest known: %d\n", status.Version, status.Latest)
+
+	if status.Version > status.Latest {
+		fmt.Println("the schema is newer than the application, upgrade the application")
+	}
+
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied() {
+			state = "applied " + time.Unix(m.AppliedAt, 0).Format(time.RFC3339)
+		}
+
+		fmt.Printf("%s\t%s\n", m.Name, state)
+	}
+}
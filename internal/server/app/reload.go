@@ -0,0 +1,43 @@
+package app
+
+import (
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/routing"
+)
+
+// reload reads the configuration file again and applies it to the running server.
+func (app *App) reload() {
+	next, err := app.cfg.Reload()
+	if err != nil {
+		app.logger.Errorw("configuration is not reloaded", "error", err)
+		return
+	}
+
+	app.applyConfig(next)
+}
+
+// applyConfig applies the options safe to change on the running server: the limit
+// of the requests, the shutdown, the level of the log, the routing service and the keys.
+// Changes of other sections are rejected, they need the restart of the server.
+func (app *App) applyConfig(next *config.Cfg) {
+	if err := setLogLevel(next.Log); err != nil {
+		app.logger.Errorw("configuration is not reloaded", "error", err)
+		return
+	}
+
+	cfg, rejected := app.cfg.Merge(next)
+	if len(rejected) > 0 {
+		app.logger.Warnw("changes of the configuration need the restart and are not applied", "sections", rejected)
+	}
+
+	app.limiter.configure(cfg.App)
+	routing.Configure(cfg.Routing.GetURL(), cfg.Routing.GetTimeout())
+
+	if app.auth != nil {
+		app.auth.SetKeys(cfg.Secure)
+	}
+	app.cfg = cfg
+
+	app.logger.Infow("configuration is reloaded", "max_request", cfg.App.MaxRequest,
+		"log_level", cfg.Log.GetLevel(), "routing_url", cfg.Routing.GetURL())
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/alaleks/geospace/internal/server/cache"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/routing"
+)
+
+// openStore opens the storage by the driver of the configuration.
+func openStore(cfg *config.Cfg) (database.Store, error) {
+	switch driver := cfg.CfgDatabase.GetDriver(); driver {
+	case config.DriverMariaDB, config.DriverPostgres:
+		return database.Connect(*cfg)
+	case config.DriverSQLite:
+		return database.OpenSQLite(cfg.CfgDatabase.Path, cfg.CfgDatabase.GetQueryTimeout())
+	case config.DriverMemory:
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+}
+
+// withCache wraps the storage by the cache of the configuration
+// and sets it for the distances by road. The storage is returned
+// as is if caching is disabled.
+func withCache(db database.Store, cfg config.Cache) (database.Store, error) {
+	c, err := cache.New(cfg)
+	if err != nil || c == nil {
+		return db, err
+	}
+
+	routing.Cache = c
+
+	return cache.NewStore(db, c), nil
+}
@@ -0,0 +1,122 @@
+// Package cache keeps the results of the frequent lookups: the cities found
+// by names and the distances by road. Entries are kept in the LRU cache of the
+// process or in Redis shared by the instances of the application.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alaleks/geospace/internal/server/config"
+)
+
+// Cache is the storage of the entries by keys. Errors of the backend are not returned,
+// the failed lookup is a miss, so the application works without the cache.
+type Cache interface {
+	// Get returns the value by the key and false if there is no such entry.
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set saves the value by the key for the time to live of the cache.
+	Set(ctx context.Context, key string, value []byte)
+	// Purge deletes all entries having the prefix of the key.
+	Purge(ctx context.Context, prefix string)
+}
+
+// Stats contains the counters of the lookups of one kind of the entries.
+type Stats struct {
+	Hits   int64 `json:"hits"`   // quantity of the entries found
+	Misses int64 `json:"misses"` // quantity of the entries not found
+}
+
+// New creates the cache by the configuration. Returns nil if caching is disabled.
+func New(cfg config.Cache) (*Metered, error) {
+	switch backend := cfg.GetBackend(); backend {
+	case config.CacheMemory:
+		return NewMetered(NewLRU(cfg.GetSize(), cfg.GetTTL())), nil
+	case config.CacheRedis:
+		redis, err := NewRedis(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewMetered(redis), nil
+	case config.CacheNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown backend of the cache %q, use memory, redis or none", backend)
+	}
+}
+
+// Metered counts hits and misses of the cache by the kinds of the entries.
+// The kind is the part of the key before the first colon: "city", "distance".
+type Metered struct {
+	Cache
+	mu    sync.RWMutex
+	stats map[string]*counters
+}
+
+// counters are the counters of the lookups of one kind.
+type counters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewMetered returns the cache counting the lookups.
+func NewMetered(c Cache) *Metered {
+	return &Metered{
+		Cache: c,
+		stats: make(map[string]*counters),
+	}
+}
+
+// Get returns the value by the key and counts the lookup.
+func (m *Metered) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok := m.Cache.Get(ctx, key)
+
+	c := m.counters(key)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	return value, ok
+}
+
+// Stats returns the counters of the lookups by the kinds of the entries.
+func (m *Metered) Stats() map[string]Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]Stats, len(m.stats))
+	for kind, c := range m.stats {
+		stats[kind] = Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+	}
+
+	return stats
+}
+
+// counters returns the counters of the kind of the key.
+func (m *Metered) counters(key string) *counters {
+	kind, _, _ := strings.Cut(key, ":")
+
+	m.mu.RLock()
+	c, ok := m.stats[kind]
+	m.mu.RUnlock()
+
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok = m.stats[kind]; !ok {
+		c = new(counters)
+		m.stats[kind] = c
+	}
+
+	return c
+}
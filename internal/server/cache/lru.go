@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is the cache in the memory of the process. The least recently used
+// entries are evicted when the size is exceeded, expired entries are
+// deleted on the lookup.
+type LRU struct {
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	order   *list.List // front is the most recently used entry
+	size    int
+	ttl     time.Duration
+	nowFunc func() time.Time
+}
+
+// entry is the element of the list of the LRU cache.
+type entry struct {
+	expires time.Time
+	key     string
+	value   []byte
+}
+
+// NewLRU returns the LRU cache keeping up to size entries for the time to live.
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		items:   make(map[string]*list.Element, size),
+		order:   list.New(),
+		size:    size,
+		ttl:     ttl,
+		nowFunc: time.Now,
+	}
+}
+
+// Get returns the value by the key and false if there is no such entry or it is expired.
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if c.nowFunc().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return e.value, true
+}
+
+// Set saves the value by the key, the least recently used entry
+// is evicted if the cache is full.
+func (c *LRU) Set(_ context.Context, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.nowFunc().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Purge deletes all entries having the prefix of the key.
+func (c *LRU) Purge(_ context.Context, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+		}
+	}
+}
+
+// Len returns quantity of the entries including expired ones.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// remove deletes the element from the list and the map.
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	c := NewLRU(2, time.Minute)
+	c.nowFunc = func() time.Time { return now }
+
+	c.Set(ctx, "city:a", []byte("a"))
+	c.Set(ctx, "city:b", []byte("b"))
+
+	// a becomes the most recently used, so b is evicted
+	if _, ok := c.Get(ctx, "city:a"); !ok {
+		t.Fatal("expected city:a")
+	}
+
+	c.Set(ctx, "distance:c", []byte("c"))
+
+	if _, ok := c.Get(ctx, "city:b"); ok {
+		t.Error("expected city:b to be evicted")
+	}
+
+	if value, ok := c.Get(ctx, "distance:c"); !ok || string(value) != "c" {
+		t.Errorf("expected c, got %q, %v", value, ok)
+	}
+
+	// entries are expired by the time to live
+	now = now.Add(2 * time.Minute)
+
+	if _, ok := c.Get(ctx, "city:a"); ok {
+		t.Error("expected city:a to be expired")
+	}
+
+	if c.Len() != 1 {
+		t.Errorf("expected 1 entry, got %d", c.Len())
+	}
+
+	c.Set(ctx, "city:a", []byte("a"))
+	c.Set(ctx, "distance:c", []byte("c"))
+	c.Purge(ctx, "city:")
+
+	if _, ok := c.Get(ctx, "city:a"); ok {
+		t.Error("expected city:a to be purged")
+	}
+
+	if _, ok := c.Get(ctx, "distance:c"); !ok {
+		t.Error("expected distance:c to be kept")
+	}
+}
+
+func TestMetered(t *testing.T) {
+	ctx := context.Background()
+	c := NewMetered(NewLRU(10, time.Minute))
+
+	c.Set(ctx, "city:find:rome", []byte("{}"))
+	c.Get(ctx, "city:find:rome")
+	c.Get(ctx, "city:find:milan")
+	c.Get(ctx, "distance:1,2;3,4")
+
+	stats := c.Stats()
+	if stats["city"] != (Stats{Hits: 1, Misses: 1}) || stats["distance"] != (Stats{Misses: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
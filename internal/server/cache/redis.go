@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisPrefix    = "geospace:" // prefix of the keys of the application in Redis
+	redisScanCount = 1000        // quantity of the keys scanned by one command on purge
+	redisTimeout   = 100 * time.Millisecond
+)
+
+// Redis is the cache shared by the instances of the application. Commands are limited
+// by the short timeout, so the slow Redis does not slow down the requests.
+type Redis struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedis connects to Redis by the configuration and checks the connection.
+func NewRedis(cfg config.Cache) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.GetRedisAddr(),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &Redis{client: client, ttl: cfg.GetTTL()}, nil
+}
+
+// Get returns the value by the key and false if there is no such entry or Redis fails.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	value, err := r.client.Get(ctx, redisPrefix+key).Bytes()
+
+	return value, err == nil
+}
+
+// Set saves the value by the key for the time to live, errors are ignored.
+func (r *Redis) Set(ctx context.Context, key string, value []byte) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	r.client.Set(ctx, redisPrefix+key, value, r.ttl)
+}
+
+// Purge deletes all entries having the prefix of the key. Keys are scanned
+// by parts, so Redis is not blocked. The purge is not limited by the timeout
+// of the commands, it is made on changes of the cities only.
+func (r *Redis) Purge(ctx context.Context, prefix string) {
+	iter := r.client.Scan(ctx, 0, redisPrefix+prefix+"*", redisScanCount).Iterator()
+
+	keys := make([]string, 0, redisScanCount)
+
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+
+		if len(keys) == redisScanCount {
+			r.client.Unlink(ctx, keys...)
+			keys = keys[:0]
+		}
+	}
+
+	if len(keys) > 0 {
+		r.client.Unlink(ctx, keys...)
+	}
+}
+
+// Close closes the connections to Redis.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
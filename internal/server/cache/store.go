@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// prefixes of the keys of the cities
+const (
+	prefixCity    = "city:"
+	prefixFind    = prefixCity + "find:"
+	prefixResolve = prefixCity + "resolve:"
+)
+
+// Reporter reports the counters of the lookups of the cache.
+type Reporter interface {
+	// CacheStats returns the counters by the kinds of the entries.
+	CacheStats() map[string]Stats
+}
+
+// Store is the storage keeping the cities found by names in the cache.
+// The cities are purged from the cache when they are changed by the
+// approved suggestions or the import of the dataset.
+type Store struct {
+	database.Store
+	cache *Metered
+}
+
+// check that Store implements the interfaces
+var (
+	_ database.Store        = (*Store)(nil)
+	_ database.PoolReporter = (*Store)(nil)
+	_ Reporter              = (*Store)(nil)
+)
+
+// NewStore returns the storage using the cache.
+func NewStore(db database.Store, c *Metered) *Store {
+	return &Store{Store: db, cache: c}
+}
+
+// FindCity returns the city by name from the cache or the storage.
+func (s *Store) FindCity(ctx context.Context, cityRaw string) (models.City, error) {
+	return s.cached(ctx, prefixFind+cacheKey(cityRaw), func() (models.City, error) {
+		return s.Store.FindCity(ctx, cityRaw)
+	})
+}
+
+// ResolveCity returns the city by name from the cache or the storage.
+func (s *Store) ResolveCity(ctx context.Context, cityRaw string) (models.City, error) {
+	return s.cached(ctx, prefixResolve+cacheKey(cityRaw), func() (models.City, error) {
+		return s.Store.ResolveCity(ctx, cityRaw)
+	})
+}
+
+// ApproveSuggestion applies the suggestion and purges the cities from the cache.
+func (s *Store) ApproveSuggestion(ctx context.Context, sid, reviewerID int, comment string) (models.City, error) {
+	city, err := s.Store.ApproveSuggestion(ctx, sid, reviewerID, comment)
+	if err == nil {
+		s.cache.Purge(ctx, prefixCity)
+	}
+
+	return city, err
+}
+
+// BeginCitySync starts the synchronization, the cities are purged from the cache when it ends.
+func (s *Store) BeginCitySync(ctx context.Context, source string, opts database.SyncOptions) (database.CitySyncer, error) {
+	syncer, err := s.Store.BeginCitySync(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &citySync{CitySyncer: syncer, cache: s.cache}, nil
+}
+
+// Close closes the storage and the connections of the cache.
+func (s *Store) Close() error {
+	err := s.Store.Close()
+
+	if closer, ok := s.cache.Cache.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+
+	return err
+}
+
+// PoolStats returns the statistics of the pools of the storage if it reports them.
+func (s *Store) PoolStats() []database.PoolStats {
+	if reporter, ok := s.Store.(database.PoolReporter); ok {
+		return reporter.PoolStats()
+	}
+
+	return []database.PoolStats{}
+}
+
+// CacheStats returns the counters of the lookups of the cache.
+func (s *Store) CacheStats() map[string]Stats {
+	return s.cache.Stats()
+}
+
+// cached returns the city by the key from the cache, otherwise
+// gets it by fn and saves. Errors are not cached.
+func (s *Store) cached(ctx context.Context, key string, fn func() (models.City, error)) (models.City, error) {
+	var city models.City
+
+	if value, ok := s.cache.Get(ctx, key); ok && json.Unmarshal(value, &city) == nil {
+		return city, nil
+	}
+
+	city, err := fn()
+	if err != nil {
+		return city, err
+	}
+
+	if value, err := json.Marshal(city); err == nil {
+		s.cache.Set(ctx, key, value)
+	}
+
+	return city, nil
+}
+
+// citySync purges the cities from the cache when the synchronization ends.
+// The resumable synchronization commits every batch, so the cities are
+// purged on close as well, even if the import is interrupted.
+type citySync struct {
+	database.CitySyncer
+	cache Cache
+}
+
+// Finish ends the synchronization and purges the cities from the cache.
+func (cs *citySync) Finish() (database.ImportStats, error) {
+	stats, err := cs.CitySyncer.Finish()
+	cs.cache.Purge(context.Background(), prefixCity)
+
+	return stats, err
+}
+
+// Close rolls back the not finished changes and purges the cities from the cache.
+func (cs *citySync) Close() error {
+	err := cs.CitySyncer.Close()
+	cs.cache.Purge(context.Background(), prefixCity)
+
+	return err
+}
+
+// cacheKey normalizes the name of the city for the key.
+func cacheKey(cityRaw string) string {
+	return strings.ToLower(strings.TrimSpace(cityRaw))
+}
@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/cache"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+var rome = models.City{Name: "Rome", CountryCode: "IT", Country: "Italy",
+	Latitude: 41.89193, Longitude: 12.51133, Source: "test", ExternalID: "1"}
+
+func testStore(t *testing.T, c *cache.Metered) {
+	t.Helper()
+
+	ctx := context.Background()
+	db := cache.NewStore(memory.New(rome), c)
+
+	for i := 0; i < 2; i++ {
+		city, err := db.FindCity(ctx, " rome ")
+		if err != nil || city.Name != "Rome" {
+			t.Fatalf("expected Rome, got %+v, %v", city, err)
+		}
+	}
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := db.ResolveCity(ctx, "Atlantis"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	if stats := db.CacheStats()["city"]; stats != (cache.Stats{Hits: 1, Misses: 3}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// the import purges the cached cities
+	sync, err := db.BeginCitySync(ctx, "test", database.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := rome
+	renamed.Name, renamed.AlternativeNames = "Roma", "Rome,"
+
+	if err := sync.Write([]models.City{renamed}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sync.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	city, err := db.FindCity(ctx, "Rome")
+	if err != nil || city.Name != "Roma" {
+		t.Errorf("expected Roma after import, got %+v, %v", city, err)
+	}
+}
+
+func TestStoreMemory(t *testing.T) {
+	c, err := cache.New(config.Cache{Backend: config.CacheMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStore(t, c)
+}
+
+// TestStoreRedis runs against the Redis server at the address of GEOSPACE_TEST_REDIS.
+func TestStoreRedis(t *testing.T) {
+	addr := os.Getenv("GEOSPACE_TEST_REDIS")
+	if addr == "" {
+		t.Skip("GEOSPACE_TEST_REDIS is not set")
+	}
+
+	redis, err := cache.NewRedis(config.Cache{RedisAddr: addr, TTL: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer redis.Close()
+
+	redis.Purge(context.Background(), "")
+
+	testStore(t, cache.NewMetered(redis))
+}
+
+func TestNewDisabled(t *testing.T) {
+	c, err := cache.New(config.Cache{Backend: config.CacheNone})
+	if err != nil || c != nil {
+		t.Errorf("expected disabled cache, got %v, %v", c, err)
+	}
+
+	if _, err := cache.New(config.Cache{Backend: "memcached"}); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
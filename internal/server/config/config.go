@@ -3,16 +3,24 @@ package config
 
 import (
 	"bytes"
-	"flag"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
 	"path"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alaleks/geospace/pkg/genkey"
 	"github.com/golang-module/dongle"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"gopkg.in/yaml.v2"
 )
 
@@ -22,6 +30,71 @@ const (
 	sizeIVCipher  = 8              // size of IV cipher in bytes
 	sizeKeyCipher = 48             // size of key cipher in bytes
 	sizeKeySecret = 64             // size of key secret in bytes
+	maxPort       = 65535          // maximum number of the TCP port
+
+	DefaultQueryTimeout = 5 * time.Second // timeout of one query to the database
+	DefaultHost         = "localhost"     // host of the database for TCP connections
+	DefaultSSLMode      = "disable"       // SSL mode of the connections to PostgreSQL
+	DefaultPostgresPort = 5432            // port of PostgreSQL if it is not set
+
+	DefaultMaxIdleConns         = 100              // maximum number of idle connections to the database
+	DefaultConnMaxLifetime      = 15 * time.Minute // the maximum length of time a connection can be reused
+	DefaultReplicaCheckInterval = 10 * time.Second // interval of the health checks of the read replicas
+
+	DefaultCacheSize = 10000            // maximum quantity of the entries of the cache in memory
+	DefaultCacheTTL  = time.Hour        // time to live of the entries of the cache
+	DefaultRedisAddr = "localhost:6379" // address of Redis
+
+	DefaultOTLPEndpoint = "localhost:4318" // address of the OpenTelemetry collector accepting OTLP over HTTP
+	DefaultLogLevel     = "info"           // minimum level of the lines of the log
+
+	DefaultRoutingURL     = "http://router.project-osrm.org" // address of the routing service
+	DefaultRoutingTimeout = 500 * time.Millisecond           // maximum time of the request to the routing service
+
+	DefaultShutdownTimeout = 30 * time.Second // maximum time of the requests in flight on shutdown
+
+	DefaultIssuer = "geospace" // issuer and audience of the tokens
+
+	DefaultSMTPPort  = 587            // port of the SMTP server with STARTTLS
+	DefaultVerifyTTL = 24 * time.Hour // lifetime of the link confirming the email
+	DefaultResetTTL  = time.Hour      // lifetime of the link resetting the password
+)
+
+// backends of the emails
+const (
+	MailNone = "none" // emails are not sent, the users are verified at once, default
+	MailSMTP = "smtp" // emails are sent by the SMTP server
+	MailFile = "file" // emails are written to the files of the directory
+	MailLog  = "log"  // emails are written to the log
+)
+
+// algorithms of the signature of JWT
+const (
+	JWTAlgorithmHS256 = "HS256" // HMAC with SHA-256 by the shared secret, default
+	JWTAlgorithmRS256 = "RS256" // RSA with SHA-256, the public keys are published by JWKS
+	JWTAlgorithmEdDSA = "EdDSA" // Ed25519, the public keys are published by JWKS
+)
+
+// backends of the cache
+const (
+	CacheMemory = "memory" // LRU cache in the process, default
+	CacheRedis  = "redis"  // Redis shared by the instances
+	CacheNone   = "none"   // caching is disabled
+)
+
+// exporters of the traces
+const (
+	TracingNone   = "none"   // tracing is disabled, default
+	TracingStdout = "stdout" // spans are written to the standard output
+	TracingOTLP   = "otlp"   // spans are sent to the collector by OTLP over HTTP
+)
+
+// drivers of the database
+const (
+	DriverMariaDB  = "mariadb"  // MariaDB or MySQL server, default
+	DriverPostgres = "postgres" // PostgreSQL server with PostGIS
+	DriverSQLite   = "sqlite"   // embedded SQLite database in the file
+	DriverMemory   = "memory"   // in-memory storage, data are lost on restart
 )
 
 type (
@@ -30,83 +103,233 @@ type (
 		Secure      Secure      `yaml:"secure"`
 		CfgDatabase CfgDatabase `yaml:"database"`
 		App         App         `yaml:"app"`
+		Import      Import      `yaml:"import"`
+		Cache       Cache       `yaml:"cache"`
+		Tracing     Tracing     `yaml:"tracing"`
+		Log         Log         `yaml:"log"`
+		Routing     Routing     `yaml:"routing"`
+		Mail        Mail        `yaml:"mail"`
+
+		path  string // path to the config file
+		found bool   // the config file exists
+		flags *Flags // flags of the command line, applied again on reload
 	}
 
 	// CfgDatabase contains the configuration for a database connection.
 	CfgDatabase struct {
-		Name       string `yaml:"name"`        // Name of the database
-		User       string `yaml:"user"`        // User name of the database
-		Password   string `yaml:"password"`    // Password of the database
-		UnixSocket string `yaml:"unix_socket"` // Socket for connections (faster than TCP connection)
-		Port       int    `yaml:"port"`        // Port of the database for TCP connections
+		Driver       string `yaml:"driver"`        // Driver of the database: mariadb (default), postgres, sqlite or memory
+		Path         string `yaml:"path"`          // Path to the file of the SQLite database
+		Name         string `yaml:"name"`          // Name of the database
+		User         string `yaml:"user"`          // User name of the database
+		Password     string `yaml:"password"`      // Password of the database
+		PasswordFile string `yaml:"password_file"` // File of the password of the database, overrides the password
+		UnixSocket   string `yaml:"unix_socket"`   // Socket for connections (faster than TCP connection)
+		Host         string `yaml:"host"`          // Host of the database for TCP connections, localhost if not set
+		Port         int    `yaml:"port"`          // Port of the database for TCP connections
+		SSLMode      string `yaml:"sslmode"`       // SSL mode of the connections to PostgreSQL, disable if not set
+		QueryTimeout int    `yaml:"query_timeout"` // Timeout of one query in milliseconds, 5000 if not set
+
+		Replicas []Replica `yaml:"replicas"` // Read replicas of the database server, the reads of cities are routed to them
+		Pool     Pool      `yaml:"pool"`     // Pool of connections to the primary and every replica
+	}
+
+	// Replica contains the address of the read replica of the database server.
+	// Name, user, password and SSL mode are the same as of the primary.
+	Replica struct {
+		Host       string `yaml:"host"`        // Host of the replica, localhost if not set
+		UnixSocket string `yaml:"unix_socket"` // Socket of the replica
+		Port       int    `yaml:"port"`        // Port of the replica, port of the primary if not set
+	}
+
+	// Pool contains the params of the pools of connections to the database server.
+	Pool struct {
+		MaxOpenConns         int `yaml:"max_open_conns"`         // Maximum number of open connections, unlimited if not set
+		MaxIdleConns         int `yaml:"max_idle_conns"`         // Maximum number of idle connections, 100 if not set
+		ConnMaxLifetime      int `yaml:"conn_max_lifetime"`      // Maximum lifetime of a connection in seconds, 900 if not set
+		ConnMaxIdleTime      int `yaml:"conn_max_idle_time"`     // Maximum idle time of a connection in seconds, unlimited if not set
+		ReplicaCheckInterval int `yaml:"replica_check_interval"` // Interval of the health checks of the replicas in seconds, 10 if not set
 	}
 
 	// App contains the params of settings.
 	App struct {
 		Name       string `yaml:"name"`        // Name of the application
 		Port       string `yaml:"port"`        // Port for running the application
+		GRPCPort   string `yaml:"grpc_port"`   // Port for running the gRPC server, disabled if empty
 		MaxRequest int    `yaml:"max_request"` // Max request quantity in seconds
 		Expiration int    `yaml:"expiration"`  // Expiration period in seconds
+
+		// Delay of the shutdown in seconds, the server is not ready but serves the requests,
+		// so the load balancer stops sending new requests before the server stops
+		ShutdownDelay int `yaml:"shutdown_delay"`
+		// Maximum time of the requests in flight on shutdown in seconds, 30 if not set
+		ShutdownTimeout int `yaml:"shutdown_timeout"`
+	}
+
+	// Import contains the params of the dataset of cities.
+	Import struct {
+		Format         string            `yaml:"format"`          // Format of the dataset: sample, geonames, csv or geojson
+		Source         string            `yaml:"source"`          // Name of the dataset, the format name by default
+		Path           string            `yaml:"path"`            // Path to the dataset file (can be zip archive)
+		AlternateNames string            `yaml:"alternate_names"` // Path to the GeoNames alternateNames file
+		Countries      string            `yaml:"countries"`       // Path to the GeoNames countryInfo file
+		Delimiter      string            `yaml:"delimiter"`       // Delimiter of the CSV file
+		BatchSize      int               `yaml:"batch_size"`      // Quantity of the cities written to the database at once
+		Columns        map[string]string `yaml:"columns"`         // Mapping of the city fields to CSV columns or GeoJSON properties
+
+		dir string // directory of the config file
+	}
+
+	// Cache contains the params of the cache of the cities and the distances by road.
+	Cache struct {
+		Backend       string `yaml:"backend"`        // Backend of the cache: memory (default), redis or none
+		Size          int    `yaml:"size"`           // Maximum quantity of the entries in memory, 10000 if not set
+		TTL           int    `yaml:"ttl"`            // Time to live of the entries in seconds, 3600 if not set
+		RedisAddr     string `yaml:"redis_addr"`     // Address of Redis, localhost:6379 if not set
+		RedisPassword string `yaml:"redis_password"` // Password of Redis
+		RedisDB       int    `yaml:"redis_db"`       // Number of the database of Redis
+
+		// File of the password of Redis, overrides the password
+		RedisPasswordFile string `yaml:"redis_password_file"`
+	}
+
+	// Tracing contains the params of the export of the traces.
+	Tracing struct {
+		Exporter    string   `yaml:"exporter"`     // Exporter of the spans: none (default), stdout or otlp
+		Endpoint    string   `yaml:"endpoint"`     // Address of the collector, localhost:4318 if not set
+		Insecure    bool     `yaml:"insecure"`     // If true the spans are sent to the collector without TLS
+		SampleRatio *float64 `yaml:"sample_ratio"` // Share of the traced requests from 0 to 1, 1 if not set
+	}
+
+	// Log contains the params of the log of the server.
+	Log struct {
+		Level       string   `yaml:"level"`        // Minimum level of the lines: debug, info (default), warn or error
+		SampleRatio *float64 `yaml:"sample_ratio"` // Share of the successful requests written to the access log, 1 if not set
+	}
+
+	// Routing contains the params of the routing service calculating the distances by road.
+	Routing struct {
+		URL     string `yaml:"url"`     // Address of the OSRM server, http://router.project-osrm.org if not set
+		Timeout int    `yaml:"timeout"` // Maximum time of the request in milliseconds, 500 if not set
+	}
+
+	// Mail contains the params of the emails confirming the email and resetting the password.
+	Mail struct {
+		Backend          string `yaml:"backend"`            // Sender of the emails: none (default), smtp, file or log
+		From             string `yaml:"from"`               // Address of the sender
+		BaseURL          string `yaml:"base_url"`           // Address of the server in the links of the emails
+		SMTPHost         string `yaml:"smtp_host"`          // Host of the SMTP server
+		SMTPPort         int    `yaml:"smtp_port"`          // Port of the SMTP server, 587 if not set
+		SMTPUser         string `yaml:"smtp_user"`          // User of the SMTP server, no authentication if empty
+		SMTPPassword     string `yaml:"smtp_password"`      // Password of the SMTP server
+		SMTPPasswordFile string `yaml:"smtp_password_file"` // File with the password of the SMTP server
+		Dir              string `yaml:"dir"`                // Directory of the emails of the file backend
+		VerifyTTL        int    `yaml:"verify_ttl"`         // Lifetime of the link confirming the email in seconds, 1 day if not set
+		ResetTTL         int    `yaml:"reset_ttl"`          // Lifetime of the link resetting the password in seconds, 1 hour if not set
 	}
 
 	// Secure contains the params for encryption
 	// and decryption private data.
 	Secure struct {
-		SecretJWT string `yaml:"secret_jwt"` // SecretJWT needed create JWT token.
-		Key       string `yaml:"key"`        // Key needed for create new cipher.
-		IV        string `yaml:"iv"`         // IV  needed for create new cipher.
+		SecretJWT string   `yaml:"secret_jwt"` // SecretJWT needed create JWT token.
+		Key       string   `yaml:"key"`        // Key needed for create new cipher.
+		IV        string   `yaml:"iv"`         // IV  needed for create new cipher.
+		APIKeys   []string `yaml:"api_keys"`   // APIKeys of the services using the gRPC API.
+
+		// Keys of JWT with ids, the first key signs the new tokens, all keys verify them.
+		// SecretJWT verifies the tokens without the id of the key, it signs them if there are no keys.
+		JWTKeys []JWTKey `yaml:"jwt_keys,omitempty"`
+		// Previous keys of the cipher decrypting the passwords not encrypted by Key yet.
+		PreviousCiphers []CipherKey `yaml:"previous_ciphers,omitempty"`
+		// File of the secrets, YAML with the options of this section, overrides them.
+		File string `yaml:"file,omitempty"`
+
+		JWTAlgorithm string   `yaml:"jwt_algorithm,omitempty"` // Algorithm of the new keys of JWT: HS256, RS256 or EdDSA
+		Issuer       string   `yaml:"issuer,omitempty"`        // Issuer of the tokens in the claim iss
+		Audience     []string `yaml:"audience,omitempty"`      // Audience of the tokens in the claim aud
 	}
-)
 
-// New create config and returns a pointer to the Cfg struct and error.
-func New(logger *zap.SugaredLogger) (*Cfg, error) {
-	cfg := new(Cfg)
-	// read config file
-	if err := cfg.readCfgFile(); err == nil {
-		return cfg, nil
+	// JWTKey contains the key signing JWT.
+	JWTKey struct {
+		ID        string `yaml:"id"`                  // Id of the key in the header kid of the token
+		Algorithm string `yaml:"algorithm,omitempty"` // Algorithm of the signature, HS256 by default
+		Secret    string `yaml:"secret"`              // Secret of HS256 or private key of PKCS #8 in base64
+		Created   int64  `yaml:"created"`             // Time of the creation of the key in unix seconds
 	}
 
-	// default parameters
-	cfg.App = App{
-		Name:       "geospace",
-		Port:       ":3000",
-		MaxRequest: 100,
-		Expiration: 1,
+	// CipherKey contains the key and IV of the cipher of the passwords.
+	CipherKey struct {
+		Key string `yaml:"key"` // Key of the cipher in base64
+		IV  string `yaml:"iv"`  // IV of the cipher in base64
 	}
+)
 
-	// parce flags
-	cfg.readParamFlags()
-	err := cfg.validateConfig()
+// New loads the configuration by Load. On the first run, when the config file
+// does not exist, the connection with the database is checked, the missing keys
+// of encryption are generated and the config file is created with the defaults and
+// the generated keys, the environment variables, the flags and the secrets of
+// the files are not written to it.
+func New(logger *zap.SugaredLogger, flags *Flags) (*Cfg, error) {
+	cfg, err := Load(flags)
 	if err != nil {
-		return cfg, err
+		return nil, err
+	}
+
+	if cfg.found {
+		return cfg, nil
 	}
 
 	// check connection with database
 	// this check only then config file not existing.
-	db, err := sqlx.Connect("mysql", cfg.CreateDSN())
-	if err != nil {
-		return nil, err
+	if driverName := cfg.CfgDatabase.GetDriverName(); driverName != "" {
+		db, err := sqlx.Connect(driverName, cfg.CreateDSN())
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.Ping()
+		if err != nil {
+			return nil, err
+		}
+
+		db.Close()
 	}
 
-	err = db.Ping()
-	if err != nil {
+	// the config file keeps the options of the environment variables and the flags,
+	// so the next start does not need them, but the passwords and the keys of the
+	// environment variables and the files of the secrets are not copied to it
+	file := newCfg(cfg.path, cfg.flags)
+	if err := file.applyEnv(newEnvironment(os.Environ()).withoutSecrets()); err != nil {
 		return nil, err
 	}
 
-	db.Close()
+	file.flags.apply(file)
 
 	// generate keys for encryption/decryption
-	cfg.Secure = Secure{
-		Key: dongle.Encode.FromString(genkey.Create(sizeKeyCipher)).
-			ByBase64().ToString(),
-		IV: dongle.Encode.FromString(genkey.Create(sizeIVCipher)).
-			ByBase64().ToString(),
-		SecretJWT: dongle.Encode.FromString(genkey.Create(sizeKeySecret)).
-			ByBase64().ToString(),
+	// if they are not set by the environment variables
+	if cfg.Secure.Key == "" {
+		cfg.Secure.Key = dongle.Encode.FromString(genkey.Create(sizeKeyCipher)).ByBase64().ToString()
+		file.Secure.Key = cfg.Secure.Key
+	}
+
+	if cfg.Secure.IV == "" {
+		cfg.Secure.IV = dongle.Encode.FromString(genkey.Create(sizeIVCipher)).ByBase64().ToString()
+		file.Secure.IV = cfg.Secure.IV
+	}
+
+	// the new tokens are signed by the key with the id of every algorithm,
+	// SecretJWT of the environment only verifies the tokens of the previous versions
+	if len(cfg.Secure.JWTKeys) == 0 {
+		key, err := newJWTKey(cfg.Secure.GetJWTAlgorithm(), time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Secure.JWTKeys = []JWTKey{key}
+		file.Secure.JWTKeys = cfg.Secure.JWTKeys
 	}
 
 	// create config file
-	err = cfg.createCfgFile()
+	err = file.createCfgFile()
 	if err != nil {
 		// if can't create the config file but config is valid
 		// write about this error in log and using parameters of configuration
@@ -117,18 +340,474 @@ func New(logger *zap.SugaredLogger) (*Cfg, error) {
 	return cfg, nil
 }
 
-// CreateDSN returns a string for connecting to the database.
+// Load returns the configuration made of the layers: the defaults are overridden
+// by the config file, the config file by the environment variables GEOSPACE_*
+// and the environment variables by the flags of the command line. The config file
+// is taken from the flag -config, the variable GEOSPACE_CONFIG or the directory
+// "cfg" of the project. The missing config file is not an error, it is created by New.
+func Load(flags *Flags) (*Cfg, error) {
+	path := flags.configPath()
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+
+	if path == "" {
+		rootDir, err := GetRootDir()
+		if err != nil {
+			return nil, err
+		}
+
+		path = rootDir + cfgDirName + cfgFile
+	}
+
+	cfg := newCfg(path, flags)
+	if err := cfg.load(newEnvironment(os.Environ())); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Reload loads the configuration again from the same config file,
+// environment variables and flags. The config file must exist.
+func (cfg *Cfg) Reload() (*Cfg, error) {
+	next := newCfg(cfg.path, cfg.flags)
+	if err := next.load(newEnvironment(os.Environ())); err != nil {
+		return nil, err
+	}
+
+	if !next.found {
+		return nil, fmt.Errorf("config file %s is not found", cfg.path)
+	}
+
+	return next, nil
+}
+
+// Path returns the path to the config file.
+func (cfg *Cfg) Path() string {
+	return cfg.path
+}
+
+// Found reports whether the config file exists.
+func (cfg *Cfg) Found() bool {
+	return cfg.found
+}
+
+// redacted replaces the secrets in the output of the configuration.
+const redacted = "[redacted]"
+
+// Redacted returns the copy of the configuration with the passwords and the keys replaced.
+func (cfg *Cfg) Redacted() *Cfg {
+	c := *cfg
+
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+
+	redact(&c.CfgDatabase.Password)
+	redact(&c.Cache.RedisPassword)
+	redact(&c.Mail.SMTPPassword)
+	redact(&c.Secure.SecretJWT)
+	redact(&c.Secure.Key)
+	redact(&c.Secure.IV)
+
+	c.Secure.JWTKeys = append([]JWTKey(nil), cfg.Secure.JWTKeys...)
+	for i := range c.Secure.JWTKeys {
+		redact(&c.Secure.JWTKeys[i].Secret)
+	}
+
+	c.Secure.PreviousCiphers = append([]CipherKey(nil), cfg.Secure.PreviousCiphers...)
+	for i := range c.Secure.PreviousCiphers {
+		redact(&c.Secure.PreviousCiphers[i].Key)
+		redact(&c.Secure.PreviousCiphers[i].IV)
+	}
+
+	c.Secure.APIKeys = make([]string, len(cfg.Secure.APIKeys))
+	for i := range c.Secure.APIKeys {
+		c.Secure.APIKeys[i] = redacted
+	}
+
+	return &c
+}
+
+// newCfg returns the configuration with the default parameters.
+func newCfg(path string, flags *Flags) *Cfg {
+	return &Cfg{
+		App: App{
+			Name:       "geospace",
+			Port:       ":3000",
+			MaxRequest: 100,
+			Expiration: 1,
+		},
+		path:  path,
+		flags: flags,
+	}
+}
+
+// load overrides the configuration by the config file, the environment
+// variables and the flags and validates the result.
+func (cfg *Cfg) load(env environment) error {
+	found, err := cfg.readCfgFile()
+	if err != nil {
+		return err
+	}
+
+	cfg.found = found
+	cfg.Import.dir = path.Dir(cfg.path)
+
+	if err := cfg.applyEnv(env); err != nil {
+		return err
+	}
+
+	cfg.flags.apply(cfg)
+
+	if err := cfg.readSecrets(); err != nil {
+		return err
+	}
+
+	return cfg.validateConfig()
+}
+
+// Merge returns the configuration with the options of next safe to change
+// on the running server: the limit of the requests, the shutdown, the level
+// of the log, the routing service and the keys. It also returns the sections of next
+// having other changes, they are applied only after the restart.
+func (cfg *Cfg) Merge(next *Cfg) (*Cfg, []string) {
+	merged := *cfg
+	merged.App.MaxRequest = next.App.MaxRequest
+	merged.App.Expiration = next.App.Expiration
+	merged.App.ShutdownDelay = next.App.ShutdownDelay
+	merged.App.ShutdownTimeout = next.App.ShutdownTimeout
+	merged.Log.Level = next.Log.Level
+	merged.Routing = next.Routing
+	merged.Secure = next.Secure
+
+	var rejected []string
+
+	// the sections are compared after the safe options are taken from next
+	current, updated := reflect.ValueOf(merged), reflect.ValueOf(*next)
+	for i := 0; i < current.NumField(); i++ {
+		if !current.Type().Field(i).IsExported() {
+			continue
+		}
+
+		if !reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			rejected = append(rejected, current.Type().Field(i).Tag.Get("yaml"))
+		}
+	}
+
+	return &merged, rejected
+}
+
+// CreateDSN returns a string for connecting to the database: the DSN of the mysql
+// driver for MariaDB and the libpq connection string for PostgreSQL.
 func (cfg *Cfg) CreateDSN() string {
+	return cfg.CfgDatabase.dsn()
+}
+
+// ReplicaDSNs returns the strings for connecting to the read replicas.
+func (cfg *Cfg) ReplicaDSNs() []string {
+	dsns := make([]string, 0, len(cfg.CfgDatabase.Replicas))
+
+	for _, r := range cfg.CfgDatabase.Replicas {
+		c := cfg.CfgDatabase
+		c.Host, c.UnixSocket = r.Host, r.UnixSocket
+
+		if r.Port != 0 {
+			c.Port = r.Port
+		}
+
+		dsns = append(dsns, c.dsn())
+	}
+
+	return dsns
+}
+
+// dsn returns a string for connecting to the database server.
+func (c CfgDatabase) dsn() string {
+	if c.GetDriver() == DriverPostgres {
+		// the socket of PostgreSQL is set by the directory in the host
+		host := c.GetHost()
+		if c.UnixSocket != "" {
+			host = c.UnixSocket
+		}
+
+		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			quoteDSNValue(host), c.GetPort(), quoteDSNValue(c.User), quoteDSNValue(c.Password),
+			quoteDSNValue(c.Name), quoteDSNValue(c.GetSSLMode()))
+	}
+
 	// if indicated unix socket then we make a connection through it.
-	if cfg.CfgDatabase.UnixSocket != "" {
+	if c.UnixSocket != "" {
 		return fmt.Sprintf("%s:%s@unix(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			cfg.CfgDatabase.User, cfg.CfgDatabase.Password,
-			cfg.CfgDatabase.UnixSocket, cfg.CfgDatabase.Name)
+			c.User, c.Password, c.UnixSocket, c.Name)
+	}
+
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.User, c.Password, c.GetHost(), c.Port, c.Name)
+}
+
+// quoteDSNValue quotes the value of the libpq connection string
+// if it is empty or contains spaces, quotes or backslashes.
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// GetDriver returns the driver of the database, mariadb if not set.
+func (c *CfgDatabase) GetDriver() string {
+	if c.Driver == "" {
+		return DriverMariaDB
+	}
+
+	return c.Driver
+}
+
+// GetDriverName returns the name of the database/sql driver
+// for the database server, empty for the embedded storages.
+func (c *CfgDatabase) GetDriverName() string {
+	switch c.GetDriver() {
+	case DriverMariaDB:
+		return "mysql"
+	case DriverPostgres:
+		return "pgx"
+	default:
+		return ""
+	}
+}
+
+// GetHost returns the host of the database, localhost if not set.
+func (c *CfgDatabase) GetHost() string {
+	if c.Host == "" {
+		return DefaultHost
+	}
+
+	return c.Host
+}
+
+// GetPort returns the port of the database, the default port
+// of PostgreSQL is used if the port is not set.
+func (c *CfgDatabase) GetPort() int {
+	if c.Port == 0 && c.GetDriver() == DriverPostgres {
+		return DefaultPostgresPort
+	}
+
+	return c.Port
+}
+
+// GetSSLMode returns the SSL mode of the connections to PostgreSQL, disable if not set.
+func (c *CfgDatabase) GetSSLMode() string {
+	if c.SSLMode == "" {
+		return DefaultSSLMode
+	}
+
+	return c.SSLMode
+}
+
+// GetMaxIdleConns returns the maximum number of idle connections, 100 if not set.
+func (p *Pool) GetMaxIdleConns() int {
+	if p.MaxIdleConns <= 0 {
+		return DefaultMaxIdleConns
+	}
+
+	return p.MaxIdleConns
+}
+
+// GetConnMaxLifetime returns the maximum lifetime of a connection, 15 minutes if not set.
+func (p *Pool) GetConnMaxLifetime() time.Duration {
+	if p.ConnMaxLifetime <= 0 {
+		return DefaultConnMaxLifetime
+	}
+
+	return time.Duration(p.ConnMaxLifetime) * time.Second
+}
+
+// GetConnMaxIdleTime returns the maximum idle time of a connection, 0 (unlimited) if not set.
+func (p *Pool) GetConnMaxIdleTime() time.Duration {
+	return time.Duration(p.ConnMaxIdleTime) * time.Second
+}
+
+// GetReplicaCheckInterval returns the interval of the health checks of the replicas.
+func (p *Pool) GetReplicaCheckInterval() time.Duration {
+	if p.ReplicaCheckInterval <= 0 {
+		return DefaultReplicaCheckInterval
+	}
+
+	return time.Duration(p.ReplicaCheckInterval) * time.Second
+}
+
+// GetShutdownDelay returns the delay of the shutdown, 0 (no delay) if not set.
+func (a *App) GetShutdownDelay() time.Duration {
+	return time.Duration(a.ShutdownDelay) * time.Second
+}
+
+// GetShutdownTimeout returns the maximum time of the requests in flight on shutdown.
+func (a *App) GetShutdownTimeout() time.Duration {
+	if a.ShutdownTimeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+
+	return time.Duration(a.ShutdownTimeout) * time.Second
+}
+
+// GetURL returns the address of the routing service.
+func (r *Routing) GetURL() string {
+	if r.URL == "" {
+		return DefaultRoutingURL
 	}
 
-	return fmt.Sprintf("host=localhost user=%s password=%s dbname=%s port=%d",
-		cfg.CfgDatabase.User, cfg.CfgDatabase.Password,
-		cfg.CfgDatabase.Name, cfg.CfgDatabase.Port)
+	return strings.TrimSuffix(r.URL, "/")
+}
+
+// GetTimeout returns the maximum time of the request to the routing service.
+func (r *Routing) GetTimeout() time.Duration {
+	if r.Timeout <= 0 {
+		return DefaultRoutingTimeout
+	}
+
+	return time.Duration(r.Timeout) * time.Millisecond
+}
+
+// GetBackend returns the backend of the emails, none if not set.
+func (m *Mail) GetBackend() string {
+	if m.Backend == "" {
+		return MailNone
+	}
+
+	return m.Backend
+}
+
+// Enabled checks whether the emails are sent to the users.
+func (m *Mail) Enabled() bool {
+	return m.GetBackend() != MailNone
+}
+
+// GetBaseURL returns the address of the server in the links without the trailing slash.
+func (m *Mail) GetBaseURL() string {
+	return strings.TrimSuffix(m.BaseURL, "/")
+}
+
+// GetSMTPPort returns the port of the SMTP server, 587 if not set.
+func (m *Mail) GetSMTPPort() int {
+	if m.SMTPPort <= 0 {
+		return DefaultSMTPPort
+	}
+
+	return m.SMTPPort
+}
+
+// GetVerifyTTL returns the lifetime of the link confirming the email, 1 day if not set.
+func (m *Mail) GetVerifyTTL() time.Duration {
+	if m.VerifyTTL <= 0 {
+		return DefaultVerifyTTL
+	}
+
+	return time.Duration(m.VerifyTTL) * time.Second
+}
+
+// GetResetTTL returns the lifetime of the link resetting the password, 1 hour if not set.
+func (m *Mail) GetResetTTL() time.Duration {
+	if m.ResetTTL <= 0 {
+		return DefaultResetTTL
+	}
+
+	return time.Duration(m.ResetTTL) * time.Second
+}
+
+// GetBackend returns the backend of the cache, memory if not set.
+func (c *Cache) GetBackend() string {
+	if c.Backend == "" {
+		return CacheMemory
+	}
+
+	return c.Backend
+}
+
+// GetSize returns the maximum quantity of the entries in memory, 10000 if not set.
+func (c *Cache) GetSize() int {
+	if c.Size <= 0 {
+		return DefaultCacheSize
+	}
+
+	return c.Size
+}
+
+// GetTTL returns the time to live of the entries, 1 hour if not set.
+func (c *Cache) GetTTL() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultCacheTTL
+	}
+
+	return time.Duration(c.TTL) * time.Second
+}
+
+// GetRedisAddr returns the address of Redis, localhost:6379 if not set.
+func (c *Cache) GetRedisAddr() string {
+	if c.RedisAddr == "" {
+		return DefaultRedisAddr
+	}
+
+	return c.RedisAddr
+}
+
+// GetExporter returns the exporter of the spans, none if not set.
+func (t *Tracing) GetExporter() string {
+	if t.Exporter == "" {
+		return TracingNone
+	}
+
+	return t.Exporter
+}
+
+// GetEndpoint returns the address of the collector, localhost:4318 if not set.
+func (t *Tracing) GetEndpoint() string {
+	if t.Endpoint == "" {
+		return DefaultOTLPEndpoint
+	}
+
+	return t.Endpoint
+}
+
+// GetSampleRatio returns the share of the traced requests, all requests are traced if not set.
+func (t *Tracing) GetSampleRatio() float64 {
+	if t.SampleRatio == nil {
+		return 1
+	}
+
+	return *t.SampleRatio
+}
+
+// GetLevel returns the minimum level of the lines of the log, info if not set.
+func (l *Log) GetLevel() string {
+	if l.Level == "" {
+		return DefaultLogLevel
+	}
+
+	return l.Level
+}
+
+// GetSampleRatio returns the share of the successful requests written to the access log,
+// all requests are written if not set.
+func (l *Log) GetSampleRatio() float64 {
+	if l.SampleRatio == nil {
+		return 1
+	}
+
+	return *l.SampleRatio
+}
+
+// GetQueryTimeout returns the timeout of one query to the database.
+func (c *CfgDatabase) GetQueryTimeout() time.Duration {
+	if c.QueryTimeout <= 0 {
+		return DefaultQueryTimeout
+	}
+
+	return time.Duration(c.QueryTimeout) * time.Millisecond
 }
 
 // GetKeyCipher returns key after decrypt.
@@ -146,19 +825,47 @@ func (s *Secure) GetSecretJWT() string {
 	return dongle.Decode.FromString(s.SecretJWT).ByBase64().ToString()
 }
 
-// GetRootDir returns the root directory of the project.
-func GetRootDir() (string, error) {
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return "", err
+// GetJWTAlgorithm returns the algorithm of the new keys of JWT, HS256 by default.
+func (s *Secure) GetJWTAlgorithm() string {
+	if s.JWTAlgorithm == "" {
+		return JWTAlgorithmHS256
+	}
+
+	return s.JWTAlgorithm
+}
+
+// GetIssuer returns the issuer of the tokens, geospace by default.
+func (s *Secure) GetIssuer() string {
+	if s.Issuer == "" {
+		return DefaultIssuer
+	}
+
+	return s.Issuer
+}
+
+// GetAudience returns the audience of the tokens, geospace by default.
+func (s *Secure) GetAudience() []string {
+	if len(s.Audience) == 0 {
+		return []string{DefaultIssuer}
 	}
 
-	rootDir, err := os.Open(path.Join(currentDir, "../../"))
+	return s.Audience
+}
+
+// GetConfigDir returns the directory of the config file the section was loaded from.
+func (i Import) GetConfigDir() string {
+	return i.dir
+}
+
+// GetRootDir returns the root directory of the project
+// for the server started from the directory cmd/server.
+func GetRootDir() (string, error) {
+	currentDir, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
 
-	return rootDir.Name(), nil
+	return path.Join(currentDir, "../../"), nil
 }
 
 // ReadCfgFile perfoms read the config file.
@@ -208,129 +915,178 @@ func ReadCfgFile() (Cfg, error) {
 }
 
 // validateConfig performs validation of the configuration and
-// returns error if required config parameters are not valid.
-// This function checks only required parameters.
+// returns the errors of all invalid parameters.
 func (cfg *Cfg) validateConfig() error {
-	switch {
-	case len(cfg.CfgDatabase.Name) == 0:
-		return fmt.Errorf("database name cannot be empty")
-	case len(cfg.CfgDatabase.User) == 0:
-		return fmt.Errorf("database username cannot be empty")
-	case len(cfg.CfgDatabase.Password) == 0:
-		return fmt.Errorf("database password cannot be empty")
-	}
+	var errs []error
 
-	if len(cfg.CfgDatabase.UnixSocket) == 0 && cfg.CfgDatabase.Port == 0 {
-		return fmt.Errorf("unix socket or port of database cannot be empty")
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
 
-	return nil
-}
+	db := &cfg.CfgDatabase
 
-// readCfgFile performs read configuration from yaml file.
-func (cfg *Cfg) readCfgFile() error {
-	// get root directory
-	rootDir, err := GetRootDir()
-	if err != nil {
-		return err
+	switch db.GetDriver() {
+	case DriverMariaDB, DriverPostgres:
+		check(db.Name != "", "database name cannot be empty")
+		check(db.User != "", "database username cannot be empty")
+		check(db.Password != "", "database password cannot be empty")
+		check(db.UnixSocket != "" || db.GetPort() != 0, "unix socket or port of database cannot be empty")
+		check(db.Port >= 0 && db.Port <= maxPort, "port of database %d is out of range", db.Port)
+
+		if db.GetDriver() == DriverPostgres {
+			switch db.GetSSLMode() {
+			case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+			default:
+				check(false, "unknown sslmode %q of PostgreSQL", db.SSLMode)
+			}
+		}
+
+		for i, r := range db.Replicas {
+			check(r.Host != "" || r.UnixSocket != "", "host or unix socket of replica %d cannot be empty", i+1)
+			check(r.Port >= 0 && r.Port <= maxPort, "port of replica %d is out of range", i+1)
+		}
+	case DriverSQLite:
+		check(db.Path != "", "path of the SQLite database cannot be empty")
+	case DriverMemory:
+	default:
+		check(false, "unknown database driver %q, use mariadb, postgres, sqlite or memory", db.Driver)
 	}
 
-	// create path to cfg dir
-	cfgPath := rootDir + cfgDirName
+	check(db.QueryTimeout >= 0, "query_timeout of database cannot be negative")
+	check(db.Pool.MaxOpenConns >= 0 && db.Pool.MaxIdleConns >= 0 && db.Pool.ConnMaxLifetime >= 0 &&
+		db.Pool.ConnMaxIdleTime >= 0 && db.Pool.ReplicaCheckInterval >= 0, "parameters of the pool cannot be negative")
 
-	// check dir of cfg exists
-	if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-		return err
+	check(validAddr(cfg.App.Port), "port of the application %q must be [host]:port", cfg.App.Port)
+	check(cfg.App.GRPCPort == "" || validAddr(cfg.App.GRPCPort),
+		"port of the gRPC server %q must be [host]:port", cfg.App.GRPCPort)
+	check(cfg.App.MaxRequest >= 0 && cfg.App.Expiration >= 0 && cfg.App.ShutdownDelay >= 0 &&
+		cfg.App.ShutdownTimeout >= 0, "max_request, expiration and shutdown of the application cannot be negative")
+
+	switch cfg.Cache.GetBackend() {
+	case CacheMemory, CacheRedis, CacheNone:
+	default:
+		check(false, "unknown backend of the cache %q, use memory, redis or none", cfg.Cache.Backend)
 	}
 
-	// open cfg file
-	f, err := os.Open(cfgPath + cfgFile)
-	if err != nil {
-		return err
+	check(cfg.Cache.Size >= 0 && cfg.Cache.TTL >= 0 && cfg.Cache.RedisDB >= 0,
+		"size, ttl and redis_db of the cache cannot be negative")
+
+	switch cfg.Tracing.GetExporter() {
+	case TracingNone, TracingStdout, TracingOTLP:
+	default:
+		check(false, "unknown exporter of the traces %q, use none, stdout or otlp", cfg.Tracing.Exporter)
 	}
 
-	defer f.Close()
+	check(validRatio(cfg.Tracing.SampleRatio), "sample_ratio of the tracing must be from 0 to 1")
 
-	// read file to buffer
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(f)
-	if err != nil {
-		return err
+	_, err := zapcore.ParseLevel(cfg.Log.GetLevel())
+	check(err == nil, "unknown level of the log %q, use debug, info, warn or error", cfg.Log.Level)
+	check(validRatio(cfg.Log.SampleRatio), "sample_ratio of the log must be from 0 to 1")
+
+	u, err := url.Parse(cfg.Routing.GetURL())
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"url of the routing service %q must be http(s)://host[:port]", cfg.Routing.URL)
+	check(cfg.Routing.Timeout >= 0, "timeout of the routing service cannot be negative")
+
+	errs = append(errs, cfg.Mail.validate()...)
+
+	// keys are generated on the first run
+	if cfg.found {
+		check((cfg.Secure.SecretJWT != "" || len(cfg.Secure.JWTKeys) > 0) && cfg.Secure.Key != "" && cfg.Secure.IV != "",
+			"secret_jwt or jwt_keys, key and iv of the secure section cannot be empty")
 	}
 
-	// unmarshal config from yaml
-	return yaml.Unmarshal(buf.Bytes(), cfg)
+	errs = append(errs, cfg.Secure.validate()...)
+
+	return errors.Join(errs...)
 }
 
-// createCfgFile performs create configuration yaml file.
-func (cfg *Cfg) createCfgFile() error {
-	// get root directory
-	rootDir, err := GetRootDir()
-	if err != nil {
-		return err
-	}
+// validate returns the errors of the params of the emails.
+func (m *Mail) validate() []error {
+	var errs []error
 
-	// create cfg dir
-	cfgPath := rootDir + cfgDirName
-	if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-		err = os.Mkdir(cfgPath, os.ModePerm)
-		if err != nil {
-			return err
+	switch m.GetBackend() {
+	case MailNone:
+		return nil
+	case MailSMTP:
+		if m.SMTPHost == "" {
+			errs = append(errs, errors.New("smtp_host of the mail cannot be empty"))
 		}
+
+		if m.SMTPPort < 0 || m.SMTPPort > maxPort {
+			errs = append(errs, fmt.Errorf("smtp_port of the mail %d is out of range", m.SMTPPort))
+		}
+	case MailFile:
+		if m.Dir == "" {
+			errs = append(errs, errors.New("dir of the mail cannot be empty"))
+		}
+	case MailLog:
+	default:
+		return []error{fmt.Errorf("unknown backend of the mail %q, use none, smtp, file or log", m.Backend)}
 	}
 
-	// marshal config to yaml
-	b, err := yaml.Marshal(cfg)
-	if err != nil {
-		return err
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		errs = append(errs, fmt.Errorf("from of the mail %q must be the email address", m.From))
+	}
+
+	if u, err := url.Parse(m.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("base_url of the mail %q must be http(s)://host[:port]", m.BaseURL))
+	}
+
+	if m.VerifyTTL < 0 || m.ResetTTL < 0 {
+		errs = append(errs, errors.New("verify_ttl and reset_ttl of the mail cannot be negative"))
 	}
 
-	// write config to file
-	return os.WriteFile(cfgPath+cfgFile, b, os.ModePerm)
+	return errs
 }
 
-// readParamFlags performs reading parameters from flags the command line.
-func (cfg *Cfg) readParamFlags() {
-	var (
-		// required parameters
-		dbName   = flag.String("d", "", "Name of the database")
-		dbUser   = flag.String("u", "", "User name of the database")
-		dbPass   = flag.String("p", "", "Password of the database")
-		dbSocket = flag.String("s", "", "Socket of connection to database")
-		dbPort   = flag.Int("t", 0, "Port of the database")
+// validAddr checks that the address is [host]:port.
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
 
-		// optional parameters
-		appName    = flag.String("n", "", "Name of the database")
-		port       = flag.Int("a", 0, "Port for running the application")
-		maxRequest = flag.Int("r", 0, "Max request quantity in seconds")
-		expiration = flag.Int("e", 0, "Expiration period in seconds")
-	)
+	n, err := strconv.Atoi(port)
 
-	flag.Parse()
+	return err == nil && n > 0 && n <= maxPort
+}
 
-	cfg.CfgDatabase = CfgDatabase{
-		Name:       *dbName,
-		User:       *dbUser,
-		Password:   *dbPass,
-		UnixSocket: *dbSocket,
-		Port:       *dbPort,
-	}
+// validRatio checks that the ratio is not set or from 0 to 1.
+func validRatio(ratio *float64) bool {
+	return ratio == nil || (*ratio >= 0 && *ratio <= 1)
+}
 
-	// check optional parameters
-	// if empty to stay default value
-	if *port != 0 {
-		cfg.App.Port = fmt.Sprintf(":%d", *port)
+// readCfgFile performs read configuration from yaml file,
+// it reports whether the file exists.
+func (cfg *Cfg) readCfgFile() (bool, error) {
+	b, err := os.ReadFile(cfg.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
 	}
 
-	if *maxRequest != 0 {
-		cfg.App.MaxRequest = *maxRequest
+	if err != nil {
+		return false, err
 	}
 
-	if *expiration != 0 {
-		cfg.App.Expiration = *expiration
+	// unknown parameters are rejected, they are mistakes
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		return true, fmt.Errorf("config file %s: %w", cfg.path, err)
 	}
 
-	if *appName != "" {
-		cfg.App.Name = *appName
+	return true, nil
+}
+
+// createCfgFile performs create configuration yaml file.
+func (cfg *Cfg) createCfgFile() error {
+	// marshal config to yaml
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
 	}
+
+	// write config to file, it contains the secrets
+	return writeSecretFile(cfg.path, b)
 }
@@ -0,0 +1,470 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"go.uber.org/zap"
+)
+
+func TestCreateDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		db   config.CfgDatabase
+		want string
+	}{
+		{
+			name: "mariadb tcp",
+			db:   config.CfgDatabase{Name: "geo", User: "user", Password: "pass", Port: 3306},
+			want: "user:pass@tcp(localhost:3306)/geo?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		{
+			name: "mariadb socket",
+			db:   config.CfgDatabase{Name: "geo", User: "user", Password: "pass", UnixSocket: "/run/mysqld/mysqld.sock"},
+			want: "user:pass@unix(/run/mysqld/mysqld.sock)/geo?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		{
+			name: "postgres defaults",
+			db:   config.CfgDatabase{Driver: config.DriverPostgres, Name: "geo", User: "user", Password: "pass"},
+			want: "host=localhost port=5432 user=user password=pass dbname=geo sslmode=disable",
+		},
+		{
+			name: "postgres quoted",
+			db: config.CfgDatabase{Driver: config.DriverPostgres, Name: "geo", User: "user", Password: `it's a \ pass`,
+				Host: "db.local", Port: 6432, SSLMode: "require"},
+			want: `host=db.local port=6432 user=user password='it\'s a \\ pass' dbname=geo sslmode=require`,
+		},
+		{
+			name: "postgres socket",
+			db:   config.CfgDatabase{Driver: config.DriverPostgres, Name: "geo", User: "user", Password: "pass", UnixSocket: "/var/run/postgresql"},
+			want: "host=/var/run/postgresql port=5432 user=user password=pass dbname=geo sslmode=disable",
+		},
+	}
+
+	for _, tt := range tests {
+		cfg := config.Cfg{CfgDatabase: tt.db}
+		if got := cfg.CreateDSN(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplicaDSNs(t *testing.T) {
+	cfg := config.Cfg{CfgDatabase: config.CfgDatabase{
+		Driver: config.DriverPostgres, Name: "geo", User: "user", Password: "pass", Host: "primary", Port: 6432,
+		Replicas: []config.Replica{{Host: "replica1"}, {Host: "replica2", Port: 5433}},
+	}}
+
+	want := []string{
+		"host=replica1 port=6432 user=user password=pass dbname=geo sslmode=disable",
+		"host=replica2 port=5433 user=user password=pass dbname=geo sslmode=disable",
+	}
+
+	got := cfg.ReplicaDSNs()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cfg := &config.Cfg{
+		CfgDatabase: config.CfgDatabase{Driver: config.DriverSQLite, Path: "geo.db"},
+		App:         config.App{Name: "geospace", Port: ":3000", MaxRequest: 100, Expiration: 1},
+	}
+
+	next := &config.Cfg{
+		CfgDatabase: config.CfgDatabase{Driver: config.DriverSQLite, Path: "other.db"},
+		App:         config.App{Name: "geospace", Port: ":3000", MaxRequest: 10, Expiration: 2},
+		Log:         config.Log{Level: "debug"},
+		Routing:     config.Routing{URL: "http://osrm.local:5000/", Timeout: 200},
+	}
+
+	merged, rejected := cfg.Merge(next)
+
+	if len(rejected) != 1 || rejected[0] != "database" {
+		t.Errorf("expected rejected database, got %q", rejected)
+	}
+
+	if merged.CfgDatabase.Path != "geo.db" || merged.App.MaxRequest != 10 || merged.App.Expiration != 2 ||
+		merged.Log.GetLevel() != "debug" || merged.Routing.GetURL() != "http://osrm.local:5000" {
+		t.Errorf("unexpected merged configuration %+v", merged)
+	}
+
+	if cfg.App.MaxRequest != 100 {
+		t.Error("running configuration is changed")
+	}
+
+	// the port is changed only by the restart
+	next.CfgDatabase.Path = "geo.db"
+	next.App.Port = ":4000"
+
+	if merged, rejected = cfg.Merge(next); len(rejected) != 1 || rejected[0] != "app" || merged.App.Port != ":3000" {
+		t.Errorf("expected rejected app, got %q, port %s", rejected, merged.App.Port)
+	}
+}
+
+// writeConfig writes the config file and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// parseFlags returns the flags of the configuration parsed from the arguments.
+func parseFlags(t *testing.T, args ...string) *config.Flags {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := config.NewFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return flags
+}
+
+const testConfig = `
+database:
+  driver: postgres
+  name: geo
+  user: geo
+  password: secret
+  host: db-file
+app:
+  port: ":3000"
+  max_request: 50
+secure:
+  secret_jwt: c2VjcmV0
+  key: a2V5
+  iv: MTIzNDU2Nzg=
+`
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, testConfig)
+
+	t.Setenv(config.EnvConfig, path)
+	t.Setenv("GEOSPACE_DATABASE_HOST", "db-env")
+	t.Setenv("GEOSPACE_DATABASE_PORT", "6432")
+	t.Setenv("GEOSPACE_DATABASE_REPLICAS_0_HOST", "replica-env")
+	t.Setenv("GEOSPACE_SECURE_API_KEYS", "one, two")
+	t.Setenv("GEOSPACE_LOG_SAMPLE_RATIO", "0.5")
+	t.Setenv("GEOSPACE_IMPORT_COLUMNS", "name=city,country_code=cc")
+	t.Setenv("GEOSPACE_SECURE_JWT_KEYS_0_ID", "env")
+	t.Setenv("GEOSPACE_SECURE_JWT_KEYS_0_SECRET", "c2VjcmV0")
+	t.Setenv("GEOSPACE_SECURE_JWT_KEYS_0_CREATED", "1700000000")
+
+	cfg, err := config.Load(parseFlags(t, "-o", "db-flag", "-a", "4000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.Found() || cfg.Path() != path {
+		t.Errorf("config file %s is not loaded", path)
+	}
+
+	// file < env < flags, defaults are kept if not set
+	db := cfg.CfgDatabase
+	if db.Host != "db-flag" || db.Port != 6432 || db.Name != "geo" || len(db.Replicas) != 1 || db.Replicas[0].Host != "replica-env" {
+		t.Errorf("unexpected database %+v", db)
+	}
+
+	if cfg.App.Port != ":4000" || cfg.App.MaxRequest != 50 || cfg.App.Name != "geospace" {
+		t.Errorf("unexpected app %+v", cfg.App)
+	}
+
+	if len(cfg.Secure.APIKeys) != 2 || cfg.Secure.APIKeys[1] != "two" || cfg.Log.GetSampleRatio() != 0.5 ||
+		cfg.Import.Columns["country_code"] != "cc" {
+		t.Errorf("unexpected lists %q, %v, %v", cfg.Secure.APIKeys, cfg.Log.GetSampleRatio(), cfg.Import.Columns)
+	}
+
+	// int64 of the list of the structs
+	if keys := cfg.Secure.JWTKeys; len(keys) != 1 || keys[0].ID != "env" || keys[0].Created != 1700000000 {
+		t.Errorf("unexpected keys of JWT %+v", keys)
+	}
+
+	// the flag of the config file takes precedence over the variable
+	if cfg, err = config.Load(parseFlags(t, "-config", filepath.Join(t.TempDir(), "missing.yaml"), "-b", "memory")); err != nil || cfg.Found() {
+		t.Errorf("expected missing config file, got %v", err)
+	}
+}
+
+func TestFirstRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	password := filepath.Join(dir, "password")
+	if err := os.WriteFile(password, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(config.EnvConfig, path)
+	t.Setenv("GEOSPACE_DATABASE_PASSWORD_FILE", password)
+	t.Setenv("GEOSPACE_CACHE_REDIS_PASSWORD", "from-env")
+	t.Setenv("GEOSPACE_DATABASE_DRIVER", config.DriverMemory)
+	t.Setenv("GEOSPACE_SECURE_IV", "aXYtZnJvbS1lbnYtMTIzNA==")
+
+	cfg, err := config.New(zap.NewNop().Sugar(), parseFlags(t, "-o", "db-flag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256 signs by the key with the id as well
+	if len(cfg.Secure.JWTKeys) != 1 || cfg.Secure.JWTKeys[0].ID == "" ||
+		cfg.Secure.JWTKeys[0].GetAlgorithm() != config.JWTAlgorithmHS256 || cfg.Secure.SecretJWT != "" {
+		t.Errorf("unexpected keys of JWT %+v, %q", cfg.Secure.JWTKeys, cfg.Secure.SecretJWT)
+	}
+
+	if cfg.CfgDatabase.Password != "from-file" || cfg.Secure.Key == "" || cfg.Secure.IV != "aXYtZnJvbS1lbnYtMTIzNA==" {
+		t.Fatalf("unexpected configuration %+v", cfg)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file keeps the options of the flags and the environment variables
+	// and the generated keys, but not the secrets of the environment variables and the files
+	for _, secret := range []string{"from-file", "from-env", "aXYtZnJvbS1lbnYtMTIzNA=="} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("config file contains %q:\n%s", secret, b)
+		}
+	}
+
+	for _, option := range []string{"db-flag", "memory", password, cfg.Secure.Key} {
+		if !strings.Contains(string(b), option) {
+			t.Errorf("config file does not contain %q:\n%s", option, b)
+		}
+	}
+
+	// the next start without the flags and the variables
+	// of the options uses the file
+	os.Unsetenv("GEOSPACE_DATABASE_DRIVER")
+	os.Unsetenv("GEOSPACE_CACHE_REDIS_PASSWORD")
+
+	next, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.CfgDatabase.Host != "db-flag" || next.CfgDatabase.GetDriver() != config.DriverMemory ||
+		next.CfgDatabase.Password != "from-file" || next.Cache.RedisPassword != "" {
+		t.Errorf("options are not kept %+v, %+v", next.CfgDatabase, next.Cache)
+	}
+
+	if next.Secure.Key != cfg.Secure.Key || next.Secure.IV != cfg.Secure.IV ||
+		len(next.Secure.JWTKeys) != 1 || next.Secure.JWTKeys[0] != cfg.Secure.JWTKeys[0] {
+		t.Errorf("generated keys are not kept %+v", next.Secure)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    []string
+	}{
+		{
+			name:    "unknown option",
+			content: testConfig + "\ncache:\n  backnd: redis\n",
+			want:    []string{"field backnd not found"},
+		},
+		{
+			name:    "environment",
+			content: testConfig,
+			env:     map[string]string{"GEOSPACE_APP_MAX_REQUEST": "many"},
+			want:    []string{`GEOSPACE_APP_MAX_REQUEST: invalid integer "many"`},
+		},
+		{
+			name:    "int64",
+			content: testConfig,
+			env:     map[string]string{"GEOSPACE_SECURE_JWT_KEYS_0_CREATED": "yesterday"},
+			want:    []string{`GEOSPACE_SECURE_JWT_KEYS_0_CREATED: invalid integer "yesterday"`},
+		},
+		{
+			name:    "all errors",
+			content: testConfig,
+			env: map[string]string{
+				"GEOSPACE_DATABASE_SSLMODE": "weird",
+				"GEOSPACE_APP_PORT":         "3000",
+				"GEOSPACE_CACHE_BACKEND":    "disk",
+				"GEOSPACE_LOG_LEVEL":        "loud",
+				"GEOSPACE_ROUTING_URL":      "osrm:5000",
+			},
+			want: []string{"sslmode", "port of the application", "backend of the cache", "level of the log", "routing service"},
+		},
+		{
+			name:    "keys of JWT",
+			content: testConfig,
+			env: map[string]string{
+				"GEOSPACE_SECURE_JWT_ALGORITHM":        "ES256",
+				"GEOSPACE_SECURE_JWT_KEYS_0_ID":        "rsa",
+				"GEOSPACE_SECURE_JWT_KEYS_0_ALGORITHM": "RS256",
+				"GEOSPACE_SECURE_JWT_KEYS_0_SECRET":    "c2VjcmV0",
+			},
+			want: []string{"jwt_algorithm of the secure section", `invalid private key of JWT "rsa"`},
+		},
+		{
+			name:    "mail",
+			content: testConfig,
+			env:     map[string]string{"GEOSPACE_MAIL_BACKEND": "smtp", "GEOSPACE_MAIL_FROM": "geospace"},
+			want:    []string{"smtp_host of the mail", `from of the mail "geospace"`, "base_url of the mail"},
+		},
+		{
+			name:    "missing keys",
+			content: strings.Split(testConfig, "secure:")[0],
+			want:    []string{"key and iv of the secure section cannot be empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.EnvConfig, writeConfig(t, tt.content))
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := config.Load(nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &config.Cfg{
+		CfgDatabase: config.CfgDatabase{User: "geo", Password: "secret"},
+		Secure:      config.Secure{SecretJWT: "c2VjcmV0", APIKeys: []string{"one"}},
+	}
+
+	redacted := cfg.Redacted()
+
+	if redacted.CfgDatabase.Password == "secret" || redacted.Secure.SecretJWT == "c2VjcmV0" ||
+		redacted.Secure.APIKeys[0] == "one" || redacted.Secure.IV != "" || redacted.CfgDatabase.User != "geo" {
+		t.Errorf("unexpected redacted configuration %+v", redacted)
+	}
+
+	if cfg.CfgDatabase.Password != "secret" || cfg.Secure.APIKeys[0] != "one" {
+		t.Error("configuration is changed")
+	}
+}
+
+func TestRotateJWT(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	lifetime := 72 * time.Hour
+
+	s := config.Secure{SecretJWT: "c2VjcmV0"}
+
+	first, removed, err := s.RotateJWT(config.JWTAlgorithmHS256, lifetime, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(removed) != 0 || len(s.JWTKeys) != 1 || s.JWTKeys[0] != first || s.SecretJWT == "" {
+		t.Fatalf("unexpected first rotation %+v, removed %q", s, removed)
+	}
+
+	// the tokens signed by secret_jwt and the first key are valid
+	second, removed, err := s.RotateJWT(config.JWTAlgorithmRS256, lifetime, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(removed) != 0 || len(s.JWTKeys) != 2 || s.JWTKeys[0] != second || second.ID == first.ID {
+		t.Fatalf("unexpected second rotation %+v, removed %q", s, removed)
+	}
+
+	// the tokens of secret_jwt and the first key expired, the second key is kept
+	third, removed, err := s.RotateJWT(config.JWTAlgorithmEdDSA, lifetime, now.Add(lifetime+2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(removed) != 2 || removed[0] != first.ID || removed[1] != "" || s.SecretJWT != "" {
+		t.Errorf("expected the first key and secret_jwt removed, got %q", removed)
+	}
+
+	if len(s.JWTKeys) != 2 || s.JWTKeys[0] != third || s.JWTKeys[1] != second {
+		t.Errorf("unexpected keys %+v", s.JWTKeys)
+	}
+
+	// the private keys of the asymmetric algorithms are generated
+	for _, key := range []config.JWTKey{second, third} {
+		if _, err := key.GetPrivateKey(); err != nil {
+			t.Errorf("invalid private key %s of %s: %v", key.ID, key.Algorithm, err)
+		}
+	}
+
+	if _, err := first.GetPrivateKey(); err == nil || first.Algorithm != "" {
+		t.Errorf("unexpected private key of HS256 %+v", first)
+	}
+}
+
+func TestSecretsFile(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets.yaml")
+	password := filepath.Join(dir, "password")
+
+	if err := os.WriteFile(secrets, []byte("secret_jwt: c2VjcmV0Mg==\nkey: a2V5\niv: MTIzNDU2Nzg=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(password, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(config.EnvConfig, writeConfig(t, strings.Split(testConfig, "secure:")[0]))
+	t.Setenv("GEOSPACE_SECURE_FILE", secrets)
+	t.Setenv("GEOSPACE_DATABASE_PASSWORD_FILE", password)
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Secure.GetSecretJWT() != "secret2" || cfg.CfgDatabase.Password != "from-file" {
+		t.Errorf("secrets are not read from the files: %+v, %q", cfg.Secure, cfg.CfgDatabase.Password)
+	}
+
+	// the keys are rotated in the file of the secrets
+	path, err := cfg.RotateKeys(func(s *config.Secure) error {
+		s.RotateCipher()
+		return nil
+	})
+	if err != nil || path != secrets {
+		t.Fatalf("expected rotation in %s, got %s, %v", secrets, path, err)
+	}
+
+	info, err := os.Stat(secrets)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("unexpected file of the secrets %v, %v", info, err)
+	}
+
+	if cfg, err = config.Load(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Secure.Key == "a2V5" || len(cfg.Secure.PreviousCiphers) != 1 || cfg.Secure.PreviousCiphers[0].Key != "a2V5" {
+		t.Errorf("unexpected rotated cipher %+v", cfg.Secure)
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the options
+// of the config file. The name of the variable is made of the keys of the option
+// in the config file: GEOSPACE_DATABASE_HOST, GEOSPACE_APP_MAX_REQUEST,
+// GEOSPACE_DATABASE_POOL_MAX_OPEN_CONNS. Lists are separated by commas,
+// maps are written as key=value pairs separated by commas and the replicas
+// are numbered from 0: GEOSPACE_DATABASE_REPLICAS_0_HOST.
+const EnvPrefix = "GEOSPACE"
+
+// EnvConfig is the environment variable of the path to the config file.
+const EnvConfig = EnvPrefix + "_CONFIG"
+
+// environment is the environment variables by the names.
+type environment map[string]string
+
+// variables of the passwords and the keys, they are not written to the config file
+var (
+	secretEnv = map[string]bool{
+		EnvPrefix + "_DATABASE_PASSWORD":    true,
+		EnvPrefix + "_CACHE_REDIS_PASSWORD": true,
+		EnvPrefix + "_MAIL_SMTP_PASSWORD":   true,
+		EnvPrefix + "_SECURE_SECRET_JWT":    true,
+		EnvPrefix + "_SECURE_KEY":           true,
+		EnvPrefix + "_SECURE_IV":            true,
+		EnvPrefix + "_SECURE_API_KEYS":      true,
+	}
+	secretEnvPrefixes = []string{EnvPrefix + "_SECURE_JWT_KEYS_", EnvPrefix + "_SECURE_PREVIOUS_CIPHERS_"}
+)
+
+// newEnvironment returns the variables of the list of the key=value pairs as os.Environ.
+func newEnvironment(environ []string) environment {
+	env := make(environment, len(environ))
+
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+
+	return env
+}
+
+// hasPrefix checks that any variable starts with the prefix.
+func (env environment) hasPrefix(prefix string) bool {
+	for name := range env {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutSecrets returns the variables except the passwords and the keys.
+func (env environment) withoutSecrets() environment {
+	public := make(environment, len(env))
+
+	for name, value := range env {
+		if secretEnv[name] || hasAnyPrefix(name, secretEnvPrefixes) {
+			continue
+		}
+
+		public[name] = value
+	}
+
+	return public
+}
+
+// hasAnyPrefix checks that the name starts with any of the prefixes.
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyEnv overrides the options of the configuration by the environment variables.
+func (cfg *Cfg) applyEnv(env environment) error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), EnvPrefix, env)
+}
+
+// applyEnvStruct overrides the fields of the struct by the variables named by the prefix and the yaml keys.
+func applyEnvStruct(v reflect.Value, prefix string, env environment) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(key)
+		if err := applyEnvValue(v.Field(i), name, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyEnvValue sets the value of the option from the variable of the name.
+func applyEnvValue(v reflect.Value, name string, env environment) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return applyEnvStruct(v, name, env)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			return applyEnvStructs(v, name, env)
+		}
+	}
+
+	value, ok := env[name]
+	if !ok {
+		return nil
+	}
+
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("environment variable %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// applyEnvStructs overrides the list of the structs by the numbered variables,
+// the list is extended if the variables have the next number.
+func applyEnvStructs(v reflect.Value, name string, env environment) error {
+	for i := 0; ; i++ {
+		prefix := name + "_" + strconv.Itoa(i)
+
+		if i >= v.Len() {
+			if !env.hasPrefix(prefix + "_") {
+				return nil
+			}
+
+			v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+		}
+
+		if err := applyEnvStruct(v.Index(i), prefix, env); err != nil {
+			return err
+		}
+	}
+}
+
+// setValue sets the value of the option parsed from the string.
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+
+		v.SetFloat(f)
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+
+		v.Set(ptr)
+	case reflect.Slice:
+		values := splitList(s)
+		list := reflect.MakeSlice(v.Type(), len(values), len(values))
+
+		for i, value := range values {
+			if err := setValue(list.Index(i), value); err != nil {
+				return err
+			}
+		}
+
+		v.Set(list)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+
+		for _, pair := range splitList(s) {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid pair %q, use key=value", pair)
+			}
+
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(value)))
+		}
+
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// splitList splits the list separated by commas, the empty string is the empty list.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	values := strings.Split(s, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+
+	return values
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// Flags are the flags of the command line, the flags set explicitly
+// override the config file and the environment variables.
+type Flags struct {
+	path      string       // path to the config file
+	overrides []func(*Cfg) // options set by the flags in the order of the command line
+}
+
+// NewFlags registers the flags of the configuration in the set,
+// the values are taken after the set is parsed.
+func NewFlags(fs *flag.FlagSet) *Flags {
+	f := new(Flags)
+
+	fs.StringVar(&f.path, "config", "", "Path to the config file, $"+EnvConfig+" or cfg/config.yaml of the project by default")
+
+	// database
+	f.string(fs, "b", "Driver of the database: mariadb (default), postgres, sqlite or memory",
+		func(c *Cfg, v string) { c.CfgDatabase.Driver = v })
+	f.string(fs, "f", "Path to the file of the SQLite database", func(c *Cfg, v string) { c.CfgDatabase.Path = v })
+
+	// required parameters of MariaDB and PostgreSQL
+	f.string(fs, "d", "Name of the database", func(c *Cfg, v string) { c.CfgDatabase.Name = v })
+	f.string(fs, "u", "User name of the database", func(c *Cfg, v string) { c.CfgDatabase.User = v })
+	f.string(fs, "p", "Password of the database", func(c *Cfg, v string) { c.CfgDatabase.Password = v })
+	f.string(fs, "s", "Socket of connection to database", func(c *Cfg, v string) { c.CfgDatabase.UnixSocket = v })
+	f.string(fs, "o", "Host of the database, localhost by default", func(c *Cfg, v string) { c.CfgDatabase.Host = v })
+	f.int(fs, "t", "Port of the database", func(c *Cfg, v int) { c.CfgDatabase.Port = v })
+	f.string(fs, "l", "SSL mode of the connections to PostgreSQL, disable by default",
+		func(c *Cfg, v string) { c.CfgDatabase.SSLMode = v })
+
+	// optional parameters
+	f.string(fs, "n", "Name of the application", func(c *Cfg, v string) { c.App.Name = v })
+	f.int(fs, "a", "Port for running the application", func(c *Cfg, v int) { c.App.Port = fmt.Sprintf(":%d", v) })
+	f.int(fs, "g", "Port for running the gRPC server", func(c *Cfg, v int) { c.App.GRPCPort = fmt.Sprintf(":%d", v) })
+	f.int(fs, "r", "Max request quantity in seconds", func(c *Cfg, v int) { c.App.MaxRequest = v })
+	f.int(fs, "e", "Expiration period in seconds", func(c *Cfg, v int) { c.App.Expiration = v })
+	f.int(fs, "q", "Timeout of one query to the database in milliseconds",
+		func(c *Cfg, v int) { c.CfgDatabase.QueryTimeout = v })
+
+	return f
+}
+
+// string registers the flag of the string option.
+func (f *Flags) string(fs *flag.FlagSet, name, usage string, set func(*Cfg, string)) {
+	fs.Func(name, usage, func(v string) error {
+		f.overrides = append(f.overrides, func(c *Cfg) { set(c, v) })
+		return nil
+	})
+}
+
+// int registers the flag of the integer option, the value is checked on parsing.
+func (f *Flags) int(fs *flag.FlagSet, name, usage string, set func(*Cfg, int)) {
+	fs.Func(name, usage, func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+
+		f.overrides = append(f.overrides, func(c *Cfg) { set(c, n) })
+
+		return nil
+	})
+}
+
+// apply overrides the options of the configuration by the flags set explicitly.
+func (f *Flags) apply(cfg *Cfg) {
+	if f == nil {
+		return
+	}
+
+	for _, override := range f.overrides {
+		override(cfg)
+	}
+}
+
+// configPath returns the path to the config file set by the flag, empty if it is not set.
+func (f *Flags) configPath() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.path
+}
@@ -0,0 +1,327 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alaleks/geospace/pkg/genkey"
+	"github.com/golang-module/dongle"
+	"gopkg.in/yaml.v2"
+)
+
+// sizeKeyID is the size of the random part of the id of the key of JWT.
+const sizeKeyID = 8
+
+// readSecrets reads the file of the secrets and the files of the passwords,
+// they override the options of the config file and the environment variables.
+func (cfg *Cfg) readSecrets() error {
+	if path := cfg.Secure.File; path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("file of the secrets: %w", err)
+		}
+
+		if err := yaml.UnmarshalStrict(b, &cfg.Secure); err != nil {
+			return fmt.Errorf("file of the secrets %s: %w", path, err)
+		}
+
+		// the file of the secrets cannot refer to another one
+		cfg.Secure.File = path
+	}
+
+	for _, secret := range []struct {
+		path  string
+		value *string
+	}{
+		{cfg.CfgDatabase.PasswordFile, &cfg.CfgDatabase.Password},
+		{cfg.Cache.RedisPasswordFile, &cfg.Cache.RedisPassword},
+		{cfg.Mail.SMTPPasswordFile, &cfg.Mail.SMTPPassword},
+	} {
+		if secret.path == "" {
+			continue
+		}
+
+		b, err := os.ReadFile(secret.path)
+		if err != nil {
+			return fmt.Errorf("file of the password: %w", err)
+		}
+
+		*secret.value = strings.TrimRight(string(b), "\r\n")
+	}
+
+	return nil
+}
+
+// validate returns the errors of the keys.
+func (s *Secure) validate() []error {
+	var errs []error
+
+	checkBase64 := func(name, value string) {
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s of the secure section must be base64", name))
+		}
+	}
+
+	checkBase64("secret_jwt", s.SecretJWT)
+	checkBase64("key", s.Key)
+	checkBase64("iv", s.IV)
+
+	ids := make(map[string]bool, len(s.JWTKeys))
+
+	for i, key := range s.JWTKeys {
+		switch {
+		case key.ID == "":
+			errs = append(errs, fmt.Errorf("id of the key %d of JWT cannot be empty", i+1))
+		case ids[key.ID]:
+			errs = append(errs, fmt.Errorf("id of the key of JWT %q is not unique", key.ID))
+		case key.Secret == "":
+			errs = append(errs, fmt.Errorf("secret of the key of JWT %q cannot be empty", key.ID))
+		}
+
+		ids[key.ID] = true
+
+		switch key.GetAlgorithm() {
+		case JWTAlgorithmHS256:
+			checkBase64(fmt.Sprintf("secret of the key of JWT %q", key.ID), key.Secret)
+		case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+			if _, err := key.GetPrivateKey(); err != nil {
+				errs = append(errs, fmt.Errorf("invalid private key of JWT %q: %w", key.ID, err))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown algorithm %q of the key of JWT %q", key.Algorithm, key.ID))
+		}
+	}
+
+	switch s.GetJWTAlgorithm() {
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		errs = append(errs, fmt.Errorf("jwt_algorithm of the secure section must be %s, %s or %s",
+			JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA))
+	}
+
+	for i, c := range s.PreviousCiphers {
+		checkBase64(fmt.Sprintf("key of the previous cipher %d", i+1), c.Key)
+		checkBase64(fmt.Sprintf("iv of the previous cipher %d", i+1), c.IV)
+	}
+
+	return errs
+}
+
+// GetJWTKeySecret returns the secret of the key of JWT after decrypt.
+func (k *JWTKey) GetJWTKeySecret() string {
+	return dongle.Decode.FromString(k.Secret).ByBase64().ToString()
+}
+
+// GetAlgorithm returns the algorithm of the key of JWT, HS256 by default.
+func (k *JWTKey) GetAlgorithm() string {
+	if k.Algorithm == "" {
+		return JWTAlgorithmHS256
+	}
+
+	return k.Algorithm
+}
+
+// GetPrivateKey returns the private key of the asymmetric algorithm:
+// *rsa.PrivateKey of RS256 or ed25519.PrivateKey of EdDSA.
+func (k *JWTKey) GetPrivateKey() (crypto.Signer, error) {
+	der, err := base64.StdEncoding.DecodeString(k.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if k.GetAlgorithm() == JWTAlgorithmRS256 {
+			return key, nil
+		}
+	case ed25519.PrivateKey:
+		if k.GetAlgorithm() == JWTAlgorithmEdDSA {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("private key %T does not match the algorithm %s", key, k.GetAlgorithm())
+}
+
+// GetKeyCipher returns key value after decrypt.
+func (c *CipherKey) GetKeyCipher() string {
+	return dongle.Decode.FromString(c.Key).ByBase64().ToString()
+}
+
+// GetIVCipher returns IV value after decrypt.
+func (c *CipherKey) GetIVCipher() string {
+	return dongle.Decode.FromString(c.IV).ByBase64().ToString()
+}
+
+// RotateJWT adds the new key signing JWT by the algorithm and removes
+// the keys not verifying any valid token: the key stops signing when the next key
+// is added and its tokens expire in the lifetime. It returns the new key and the ids
+// of the removed keys, the empty id is SecretJWT.
+func (s *Secure) RotateJWT(algorithm string, lifetime time.Duration, now time.Time) (JWTKey, []string, error) {
+	key, err := newJWTKey(algorithm, now)
+	if err != nil {
+		return key, nil, err
+	}
+
+	var (
+		keys    = []JWTKey{key}
+		removed []string
+	)
+
+	// the key is needed while the tokens signed before the creation of the next key are valid
+	for _, k := range s.JWTKeys {
+		if now.Sub(time.Unix(keys[len(keys)-1].Created, 0)) >= lifetime {
+			removed = append(removed, k.ID)
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	oldest := keys[len(keys)-1]
+	if s.SecretJWT != "" && (len(removed) > 0 || now.Sub(time.Unix(oldest.Created, 0)) >= lifetime) {
+		s.SecretJWT = ""
+		removed = append(removed, "")
+	}
+
+	s.JWTKeys = keys
+
+	return key, removed, nil
+}
+
+// newJWTKey generates the key of JWT by the algorithm.
+func newJWTKey(algorithm string, now time.Time) (JWTKey, error) {
+	key := JWTKey{
+		ID:      now.UTC().Format("20060102") + "-" + genkey.Create(sizeKeyID),
+		Created: now.Unix(),
+	}
+
+	var (
+		secret []byte
+		err    error
+	)
+
+	switch algorithm {
+	case JWTAlgorithmHS256:
+		secret = []byte(genkey.Create(sizeKeySecret))
+	case JWTAlgorithmRS256:
+		secret, err = genkey.CreateRSA(genkey.SizeRSA)
+	case JWTAlgorithmEdDSA:
+		secret, err = genkey.CreateEd25519()
+	default:
+		err = fmt.Errorf("unknown algorithm of JWT %q", algorithm)
+	}
+
+	if err != nil {
+		return key, err
+	}
+
+	// HS256 is the default algorithm of the keys
+	if algorithm != JWTAlgorithmHS256 {
+		key.Algorithm = algorithm
+	}
+
+	key.Secret = base64.StdEncoding.EncodeToString(secret)
+
+	return key, nil
+}
+
+// RotateCipher replaces the key and IV of the cipher of the passwords, the previous
+// ones decrypt the passwords until they are encrypted by the new key at the login.
+func (s *Secure) RotateCipher() {
+	s.PreviousCiphers = append([]CipherKey{{Key: s.Key, IV: s.IV}}, s.PreviousCiphers...)
+	s.Key = dongle.Encode.FromString(genkey.Create(sizeKeyCipher)).ByBase64().ToString()
+	s.IV = dongle.Encode.FromString(genkey.Create(sizeIVCipher)).ByBase64().ToString()
+}
+
+// RotateKeys rotates the keys by rotate in the file of the secrets if it is set,
+// otherwise in the config file, and returns the path to the changed file.
+// Only the keys of the file are changed, the environment variables and
+// the flags are not written to it.
+func (cfg *Cfg) RotateKeys(rotate func(*Secure) error) (string, error) {
+	if path := cfg.Secure.File; path != "" {
+		var secure Secure
+
+		b, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return path, err
+		}
+
+		if err := yaml.UnmarshalStrict(b, &secure); err != nil {
+			return path, fmt.Errorf("file of the secrets %s: %w", path, err)
+		}
+
+		if err := rotate(&secure); err != nil {
+			return path, err
+		}
+
+		return path, writeYAML(path, secure)
+	}
+
+	if !cfg.found {
+		return cfg.path, fmt.Errorf("config file %s is not found, the keys of the environment variables are not rotated", cfg.path)
+	}
+
+	file := newCfg(cfg.path, nil)
+	if _, err := file.readCfgFile(); err != nil {
+		return cfg.path, err
+	}
+
+	if err := rotate(&file.Secure); err != nil {
+		return cfg.path, err
+	}
+
+	return cfg.path, writeYAML(cfg.path, file)
+}
+
+// writeYAML writes the value as YAML to the file readable only by the owner.
+func writeYAML(path string, v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeSecretFile(path, b)
+}
+
+// writeSecretFile writes the file readable only by the owner, the file is replaced
+// atomically, so the running servers do not read the partially written keys.
+func writeSecretFile(path string, b []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
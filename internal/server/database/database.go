@@ -2,27 +2,31 @@
 package database
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"math"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/alaleks/geospace/internal/server/config"
 	"github.com/alaleks/geospace/internal/server/database/models"
-	"github.com/alaleks/geospace/internal/server/database/schema"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/tracing"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	MaxIdleConns    = 100              // maximum number of concurrent connections to the database
-	ConnMaxLifetime = 15 * time.Minute // the maximum length of time a connection can be reused
-	// table names
-	tableCities       = "cities"
-	tableUsers        = "users"
 	oneDegreesInKmLat = 110.574 // km in one degree latitude
 	oneDegreesInKmLon = 111.320 // km in one degree longitude
-	converFact        = 1000000 // number for convert floating point to uint
+	converFact        = 1000000 // number for convert floating point to integer
+
+	MaxCandidates = 10 // maximum number of candidates of the ambiguous city
 )
 
 // typical errors
@@ -30,53 +34,179 @@ var (
 	ErrUserAlreadyExists = errors.New("user with current email already exists")
 )
 
-// DB contains pointer to SQLX instance.
+// AmbiguousCityError is returned when the name matches several cities.
+type AmbiguousCityError struct {
+	Candidates []models.City // cities matching the name
+}
+
+func (e *AmbiguousCityError) Error() string {
+	return "name of the city is ambiguous, specify the country"
+}
+
+// DB is the storage in the SQL database, MariaDB, PostgreSQL or SQLite.
+// Queries are written with the placeholders "?", they are rebound
+// to the placeholders of the driver before execution.
+//
+// SQLX is the primary database server. The reads of the cities are routed
+// to the read replicas of the server if they are configured, see reader.
 type DB struct {
-	SQLX *sqlx.DB
+	SQLX     *sqlx.DB
+	driver   string        // config.DriverMariaDB, config.DriverPostgres or config.DriverSQLite
+	timeout  time.Duration // timeout of one query
+	replicas []*replica    // read replicas of the server
+	next     atomic.Uint32 // counter of the reads for the round robin of the replicas
+	done     chan struct{} // closed on closing the storage to stop the health checks
 }
 
-// Connect performs creating a new connection to database.
+// Connect performs creating a new connection to the database server, MariaDB or PostgreSQL.
 func Connect(cfg config.Cfg) (*DB, error) {
-	db, err := sqlx.Connect("mysql", cfg.CreateDSN())
+	driverName := cfg.CfgDatabase.GetDriverName()
+	if driverName == "" {
+		return nil, fmt.Errorf("driver %q is not a database server", cfg.CfgDatabase.GetDriver())
+	}
+
+	db, err := connect(driverName, cfg.CfgDatabase.GetDriver(), cfg.CreateDSN(),
+		cfg.CfgDatabase.GetQueryTimeout(), cfg.CfgDatabase.Pool)
 	if err != nil {
 		return nil, err
 	}
 
-	// set params of connection
-	db.SetMaxIdleConns(MaxIdleConns)
-	db.SetConnMaxLifetime(ConnMaxLifetime)
+	if len(cfg.CfgDatabase.Replicas) > 0 {
+		if err := db.openReplicas(driverName, cfg); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// connect opens the connection to the database server by the database/sql driver.
+func connect(driverName, driver, dsn string, timeout time.Duration, pool config.Pool) (*DB, error) {
+	db, err := sqlx.Connect(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	setPool(db, pool)
 
 	return &DB{
-		SQLX: db,
+		SQLX:    db,
+		driver:  driver,
+		timeout: timeout,
 	}, nil
 }
 
-// Migrate performs a create schema of table and need data in database.
-func (db *DB) Migrate() {
-	if !db.checkTableExist(tableCities) {
-		db.SQLX.MustExec(schema.City)
+// setPool sets the params of the pool of connections.
+func setPool(db *sqlx.DB, pool config.Pool) {
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.GetMaxIdleConns())
+	db.SetConnMaxLifetime(pool.GetConnMaxLifetime())
+	db.SetConnMaxIdleTime(pool.GetConnMaxIdleTime())
+}
+
+// Ping checks the connection to the database.
+func (db *DB) Ping(ctx context.Context) error {
+	ctx, cancel := db.queryContext(ctx, "ping")
+	defer cancel()
+
+	return db.SQLX.PingContext(ctx)
+}
+
+// forUpdate returns the clause locking the selected rows until the end
+// of the transaction, SQLite locks the whole database by the transaction.
+func (db *DB) forUpdate() string {
+	if db.driver == config.DriverSQLite {
+		return ""
+	}
+
+	return " FOR UPDATE"
+}
+
+// queryContext returns the context of one query limited by the timeout of the queries
+// and starts the span of the operation. The span is ended and the duration
+// of the operation is recorded to the metrics on cancellation.
+func (db *DB) queryContext(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+
+	ctx, span := tracing.Start(ctx, "db."+operation, trace.SpanKindClient,
+		db.system(), semconv.DBOperation(operation))
+
+	if db.timeout <= 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, db.timeout)
+	}
+
+	start := time.Now()
+
+	return ctx, func() {
+		cancel()
+		span.End()
+		metrics.ObserveQuery(db.driver, operation, time.Since(start))
+	}
+}
+
+// system returns the attribute of the spans naming the database server.
+func (db *DB) system() attribute.KeyValue {
+	switch db.driver {
+	case config.DriverPostgres:
+		return semconv.DBSystemPostgreSQL
+	case config.DriverSQLite:
+		return semconv.DBSystemSqlite
+	default:
+		return semconv.DBSystemMariaDB
+	}
+}
+
+// insertNamed performs the insert with named parameters and returns id of the inserted row.
+// PostgreSQL does not support LastInsertId, so the id is returned by the clause RETURNING.
+func (db *DB) insertNamed(ctx context.Context, e sqlx.ExtContext, query, idColumn string, arg any) (int, error) {
+	if db.driver == config.DriverPostgres {
+		query, args, err := e.BindNamed(query+" RETURNING "+idColumn, arg)
+		if err != nil {
+			return 0, err
+		}
+
+		var id int
+		err = sqlx.GetContext(ctx, e, &id, query, args...)
+
+		return id, err
 	}
 
-	if !db.checkTableExist(tableUsers) {
-		db.SQLX.MustExec(schema.User)
+	res, err := sqlx.NamedExecContext(ctx, e, query, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
 	}
+
+	return int(id), nil
 }
 
 // Close perfoms closing the database connection.
 func (db *DB) Close() error {
+	db.closeReplicas()
+
 	return db.SQLX.Close()
 }
 
 // CreateUser performs a create user to database.
-func (db *DB) CreateUser(name, email, password string) (int, error) {
-	var res int
-	err := db.SQLX.Get(&res, `SELECT COUNT(*) FROM users
-	WHERE email = ?`, email)
+func (db *DB) CreateUser(ctx context.Context, name, email, password string, verified bool) (int, error) {
+	ctx, cancel := db.queryContext(ctx, "create_user")
+	defer cancel()
+
+	var count int
+	err := db.SQLX.GetContext(ctx, &count, db.SQLX.Rebind(`SELECT COUNT(*) FROM users
+	WHERE email = ?`), email)
 	if err != nil {
 		return 0, err
 	}
 
-	if res > 0 {
+	if count > 0 {
 		return 0, ErrUserAlreadyExists
 	}
 
@@ -84,23 +214,36 @@ func (db *DB) CreateUser(name, email, password string) (int, error) {
 		Name:      name,
 		Email:     email,
 		Password:  password,
+		Role:      models.RoleUser,
 		CreatedAt: time.Now().Unix(),
+		Verified:  verified,
 	}
 
-	_, err = db.SQLX.NamedExec(`INSERT INTO users (name, email, password, created_at) 
-	VALUES (:name, :email, :password, :created_at)`,
-		&user)
+	return db.insertNamed(ctx, db.SQLX, `INSERT INTO users (name, email, password, role, created_at, verified) 
+	VALUES (:name, :email, :password, :role, :created_at, :verified)`, "uid", &user)
+}
+
+// GetUser provides a get user from database by email.
+func (db *DB) GetUser(ctx context.Context, email string) (models.User, error) {
+	ctx, cancel := db.queryContext(ctx, "get_user")
+	defer cancel()
+
+	var user models.User
+	err := db.SQLX.GetContext(ctx, &user, db.SQLX.Rebind("SELECT * FROM users WHERE email=?"), email)
 	if err != nil {
-		return 0, err
+		return user, err
 	}
 
-	return user.UID, err
+	return user, nil
 }
 
-// GetUser provides a get user from database by email.
-func (db *DB) GetUser(email string) (models.User, error) {
+// GetUserByID provides a get user from database by id.
+func (db *DB) GetUserByID(ctx context.Context, uid int) (models.User, error) {
+	ctx, cancel := db.queryContext(ctx, "get_user_by_id")
+	defer cancel()
+
 	var user models.User
-	err := db.SQLX.Get(&user, "SELECT * FROM users WHERE email=?", email)
+	err := db.SQLX.GetContext(ctx, &user, db.SQLX.Rebind("SELECT * FROM users WHERE uid=?"), uid)
 	if err != nil {
 		return user, err
 	}
@@ -108,53 +251,78 @@ func (db *DB) GetUser(email string) (models.User, error) {
 	return user, nil
 }
 
-// FindCityConc provides a get city by name from database (for concurrently using).
-func (db *DB) FindCityConc(cityRaw string, chErr chan<- error, cityCh chan<- models.City) {
-	var (
-		cityName    string
-		countryName string
-		city        models.City
-	)
+// UpdatePassword replaces the encrypted password of the user.
+func (db *DB) UpdatePassword(ctx context.Context, uid int, password string) error {
+	ctx, cancel := db.queryContext(ctx, "update_password")
+	defer cancel()
 
-	cityRawSplit := strings.Split(cityRaw, ",")
-	if len(cityRawSplit) > 1 {
-		cityName = strings.TrimSpace(cityRawSplit[0])
-		countryName = strings.TrimSpace(cityRawSplit[1])
-	} else {
-		cityName = strings.TrimSpace(cityRaw)
-	}
+	_, err := db.SQLX.ExecContext(ctx, db.SQLX.Rebind("UPDATE users SET password=? WHERE uid=?"), password, uid)
 
-	err := db.SQLX.Get(&city, `SELECT cid, name, name_ascii, country_code, 
-	country, timezone, latitude, longitude FROM cities 
-	WHERE (name = ? OR alternative_names LIKE ?) 
-	AND country LIKE ?;`, cityName, "%"+cityName+",%", countryName+"%")
+	return err
+}
+
+// ChangePassword replaces the encrypted password of the user and stores the time of the change.
+func (db *DB) ChangePassword(ctx context.Context, uid int, password string, changedAt int64) error {
+	ctx, cancel := db.queryContext(ctx, "change_password")
+	defer cancel()
+
+	_, err := db.SQLX.ExecContext(ctx, db.SQLX.Rebind(`UPDATE users SET password=?, password_changed_at=? 
+	WHERE uid=?`), password, changedAt, uid)
+
+	return err
+}
+
+// VerifyUser marks the email of the user as confirmed.
+func (db *DB) VerifyUser(ctx context.Context, uid int) error {
+	ctx, cancel := db.queryContext(ctx, "verify_user")
+	defer cancel()
+
+	_, err := db.SQLX.ExecContext(ctx, db.SQLX.Rebind("UPDATE users SET verified=? WHERE uid=?"), true, uid)
+
+	return err
+}
+
+// GetCity provides a get city by id from database.
+func (db *DB) GetCity(ctx context.Context, cid int) (models.City, error) {
+	ctx, cancel := db.queryContext(ctx, "get_city")
+	defer cancel()
+
+	var city models.City
+	err := db.reader().GetContext(ctx, &city, db.SQLX.Rebind(`SELECT cid, name, name_ascii, alternative_names, 
+	country_code, country, timezone, latitude, longitude, source, external_id, created_at 
+	FROM cities WHERE cid = ?`), cid)
 	if err != nil {
-		chErr <- err
+		return city, err
 	}
 
-	cityCh <- city
+	return city, nil
+}
+
+// CountCities returns quantity of the cities in database. The cities are counted
+// on the primary, the count decides whether the cities are imported.
+func (db *DB) CountCities(ctx context.Context) (int, error) {
+	ctx, cancel := db.queryContext(ctx, "count_cities")
+	defer cancel()
+
+	var count int
+	err := db.SQLX.GetContext(ctx, &count, `SELECT COUNT(*) FROM cities`)
+
+	return count, err
 }
 
 // FindCity provides a get city by name from database.
-func (db *DB) FindCity(cityRaw string) (models.City, error) {
-	var (
-		cityName    string
-		countryName string
-		city        models.City
-	)
+func (db *DB) FindCity(ctx context.Context, cityRaw string) (models.City, error) {
+	ctx, cancel := db.queryContext(ctx, "find_city")
+	defer cancel()
 
-	cityRawSplit := strings.Split(cityRaw, ",")
-	if len(cityRawSplit) > 1 {
-		cityName = strings.TrimSpace(cityRawSplit[0])
-		countryName = strings.TrimSpace(cityRawSplit[1])
-	} else {
-		cityName = strings.TrimSpace(cityRaw)
-	}
+	var city models.City
 
-	err := db.SQLX.Get(&city, `SELECT cid, name, name_ascii, country_code, 
+	cityName, countryName := SplitCityRaw(cityRaw)
+
+	err := db.reader().GetContext(ctx, &city, db.SQLX.Rebind(`SELECT cid, name, name_ascii, country_code, 
 	country, timezone, latitude, longitude FROM cities 
 	WHERE (name = ? OR alternative_names LIKE ?) 
-	AND country LIKE ?`, cityName, "%"+cityName+",%", countryName+"%")
+	AND country LIKE ?`), cityName, "%"+cityName+",%", countryName+"%")
 	if err != nil {
 		return city, err
 	}
@@ -162,33 +330,94 @@ func (db *DB) FindCity(cityRaw string) (models.City, error) {
 	return city, nil
 }
 
-// FindObjectsNearByName performs search for all objects at a distance
-// until n km from the object by name.
-// Returns city of departure, list of objects (cities) near the city and error.
-func (db *DB) FindObjectsNearByName(departure string, distance int) (models.City, []models.City, error) {
-	city, err := db.FindCity(departure)
+// FindCities provides a get of several cities by names from database with one query.
+// Names are matched as by FindCity, cities named exactly so are preferred.
+// Returns cities by the given names, names of the cities not found are missing.
+func (db *DB) FindCities(ctx context.Context, citiesRaw []string) (map[string]models.City, error) {
+	found := make(map[string]models.City, len(citiesRaw))
+	if len(citiesRaw) == 0 {
+		return found, nil
+	}
+
+	conds := make([]string, 0, len(citiesRaw))
+	args := make([]any, 0, len(citiesRaw)*3)
+
+	for _, cityRaw := range citiesRaw {
+		cityName, countryName := SplitCityRaw(cityRaw)
+		conds = append(conds, "((name = ? OR alternative_names LIKE ?) AND country LIKE ?)")
+		args = append(args, cityName, "%"+cityName+",%", countryName+"%")
+	}
+
+	ctx, cancel := db.queryContext(ctx, "find_cities")
+	defer cancel()
+
+	var cities []models.City
+
+	err := db.reader().SelectContext(ctx, &cities, db.SQLX.Rebind(`SELECT cid, name, name_ascii, alternative_names, country_code,
+	country, timezone, latitude, longitude FROM cities
+	WHERE `+strings.Join(conds, " OR ")+` ORDER BY cid`), args...)
 	if err != nil {
-		return city, nil, err
+		return nil, err
 	}
 
-	// convert float to uint
-	latUint, lonUint := uint(city.Latitude*converFact), uint(city.Longitude*converFact)
+	for _, cityRaw := range citiesRaw {
+		cityName, countryName := SplitCityRaw(cityRaw)
+
+		for _, city := range cities {
+			match, exact := MatchCity(city, cityName, countryName)
+			if !match {
+				continue
+			}
+
+			if _, ok := found[cityRaw]; !ok || exact {
+				city.AlternativeNames = ""
+				found[cityRaw] = city
+			}
 
-	// convert km to degree
-	degreeLat := float64(distance) / oneDegreesInKmLat
-	degreeLon := float64(distance) / oneDegreesInKmLon * math.Cos(degreeLat)
-	if degreeLon < 0 {
-		degreeLon = -degreeLon
+			if exact {
+				break
+			}
+		}
 	}
 
+	return found, nil
+}
+
+// ResolveCity provides a get city by name from database. Unlike FindCity it returns
+// ErrCityNotFound if there is no such city and AmbiguousCityError if the name
+// matches several cities. Cities named exactly so are preferred over the cities
+// having it among alternative names.
+func (db *DB) ResolveCity(ctx context.Context, cityRaw string) (models.City, error) {
+	ctx, cancel := db.queryContext(ctx, "resolve_city")
+	defer cancel()
+
+	cityName, countryName := SplitCityRaw(cityRaw)
+
 	var cities []models.City
 
-	err = db.SQLX.Select(&cities, `SELECT cid, name, country, 
-		latitude, longitude FROM cities HAVING 
-		ABS(CAST((latitude * ? - ?) AS INT)) <= ? AND 
-		ABS(CAST((longitude * ? - ?) AS INT)) <= ?`,
-		converFact, latUint, uint(degreeLat*converFact),
-		converFact, lonUint, uint(degreeLon*converFact))
+	err := db.reader().SelectContext(ctx, &cities, db.SQLX.Rebind(`SELECT cid, name, name_ascii, country_code,
+	country, timezone, latitude, longitude FROM cities
+	WHERE (name = ? OR alternative_names LIKE ?)
+	AND (country LIKE ? OR country_code = ?)
+	ORDER BY name = ? DESC, cid LIMIT ?`),
+		cityName, "%"+cityName+",%", countryName+"%", countryName, cityName, MaxCandidates)
+	if err != nil {
+		return models.City{}, err
+	}
+
+	return ResolveCandidates(cities, cityName)
+}
+
+// FindObjectsNearByName performs search for all objects at a distance
+// until n km from the object by name.
+// Returns city of departure, list of objects (cities) near the city and error.
+func (db *DB) FindObjectsNearByName(ctx context.Context, departure string, distance int) (models.City, []models.City, error) {
+	city, err := db.FindCity(ctx, departure)
+	if err != nil {
+		return city, nil, err
+	}
+
+	cities, err := db.FindObjectsNearByCoord(ctx, city.Latitude, city.Longitude, distance)
 	if err != nil {
 		return city, nil, err
 	}
@@ -199,43 +428,111 @@ func (db *DB) FindObjectsNearByName(departure string, distance int) (models.City
 // FindObjectsNearByCoordperforms search for all objects at a distance
 // until n km from the object by coordinates.
 // Returns list of objects (cities) near these coordinates and error.
-func (db *DB) FindObjectsNearByCoord(lat float64, lon float64, distance int) ([]models.City, error) {
-	// convert float to uint
-	latUint, lonUint := uint(lat*converFact), uint(lon*converFact)
+func (db *DB) FindObjectsNearByCoord(ctx context.Context, lat float64, lon float64, distance int) ([]models.City, error) {
+	var cities []models.City
 
-	// convert km to degree
-	degreeLat := float64(distance) / oneDegreesInKmLat
-	degreeLon := float64(distance) / oneDegreesInKmLon * math.Cos(degreeLat)
-	if degreeLon < 0 {
-		degreeLon = -degreeLon
+	err := db.EachObjectNearByCoord(ctx, lat, lon, distance, func(city models.City) error {
+		cities = append(cities, city)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	var cities []models.City
+	return cities, nil
+}
+
+// EachObjectNearByCoord performs search for all objects at a distance until n km
+// from the coordinates and calls fn for every object. Objects are read from
+// the database row by row, search is stopped on the first error returned by fn.
+// The timeout of the queries limits reading of all rows. PostgreSQL searches
+// the objects in the circle by PostGIS, other databases in the bounding box.
+func (db *DB) EachObjectNearByCoord(ctx context.Context, lat float64, lon float64, distance int,
+	fn func(models.City) error,
+) error {
+	ctx, cancel := db.queryContext(ctx, "nearby_cities")
+	defer cancel()
 
-	err := db.SQLX.Select(&cities, `SELECT cid, name, country, 
-		latitude, longitude FROM cities HAVING 
-		ABS(CAST((latitude * ? - ?) AS INT)) <= ? AND 
+	var (
+		rows *sqlx.Rows
+		err  error
+	)
+
+	if db.driver == config.DriverPostgres {
+		rows, err = db.queryNearbyPostgres(ctx, lat, lon, distance)
+	} else {
+		// convert km to degree
+		degreeLat, degreeLon := NearbyDelta(distance)
+
+		// coordinates are compared as signed integers,
+		// so the search works in the southern and western hemispheres too
+		rows, err = db.reader().QueryxContext(ctx, `SELECT cid, name, country,
+		latitude, longitude FROM cities WHERE
+		ABS(CAST((latitude * ? - ?) AS INT)) <= ? AND
 		ABS(CAST((longitude * ? - ?) AS INT)) <= ?`,
-		converFact, latUint, uint(degreeLat*converFact),
-		converFact, lonUint, uint(degreeLon*converFact))
+			converFact, int(lat*converFact), int(degreeLat*converFact),
+			converFact, int(lon*converFact), int(degreeLon*converFact))
+	}
+
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return cities, nil
+	defer rows.Close()
+
+	for rows.Next() {
+		var city models.City
+		if err := rows.StructScan(&city); err != nil {
+			return err
+		}
+
+		if err := fn(city); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// FindNearestCity performs search for the city nearest to the coordinates (reverse geocoding).
+// Distance is approximated by the equirectangular projection, which is enough
+// to order the cities. The cosine is calculated here, SQLite has no math functions.
+// PostgreSQL orders the cities by the distance on the sphere using the index of PostGIS.
+func (db *DB) FindNearestCity(ctx context.Context, lat float64, lon float64) (models.City, error) {
+	ctx, cancel := db.queryContext(ctx, "find_nearest_city")
+	defer cancel()
+
+	if db.driver == config.DriverPostgres {
+		return db.findNearestPostgres(ctx, lat, lon)
+	}
+
+	var city models.City
+
+	scale := math.Cos(lat * math.Pi / 180)
+
+	err := db.reader().GetContext(ctx, &city, `SELECT cid, name, name_ascii, country_code,
+	country, timezone, latitude, longitude FROM cities
+	ORDER BY (latitude - ?) * (latitude - ?) + (longitude - ?) * (longitude - ?) * ? LIMIT 1`,
+		lat, lat, lon, lon, scale*scale)
+	if err != nil {
+		return city, err
+	}
+
+	return city, nil
 }
 
-// checkTableExist checks if the table exists and returns
-// false if it does not exist.
-func (db *DB) checkTableExist(tableName string) bool {
-	var res int
-	err := db.SQLX.Get(&res, `SELECT COUNT(*) FROM 
-	INFORMATION_SCHEMA.TABLES 
-	WHERE TABLE_NAME = ?`, tableName)
+// ListCountries provides a get list of the countries of the cities ordered by name.
+func (db *DB) ListCountries(ctx context.Context) ([]models.Country, error) {
+	ctx, cancel := db.queryContext(ctx, "list_countries")
+	defer cancel()
+
+	countries := make([]models.Country, 0)
 
-	if res == 0 && err != nil {
-		return false
+	err := db.reader().SelectContext(ctx, &countries, `SELECT DISTINCT country_code, country
+	FROM cities WHERE country <> '' ORDER BY country, country_code`)
+	if err != nil {
+		return nil, err
 	}
 
-	return true
+	return countries, nil
 }
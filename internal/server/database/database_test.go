@@ -1,11 +1,13 @@
 package database_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/alaleks/geospace/internal/server/config"
 	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
 )
 
 func BenchmarkFindCity(b *testing.B) {
@@ -22,13 +24,13 @@ func BenchmarkFindCity(b *testing.B) {
 	b.ResetTimer()
 
 	b.Run(fmt.Sprintf("Find City by Name"), func(b *testing.B) {
-		_, _ = db.FindCity("Rome, It")
+		_, _ = db.FindCity(context.Background(), "Rome, It")
 	})
 
 	b.ResetTimer()
 
 	b.Run(fmt.Sprintf("Find City by Alternative Name"), func(b *testing.B) {
-		_, _ = db.FindCity("Рим, It")
+		_, _ = db.FindCity(context.Background(), "Рим, It")
 	})
 
 	db.Close()
@@ -48,14 +50,62 @@ func BenchmarkFindObjectsNearByName(b *testing.B) {
 	b.ResetTimer()
 
 	b.Run(fmt.Sprintf("Find Cities Nearby by Name"), func(b *testing.B) {
-		_, _, _ = db.FindObjectsNearByName("Rome, It", 100)
+		_, _, _ = db.FindObjectsNearByName(context.Background(), "Rome, It", 100)
 	})
 
 	b.ResetTimer()
 
 	b.Run(fmt.Sprintf("Find Cities Nearby by Alternative Name"), func(b *testing.B) {
-		_, _, _ = db.FindObjectsNearByName("Рим, It", 100)
+		_, _, _ = db.FindObjectsNearByName(context.Background(), "Рим, It", 100)
 	})
 
 	db.Close()
 }
+
+func BenchmarkCitySync(b *testing.B) {
+	cfg, err := config.ReadCfgFile()
+	if err != nil {
+		b.Skip(err.Error())
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		b.Skip(err.Error())
+	}
+
+	defer db.Close()
+
+	const qty, batchSize = 150000, 500
+
+	cities := make([]models.City, 0, qty)
+	for i := 0; i < qty; i++ {
+		cities = append(cities, models.City{
+			Name:        fmt.Sprintf("City %d", i),
+			CountryCode: "IT",
+			ExternalID:  fmt.Sprint(i),
+			Latitude:    float64(i%180) - 89.5,
+			Longitude:   float64(i%360) - 179.5,
+		})
+	}
+
+	b.ResetTimer()
+
+	b.Run(fmt.Sprintf("Dry run import of %d cities by batches of %d", qty, batchSize), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sync, err := db.BeginCitySync(context.Background(), "benchmark", database.SyncOptions{DryRun: true})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for start := 0; start < qty; start += batchSize {
+				if err := sync.Write(cities[start:start+batchSize], start+batchSize); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if _, err := sync.Finish(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// BBox is a bounding box of the coordinates. If MinLon is greater
+// than MaxLon the box crosses the antimeridian.
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// CityFilter contains the conditions of selection of the cities.
+// Empty fields are not used.
+type CityFilter struct {
+	BBox     *BBox  // bounding box of the coordinates
+	Country  string // country code or name of the country
+	Timezone string // name of the timezone
+}
+
+// ExportCities selects the cities by the filter ordered by id and calls fn for every city.
+// Cities are read from the database row by row, so the whole table is not loaded
+// into memory. Selection is stopped on the first error returned by fn or on cancellation
+// of the context, the timeout of the queries is not applied to the export.
+func (db *DB) ExportCities(ctx context.Context, filter CityFilter, fn func(models.City) error) error {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	if filter.Country != "" {
+		conditions = append(conditions, "(country_code = ? OR country = ?)")
+		args = append(args, filter.Country, filter.Country)
+	}
+
+	if filter.Timezone != "" {
+		conditions = append(conditions, "timezone = ?")
+		args = append(args, filter.Timezone)
+	}
+
+	if box := filter.BBox; box != nil {
+		conditions = append(conditions, "latitude BETWEEN ? AND ?")
+		args = append(args, box.MinLat, box.MaxLat)
+
+		if box.MinLon <= box.MaxLon {
+			conditions = append(conditions, "longitude BETWEEN ? AND ?")
+		} else {
+			conditions = append(conditions, "(longitude >= ? OR longitude <= ?)")
+		}
+
+		args = append(args, box.MinLon, box.MaxLon)
+	}
+
+	query := `SELECT cid, name, name_ascii, alternative_names, country_code, country,
+	timezone, latitude, longitude, source, external_id, created_at FROM cities`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := db.reader().QueryxContext(ctx, db.SQLX.Rebind(query+" ORDER BY cid"), args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var city models.City
+		if err := rows.StructScan(&city); err != nil {
+			return err
+		}
+
+		if err := fn(city); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,467 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	coordTolerance  = 1e-4 // coordinates are stored as FLOAT, so compare them with tolerance
+	deleteBatchSize = 1000 // quantity of the cities deleted by one query
+	cityColumns     = 11   // quantity of the columns in the insert of the city
+
+	// maximum quantity of the placeholders of one query, the smallest limit
+	// of the drivers (SQLite, PostgreSQL and MariaDB allow 65535)
+	maxPlaceholders = 32766
+	insertBatchSize = maxPlaceholders / cityColumns // quantity of the cities inserted by one query
+)
+
+// ErrDuplicateExternalID is returned if the dataset contains
+// several cities with the same external id.
+var ErrDuplicateExternalID = errors.New("duplicate external id in the dataset")
+
+// ImportStats contains the results of the import.
+type ImportStats struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+	Skipped   int `json:"skipped"`
+}
+
+// String returns the results of the import in a human-readable form.
+func (s ImportStats) String() string {
+	return fmt.Sprintf("inserted: %d, updated: %d, unchanged: %d, deleted: %d, skipped: %d",
+		s.Inserted, s.Updated, s.Unchanged, s.Deleted, s.Skipped)
+}
+
+// SyncOptions contains the parameters of the synchronization.
+type SyncOptions struct {
+	Fingerprint string // fingerprint of the dataset files, checkpoint is used only for the same dataset
+	DryRun      bool   // if true all changes are rolled back
+	Resumable   bool   // if true every batch is committed with the checkpoint
+}
+
+// CitySync synchronizes the cities of the source with the dataset by batches.
+// Cities are matched by the external id, so ids of the cities inside the application
+// stay the same between imports. Cities of the source which are absent in the dataset
+// are deleted on finish. Cities imported before the sources were introduced are
+// matched by name, country code and coordinates and take over the external id.
+//
+// By default all batches are written in one transaction. In the resumable mode every
+// batch is committed together with the checkpoint, so the interrupted import
+// can be continued from the last checkpoint of the same dataset.
+type CitySync struct {
+	ctx         context.Context
+	db          *DB
+	tx          *sqlx.Tx
+	existing    map[string]models.City
+	legacy      map[string][]models.City
+	seen        map[string]bool
+	source      string
+	fingerprint string
+	stats       ImportStats
+	position    int
+	dryRun      bool
+	resumable   bool
+}
+
+// BeginCitySync starts the synchronization of the cities of the source.
+// The sync must be finished by Finish or closed by Close. All queries of the sync
+// are made with the context, the timeout of the queries is not applied to the import.
+func (db *DB) BeginCitySync(ctx context.Context, source string, opts SyncOptions) (CitySyncer, error) {
+	s := &CitySync{
+		ctx:         ctx,
+		db:          db,
+		source:      source,
+		fingerprint: opts.Fingerprint,
+		dryRun:      opts.DryRun,
+		resumable:   opts.Resumable && !opts.DryRun,
+		existing:    make(map[string]models.City),
+		legacy:      make(map[string][]models.City),
+		seen:        make(map[string]bool),
+	}
+
+	var q sqlx.ExtContext = db.SQLX
+
+	if !s.resumable {
+		tx, err := db.SQLX.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		s.tx, q = tx, tx
+	}
+
+	existing, err := selectCities(ctx, q, source)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	for _, city := range existing {
+		s.existing[city.ExternalID] = city
+	}
+
+	legacy, err := selectCities(ctx, q, "")
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	for _, city := range legacy {
+		key := city.CountryCode + "|" + city.Name
+		s.legacy[key] = append(s.legacy[key], city)
+	}
+
+	if s.resumable {
+		if err := s.loadCheckpoint(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Position returns quantity of the records of the dataset processed
+// before the checkpoint. These records must be passed to Seen, not to Write.
+func (s *CitySync) Position() int {
+	return s.position
+}
+
+// Stats returns the current results of the synchronization.
+func (s *CitySync) Stats() ImportStats {
+	return s.stats
+}
+
+// Seen marks the city as present in the dataset without writing it.
+// It is used for the records processed before the checkpoint.
+func (s *CitySync) Seen(externalID string) {
+	if externalID != "" {
+		s.seen[externalID] = true
+	}
+}
+
+// Skip counts the invalid record of the dataset. If the external id
+// of the record is known, the city is not deleted on finish.
+func (s *CitySync) Skip(externalID string) {
+	s.Seen(externalID)
+	s.stats.Skipped++
+}
+
+// Write saves the batch of the cities. Position is quantity of the records
+// of the dataset processed including this batch, it is stored in the checkpoint.
+func (s *CitySync) Write(cities []models.City, position int) error {
+	tx := s.tx
+	if s.resumable {
+		var err error
+
+		tx, err = s.db.SQLX.BeginTxx(s.ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		defer tx.Rollback()
+	}
+
+	stats := s.stats
+	now := time.Now().Unix()
+	inserts := make([]models.City, 0, len(cities))
+
+	for _, city := range cities {
+		if s.seen[city.ExternalID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateExternalID, city.ExternalID)
+		}
+
+		s.seen[city.ExternalID] = true
+		city.Source = s.source
+
+		current, ok := s.existing[city.ExternalID]
+		if !ok {
+			current, ok = s.takeLegacy(city)
+		}
+
+		switch {
+		case !ok:
+			city.CreatedAt = now
+			inserts = append(inserts, city)
+			stats.Inserted++
+		case SameCity(current, city):
+			stats.Unchanged++
+		default:
+			city.ID = current.ID
+
+			_, err := tx.NamedExecContext(s.ctx, `UPDATE cities SET name = :name, name_ascii = :name_ascii,
+			alternative_names = :alternative_names, country_code = :country_code,
+			country = :country, timezone = :timezone, latitude = :latitude,
+			longitude = :longitude, source = :source, external_id = :external_id
+			WHERE cid = :cid`, &city)
+			if err != nil {
+				return err
+			}
+
+			stats.Updated++
+		}
+	}
+
+	if err := insertCities(s.ctx, tx, inserts); err != nil {
+		return err
+	}
+
+	s.stats = stats
+	s.position = position
+
+	if !s.resumable {
+		return nil
+	}
+
+	if err := s.saveCheckpoint(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Finish deletes the cities of the source absent in the dataset,
+// removes the checkpoint and commits the changes (rolls back in the dry run).
+// Returns the results of the synchronization.
+func (s *CitySync) Finish() (ImportStats, error) {
+	tx := s.tx
+	if s.resumable {
+		var err error
+
+		tx, err = s.db.SQLX.BeginTxx(s.ctx, nil)
+		if err != nil {
+			return s.stats, err
+		}
+
+		defer tx.Rollback()
+	}
+
+	removed := make([]int, 0)
+	for externalID, city := range s.existing {
+		if !s.seen[externalID] {
+			removed = append(removed, city.ID)
+		}
+	}
+
+	deleted, err := deleteCities(s.ctx, tx, removed)
+	if err != nil {
+		return s.stats, err
+	}
+
+	s.stats.Deleted += deleted
+
+	if s.dryRun {
+		return s.stats, s.Close()
+	}
+
+	_, err = tx.ExecContext(s.ctx, tx.Rebind(`DELETE FROM import_checkpoints WHERE source = ?`), s.source)
+	if err != nil {
+		return s.stats, err
+	}
+
+	err = tx.Commit()
+	s.tx = nil
+
+	return s.stats, err
+}
+
+// Close rolls back the not finished changes. In the resumable mode
+// the committed batches and the checkpoint are kept.
+func (s *CitySync) Close() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Rollback()
+	s.tx = nil
+
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+
+	return err
+}
+
+// HasCheckpoint checks whether there is the interrupted import of the source.
+func (db *DB) HasCheckpoint(ctx context.Context, source string) bool {
+	ctx, cancel := db.queryContext(ctx, "has_checkpoint")
+	defer cancel()
+
+	var count int
+	err := db.SQLX.GetContext(ctx, &count, db.SQLX.Rebind(`SELECT COUNT(*) FROM import_checkpoints WHERE source = ?`), source)
+
+	return err == nil && count > 0
+}
+
+// loadCheckpoint restores the position and the results of the previous
+// interrupted import of the same dataset.
+func (s *CitySync) loadCheckpoint() error {
+	var checkpoint struct {
+		Fingerprint string `db:"fingerprint"`
+		Stats       string `db:"stats"`
+		Position    int    `db:"position"`
+	}
+
+	err := s.db.SQLX.GetContext(s.ctx, &checkpoint, s.db.SQLX.Rebind(`SELECT fingerprint, position, stats
+	FROM import_checkpoints WHERE source = ?`), s.source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// the dataset was changed, so the import starts from the beginning
+	if checkpoint.Fingerprint != s.fingerprint {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(checkpoint.Stats), &s.stats); err != nil {
+		return err
+	}
+
+	s.position = checkpoint.Position
+
+	return nil
+}
+
+// saveCheckpoint stores the position and the results of the import.
+// The checkpoint is replaced by delete and insert, which works in all databases.
+func (s *CitySync) saveCheckpoint(tx *sqlx.Tx) error {
+	stats, err := json.Marshal(s.stats)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(s.ctx, tx.Rebind(`DELETE FROM import_checkpoints WHERE source = ?`), s.source)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(s.ctx, tx.Rebind(`INSERT INTO import_checkpoints (source, fingerprint, position, stats, updated_at)
+	VALUES (?, ?, ?, ?, ?)`), s.source, s.fingerprint, s.position, string(stats), time.Now().Unix())
+
+	return err
+}
+
+// takeLegacy finds the city without source by name, country code and
+// coordinates. The found city is removed from the list to be matched only once.
+func (s *CitySync) takeLegacy(city models.City) (models.City, bool) {
+	key := city.CountryCode + "|" + city.Name
+	candidates := s.legacy[key]
+
+	for i, candidate := range candidates {
+		if math.Abs(candidate.Latitude-city.Latitude) < coordTolerance &&
+			math.Abs(candidate.Longitude-city.Longitude) < coordTolerance {
+			s.legacy[key] = append(candidates[:i], candidates[i+1:]...)
+
+			return candidate, true
+		}
+	}
+
+	return models.City{}, false
+}
+
+// selectCities returns all cities of the source.
+func selectCities(ctx context.Context, q sqlx.ExtContext, source string) ([]models.City, error) {
+	cities := make([]models.City, 0)
+
+	err := sqlx.SelectContext(ctx, q, &cities, q.Rebind(`SELECT cid, name, name_ascii, alternative_names,
+		country_code, country, timezone, latitude, longitude, source, external_id
+		FROM cities WHERE source = ?`), source)
+
+	return cities, err
+}
+
+// insertCities inserts the cities by the multi-row queries,
+// the batch is split to keep the placeholders under the limit of the driver.
+func insertCities(ctx context.Context, tx *sqlx.Tx, cities []models.City) error {
+	for start := 0; start < len(cities); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(cities) {
+			end = len(cities)
+		}
+
+		if err := insertCityRows(ctx, tx, cities[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertCityRows inserts the cities by one multi-row query.
+func insertCityRows(ctx context.Context, tx *sqlx.Tx, cities []models.City) error {
+	values := make([]string, 0, len(cities))
+	args := make([]any, 0, len(cities)*cityColumns)
+
+	for _, city := range cities {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, city.Name, city.NameASCII, city.AlternativeNames,
+			city.CountryCode, city.Country, city.Timezone, city.Latitude, city.Longitude,
+			city.Source, city.ExternalID, city.CreatedAt)
+	}
+
+	_, err := tx.ExecContext(ctx, tx.Rebind(`INSERT INTO cities (name, name_ascii, alternative_names,
+		country_code, country, timezone, latitude, longitude, source, external_id, created_at)
+		VALUES `+strings.Join(values, ", ")), args...)
+
+	return err
+}
+
+// deleteCities deletes the cities by ids and returns quantity of the deleted cities.
+func deleteCities(ctx context.Context, tx *sqlx.Tx, ids []int) (int, error) {
+	var deleted int
+
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		query, args, err := sqlx.In(`DELETE FROM cities WHERE cid IN (?)`, ids[start:end])
+		if err != nil {
+			return deleted, err
+		}
+
+		res, err := tx.ExecContext(ctx, tx.Rebind(query), args...)
+		if err != nil {
+			return deleted, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += int(n)
+	}
+
+	return deleted, nil
+}
+
+// SameCity checks whether the stored city matches the city from the dataset.
+func SameCity(stored, city models.City) bool {
+	return stored.Name == city.Name &&
+		stored.NameASCII == city.NameASCII &&
+		stored.AlternativeNames == city.AlternativeNames &&
+		stored.CountryCode == city.CountryCode &&
+		stored.Country == city.Country &&
+		stored.Timezone == city.Timezone &&
+		stored.Source == city.Source &&
+		stored.ExternalID == city.ExternalID &&
+		math.Abs(stored.Latitude-city.Latitude) < coordTolerance &&
+		math.Abs(stored.Longitude-city.Longitude) < coordTolerance
+}
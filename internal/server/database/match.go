@@ -0,0 +1,69 @@
+package database
+
+import (
+	"math"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// SplitCityRaw splits the string "city, country" to the name of the city and the country.
+func SplitCityRaw(cityRaw string) (string, string) {
+	cityRawSplit := strings.Split(cityRaw, ",")
+	if len(cityRawSplit) > 1 {
+		return strings.TrimSpace(cityRawSplit[0]), strings.TrimSpace(cityRawSplit[1])
+	}
+
+	return strings.TrimSpace(cityRaw), ""
+}
+
+// MatchCity checks whether the city matches the name and the country as FindCity does:
+// the name is the name of the city or one of the alternative names, the country
+// is the beginning of the name of the country. Names are compared case-insensitively,
+// exact is true if the city is named exactly so.
+func MatchCity(city models.City, cityName, countryName string) (match, exact bool) {
+	exact = strings.EqualFold(city.Name, cityName)
+	if !exact && !strings.Contains(strings.ToLower(city.AlternativeNames), strings.ToLower(cityName)+",") {
+		return false, false
+	}
+
+	if !strings.HasPrefix(strings.ToLower(city.Country), strings.ToLower(countryName)) {
+		return false, false
+	}
+
+	return true, exact
+}
+
+// ResolveCandidates picks the city named cityName from the cities found by the name
+// for ResolveCity. Cities named exactly so are preferred over the cities having it
+// among alternative names.
+func ResolveCandidates(cities []models.City, cityName string) (models.City, error) {
+	exact := make([]models.City, 0, len(cities))
+	for _, city := range cities {
+		if strings.EqualFold(city.Name, cityName) {
+			exact = append(exact, city)
+		}
+	}
+
+	switch {
+	case len(exact) == 1:
+		return exact[0], nil
+	case len(exact) > 1:
+		return models.City{}, &AmbiguousCityError{Candidates: exact}
+	case len(cities) == 1:
+		return cities[0], nil
+	case len(cities) > 1:
+		return models.City{}, &AmbiguousCityError{Candidates: cities}
+	default:
+		return models.City{}, ErrCityNotFound
+	}
+}
+
+// NearbyDelta converts the distance in km to the maximum differences
+// of latitude and longitude in degrees used by the search of the objects nearby.
+func NearbyDelta(distance int) (float64, float64) {
+	degreeLat := float64(distance) / oneDegreesInKmLat
+	degreeLon := math.Abs(float64(distance) / oneDegreesInKmLon * math.Cos(degreeLat))
+
+	return degreeLat, degreeLon
+}
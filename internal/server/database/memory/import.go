@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// citySync synchronizes the cities of the source with the dataset as database.CitySync
+// does. Changes are collected and applied at once on finish, there are no checkpoints,
+// so the interrupted import starts from the beginning. The storage has no cities
+// imported before the sources were introduced, so they are not matched.
+type citySync struct {
+	ctx      context.Context
+	store    *Store
+	existing map[string]models.City
+	seen     map[string]bool
+	changes  []models.City // inserted cities have no id
+	source   string
+	stats    database.ImportStats
+	dryRun   bool
+}
+
+// BeginCitySync starts the synchronization of the cities of the source.
+func (s *Store) BeginCitySync(ctx context.Context, source string, opts database.SyncOptions) (database.CitySyncer, error) {
+	sync := &citySync{
+		ctx:      ctx,
+		store:    s,
+		existing: make(map[string]models.City),
+		seen:     make(map[string]bool),
+		source:   source,
+		dryRun:   opts.DryRun,
+	}
+
+	s.mu.RLock()
+	for _, city := range s.cities {
+		if city.Source == source {
+			sync.existing[city.ExternalID] = city
+		}
+	}
+	s.mu.RUnlock()
+
+	return sync, nil
+}
+
+// HasCheckpoint returns false, the storage keeps no checkpoints.
+func (s *Store) HasCheckpoint(context.Context, string) bool {
+	return false
+}
+
+// Position returns 0, the import always starts from the beginning.
+func (c *citySync) Position() int {
+	return 0
+}
+
+// Stats returns the current results of the synchronization.
+func (c *citySync) Stats() database.ImportStats {
+	return c.stats
+}
+
+// Seen marks the city as present in the dataset without writing it.
+func (c *citySync) Seen(externalID string) {
+	if externalID != "" {
+		c.seen[externalID] = true
+	}
+}
+
+// Skip counts the invalid record of the dataset.
+func (c *citySync) Skip(externalID string) {
+	c.Seen(externalID)
+	c.stats.Skipped++
+}
+
+// Write collects the changes of the batch of the cities.
+func (c *citySync) Write(cities []models.City, _ int) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+
+	for _, city := range cities {
+		if c.seen[city.ExternalID] {
+			return fmt.Errorf("%w: %s", database.ErrDuplicateExternalID, city.ExternalID)
+		}
+
+		c.seen[city.ExternalID] = true
+		city.Source = c.source
+
+		current, ok := c.existing[city.ExternalID]
+
+		switch {
+		case !ok:
+			city.ID = 0
+			city.CreatedAt = now
+			c.stats.Inserted++
+		case database.SameCity(current, city):
+			c.stats.Unchanged++
+			continue
+		default:
+			city.ID = current.ID
+			city.CreatedAt = current.CreatedAt
+			c.stats.Updated++
+		}
+
+		c.changes = append(c.changes, city)
+	}
+
+	return nil
+}
+
+// Finish deletes the cities of the source absent in the dataset and applies
+// the changes (discards them in the dry run).
+func (c *citySync) Finish() (database.ImportStats, error) {
+	if err := c.ctx.Err(); err != nil {
+		return c.stats, err
+	}
+
+	removed := make(map[int]bool)
+	for externalID, city := range c.existing {
+		if !c.seen[externalID] {
+			removed[city.ID] = true
+		}
+	}
+
+	c.stats.Deleted += len(removed)
+
+	if c.dryRun {
+		return c.stats, c.Close()
+	}
+
+	s := c.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(removed) > 0 {
+		kept := s.cities[:0]
+		for _, city := range s.cities {
+			if !removed[city.ID] {
+				kept = append(kept, city)
+			}
+		}
+
+		s.cities = kept
+	}
+
+	for _, city := range c.changes {
+		if city.ID == 0 {
+			city.ID = s.lastCity + 1
+		} else if _, ok := s.findCity(city.ID); !ok {
+			continue // the city was deleted during the import
+		}
+
+		s.putCity(city)
+	}
+
+	c.changes = nil
+
+	return c.stats, nil
+}
+
+// Close discards the not applied changes.
+func (c *citySync) Close() error {
+	c.changes = nil
+	return nil
+}
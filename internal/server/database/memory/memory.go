@@ -0,0 +1,519 @@
+// Package memory implements the storage of the application in memory.
+// It is used by tests and by deployments which load the dataset on start,
+// all data are lost on restart.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// Store is the storage in memory, it is safe for concurrent use.
+// Errors are the same as of database.DB, records which are not found
+// are reported by sql.ErrNoRows.
+type Store struct {
+	cities      []models.City // ordered by id
+	users       map[int]models.User
+	tokens      map[string]models.UserToken // tokens of the users by the hashes
+	suggestions map[int]models.Suggestion
+	audit       []models.Audit
+	lastCity    int // last id of the city
+	lastUser    int // last id of the user
+	lastSugg    int // last id of the suggestion
+	mu          sync.RWMutex
+}
+
+// check that Store implements database.Store
+var _ database.Store = (*Store)(nil)
+
+// New creates the storage containing the cities, ids are assigned to the cities without id.
+func New(cities ...models.City) *Store {
+	s := &Store{
+		users:       make(map[int]models.User),
+		tokens:      make(map[string]models.UserToken),
+		suggestions: make(map[int]models.Suggestion),
+	}
+
+	for _, city := range cities {
+		if city.ID == 0 {
+			city.ID = s.lastCity + 1
+		}
+
+		s.putCity(city)
+	}
+
+	return s
+}
+
+// AddUser adds the user as is and returns its id. It lets to create the users
+// with roles, which are assigned in the database by the administrator.
+func (s *Store) AddUser(user models.User) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastUser++
+	user.UID = s.lastUser
+	s.users[user.UID] = user
+
+	return user.UID
+}
+
+// Migrate does nothing, the storage has no schema.
+func (s *Store) Migrate(context.Context) error {
+	return nil
+}
+
+// Ping does nothing, the storage is always available.
+func (s *Store) Ping(context.Context) error {
+	return nil
+}
+
+// Close does nothing, data are kept until the storage is collected.
+func (s *Store) Close() error {
+	return nil
+}
+
+// CreateUser creates the user.
+func (s *Store) CreateUser(_ context.Context, name, email, password string, verified bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, email) {
+			return 0, database.ErrUserAlreadyExists
+		}
+	}
+
+	s.lastUser++
+	s.users[s.lastUser] = models.User{
+		UID:       s.lastUser,
+		Name:      name,
+		Email:     email,
+		Password:  password,
+		Role:      models.RoleUser,
+		CreatedAt: time.Now().Unix(),
+		Verified:  verified,
+	}
+
+	return s.lastUser, nil
+}
+
+// GetUser returns the user by email.
+func (s *Store) GetUser(_ context.Context, email string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+
+	return models.User{}, sql.ErrNoRows
+}
+
+// GetUserByID returns the user by id.
+func (s *Store) GetUserByID(_ context.Context, uid int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+// UpdatePassword replaces the password of the user.
+func (s *Store) UpdatePassword(_ context.Context, uid int, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	user.Password = password
+	s.users[uid] = user
+
+	return nil
+}
+
+// ChangePassword replaces the password of the user and stores the time of the change.
+func (s *Store) ChangePassword(_ context.Context, uid int, password string, changedAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	user.Password = password
+	user.PasswordChangedAt = changedAt
+	s.users[uid] = user
+
+	return nil
+}
+
+// VerifyUser marks the email of the user as confirmed.
+func (s *Store) VerifyUser(_ context.Context, uid int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	user.Verified = true
+	s.users[uid] = user
+
+	return nil
+}
+
+// CreateUserToken stores the token, the previous tokens of the user
+// with the same purpose and the expired tokens are removed.
+func (s *Store) CreateUserToken(_ context.Context, token models.UserToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+
+	for hash, t := range s.tokens {
+		if (t.UID == token.UID && t.Purpose == token.Purpose) || t.ExpiresAt < now {
+			delete(s.tokens, hash)
+		}
+	}
+
+	s.tokens[token.Hash] = token
+
+	return nil
+}
+
+// ConsumeUserToken removes the token and returns the id of its user.
+func (s *Store) ConsumeUserToken(_ context.Context, purpose, hash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[hash]
+	if !ok || token.Purpose != purpose {
+		return 0, database.ErrTokenNotFound
+	}
+
+	delete(s.tokens, hash)
+
+	if token.ExpiresAt < time.Now().Unix() {
+		return 0, database.ErrTokenNotFound
+	}
+
+	return token.UID, nil
+}
+
+// GetCity returns the city by id.
+func (s *Store) GetCity(_ context.Context, cid int) (models.City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.findCity(cid)
+	if !ok {
+		return models.City{}, sql.ErrNoRows
+	}
+
+	return s.cities[i], nil
+}
+
+// CountCities returns quantity of the cities.
+func (s *Store) CountCities(context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.cities), nil
+}
+
+// FindCity returns the first city matching the name as database.DB.FindCity does.
+func (s *Store) FindCity(_ context.Context, cityRaw string) (models.City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cityName, countryName := database.SplitCityRaw(cityRaw)
+
+	for _, city := range s.cities {
+		if match, _ := database.MatchCity(city, cityName, countryName); match {
+			return summary(city), nil
+		}
+	}
+
+	return models.City{}, sql.ErrNoRows
+}
+
+// FindCities returns the cities by names, cities named exactly so are preferred.
+func (s *Store) FindCities(_ context.Context, citiesRaw []string) (map[string]models.City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]models.City, len(citiesRaw))
+
+	for _, cityRaw := range citiesRaw {
+		cityName, countryName := database.SplitCityRaw(cityRaw)
+
+		for _, city := range s.cities {
+			match, exact := database.MatchCity(city, cityName, countryName)
+			if !match {
+				continue
+			}
+
+			if _, ok := found[cityRaw]; !ok || exact {
+				found[cityRaw] = summary(city)
+			}
+
+			if exact {
+				break
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// ResolveCity returns the city by name, the country can be given by its name or code.
+func (s *Store) ResolveCity(_ context.Context, cityRaw string) (models.City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cityName, countryName := database.SplitCityRaw(cityRaw)
+	exact, other := make([]models.City, 0), make([]models.City, 0)
+
+	for _, city := range s.cities {
+		match, isExact := database.MatchCity(city, cityName, "")
+		if !match || !strings.HasPrefix(strings.ToLower(city.Country), strings.ToLower(countryName)) &&
+			!strings.EqualFold(city.CountryCode, countryName) {
+			continue
+		}
+
+		if isExact {
+			exact = append(exact, summary(city))
+		} else {
+			other = append(other, summary(city))
+		}
+	}
+
+	candidates := append(exact, other...)
+	if len(candidates) > database.MaxCandidates {
+		candidates = candidates[:database.MaxCandidates]
+	}
+
+	return database.ResolveCandidates(candidates, cityName)
+}
+
+// FindObjectsNearByName returns the city and the cities at a distance until n km from it.
+func (s *Store) FindObjectsNearByName(ctx context.Context, departure string, distance int) (models.City, []models.City, error) {
+	city, err := s.FindCity(ctx, departure)
+	if err != nil {
+		return city, nil, err
+	}
+
+	cities, err := s.FindObjectsNearByCoord(ctx, city.Latitude, city.Longitude, distance)
+	if err != nil {
+		return city, nil, err
+	}
+
+	return city, cities, nil
+}
+
+// FindObjectsNearByCoord returns the cities at a distance until n km from the coordinates.
+func (s *Store) FindObjectsNearByCoord(ctx context.Context, lat, lon float64, distance int) ([]models.City, error) {
+	var cities []models.City
+
+	err := s.EachObjectNearByCoord(ctx, lat, lon, distance, func(city models.City) error {
+		cities = append(cities, city)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cities, nil
+}
+
+// EachObjectNearByCoord calls fn for every city at a distance until n km from the coordinates.
+// The cities are selected as by database.DB, fn is called without the lock of the storage.
+func (s *Store) EachObjectNearByCoord(ctx context.Context, lat, lon float64, distance int,
+	fn func(models.City) error,
+) error {
+	degreeLat, degreeLon := database.NearbyDelta(distance)
+
+	s.mu.RLock()
+	cities := make([]models.City, 0)
+	for _, city := range s.cities {
+		if math.Abs(city.Latitude-lat) <= degreeLat && math.Abs(city.Longitude-lon) <= degreeLon {
+			cities = append(cities, models.City{
+				ID:        city.ID,
+				Name:      city.Name,
+				Country:   city.Country,
+				Latitude:  city.Latitude,
+				Longitude: city.Longitude,
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, city := range cities {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(city); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindNearestCity returns the city nearest to the coordinates.
+func (s *Store) FindNearestCity(_ context.Context, lat, lon float64) (models.City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.cities) == 0 {
+		return models.City{}, sql.ErrNoRows
+	}
+
+	scale := math.Cos(lat * math.Pi / 180)
+	nearest, min := 0, math.Inf(1)
+
+	for i, city := range s.cities {
+		dLat, dLon := city.Latitude-lat, (city.Longitude-lon)*scale
+		if d := dLat*dLat + dLon*dLon; d < min {
+			nearest, min = i, d
+		}
+	}
+
+	return summary(s.cities[nearest]), nil
+}
+
+// ListCountries returns the countries of the cities ordered by name.
+func (s *Store) ListCountries(context.Context) ([]models.Country, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[models.Country]bool)
+	countries := make([]models.Country, 0)
+
+	for _, city := range s.cities {
+		country := models.Country{Code: city.CountryCode, Name: city.Country}
+		if country.Name == "" || seen[country] {
+			continue
+		}
+
+		seen[country] = true
+		countries = append(countries, country)
+	}
+
+	sort.Slice(countries, func(i, j int) bool {
+		if countries[i].Name != countries[j].Name {
+			return countries[i].Name < countries[j].Name
+		}
+
+		return countries[i].Code < countries[j].Code
+	})
+
+	return countries, nil
+}
+
+// ExportCities calls fn for every city selected by the filter ordered by id.
+func (s *Store) ExportCities(ctx context.Context, filter database.CityFilter, fn func(models.City) error) error {
+	s.mu.RLock()
+	cities := make([]models.City, 0)
+	for _, city := range s.cities {
+		if matchFilter(city, filter) {
+			cities = append(cities, city)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, city := range cities {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(city); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findCity returns the index of the city by id, the caller must hold the lock.
+func (s *Store) findCity(cid int) (int, bool) {
+	i := sort.Search(len(s.cities), func(i int) bool {
+		return s.cities[i].ID >= cid
+	})
+
+	return i, i < len(s.cities) && s.cities[i].ID == cid
+}
+
+// putCity inserts or replaces the city by id, the caller must hold the lock.
+func (s *Store) putCity(city models.City) {
+	if city.ID > s.lastCity {
+		s.lastCity = city.ID
+	}
+
+	i, ok := s.findCity(city.ID)
+	if ok {
+		s.cities[i] = city
+		return
+	}
+
+	s.cities = append(s.cities, models.City{})
+	copy(s.cities[i+1:], s.cities[i:])
+	s.cities[i] = city
+}
+
+// summary returns the fields of the city selected by the search queries of database.DB.
+func summary(city models.City) models.City {
+	city.AlternativeNames = ""
+	city.Source = ""
+	city.ExternalID = ""
+	city.CreatedAt = 0
+
+	return city
+}
+
+// matchFilter checks whether the city is selected by the filter.
+func matchFilter(city models.City, filter database.CityFilter) bool {
+	if filter.Country != "" && !strings.EqualFold(city.CountryCode, filter.Country) &&
+		!strings.EqualFold(city.Country, filter.Country) {
+		return false
+	}
+
+	if filter.Timezone != "" && city.Timezone != filter.Timezone {
+		return false
+	}
+
+	if box := filter.BBox; box != nil {
+		if city.Latitude < box.MinLat || city.Latitude > box.MaxLat {
+			return false
+		}
+
+		if box.MinLon <= box.MaxLon {
+			return city.Longitude >= box.MinLon && city.Longitude <= box.MaxLon
+		}
+
+		return city.Longitude >= box.MinLon || city.Longitude <= box.MaxLon
+	}
+
+	return true
+}
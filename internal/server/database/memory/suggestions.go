@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// CreateSuggestion creates the pending suggestion and returns its id.
+func (s *Store) CreateSuggestion(_ context.Context, sugg models.Suggestion) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSugg++
+	sugg.ID = s.lastSugg
+	sugg.Status = models.SuggestionPending
+	sugg.ReviewerID, sugg.ReviewComment, sugg.ReviewedAt = 0, "", 0
+	sugg.CreatedAt = time.Now().Unix()
+	s.suggestions[sugg.ID] = sugg
+
+	return sugg.ID, nil
+}
+
+// GetSuggestion returns the suggestion by id.
+func (s *Store) GetSuggestion(_ context.Context, sid int) (models.Suggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sugg, ok := s.suggestions[sid]
+	if !ok {
+		return sugg, database.ErrSuggestionNotFound
+	}
+
+	return sugg, nil
+}
+
+// ListSuggestions returns the suggestions by status (any if empty) of the user (all if 0).
+func (s *Store) ListSuggestions(_ context.Context, status string, uid int) ([]models.Suggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suggestions := make([]models.Suggestion, 0)
+
+	for _, sugg := range s.suggestions {
+		if (status == "" || sugg.Status == status) && (uid == 0 || sugg.UID == uid) {
+			suggestions = append(suggestions, sugg)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].ID < suggestions[j].ID
+	})
+
+	return suggestions, nil
+}
+
+// ApproveSuggestion applies the suggestion to the cities, marks it as approved
+// and records the action in the audit trail. Returns the city after changes.
+func (s *Store) ApproveSuggestion(_ context.Context, sid, reviewerID int, comment string) (models.City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sugg, err := s.pendingSuggestion(sid)
+	if err != nil {
+		return models.City{}, err
+	}
+
+	var current models.City
+	if sugg.CityID != 0 {
+		i, ok := s.findCity(sugg.CityID)
+		if !ok {
+			return models.City{}, database.ErrCityNotFound
+		}
+
+		current = s.cities[i]
+	}
+
+	changes := sugg.Diff(current)
+	now := time.Now().Unix()
+	city := sugg.Apply(current)
+
+	if sugg.CityID == 0 {
+		city.ID = s.lastCity + 1
+		city.CreatedAt = now
+		city.Source = database.SourceSuggestions
+		city.ExternalID = strconv.Itoa(sid)
+	}
+
+	details := struct {
+		SuggestionID int                  `json:"suggestion_id"`
+		Comment      string               `json:"comment,omitempty"`
+		Changes      []models.FieldChange `json:"changes"`
+	}{
+		SuggestionID: sid,
+		Comment:      comment,
+		Changes:      changes,
+	}
+
+	if err := s.addAudit(reviewerID, database.AuditSuggestionApproved, city.ID, details, now); err != nil {
+		return models.City{}, err
+	}
+
+	s.putCity(city)
+	s.reviewSuggestion(sugg, reviewerID, models.SuggestionApproved, comment, now)
+
+	return city, nil
+}
+
+// RejectSuggestion marks the suggestion as rejected and records the action in the audit trail.
+func (s *Store) RejectSuggestion(_ context.Context, sid, reviewerID int, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sugg, err := s.pendingSuggestion(sid)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+
+	details := struct {
+		SuggestionID int    `json:"suggestion_id"`
+		Comment      string `json:"comment,omitempty"`
+	}{
+		SuggestionID: sid,
+		Comment:      comment,
+	}
+
+	if err := s.addAudit(reviewerID, database.AuditSuggestionRejected, sugg.CityID, details, now); err != nil {
+		return err
+	}
+
+	s.reviewSuggestion(sugg, reviewerID, models.SuggestionRejected, comment, now)
+
+	return nil
+}
+
+// ListAudit returns the records of the audit trail for the entity.
+func (s *Store) ListAudit(_ context.Context, entity string, entityID int) ([]models.Audit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]models.Audit, 0)
+
+	for _, record := range s.audit {
+		if record.Entity == entity && record.EntityID == entityID {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// pendingSuggestion returns the suggestion and error if it is not pending,
+// the caller must hold the lock.
+func (s *Store) pendingSuggestion(sid int) (models.Suggestion, error) {
+	sugg, ok := s.suggestions[sid]
+	if !ok {
+		return sugg, database.ErrSuggestionNotFound
+	}
+
+	if sugg.Status != models.SuggestionPending {
+		return sugg, database.ErrSuggestionReviewed
+	}
+
+	return sugg, nil
+}
+
+// reviewSuggestion sets status and reviewer of the suggestion, the caller must hold the lock.
+func (s *Store) reviewSuggestion(sugg models.Suggestion, reviewerID int, status, comment string, now int64) {
+	sugg.Status = status
+	sugg.ReviewerID = reviewerID
+	sugg.ReviewComment = comment
+	sugg.ReviewedAt = now
+	s.suggestions[sugg.ID] = sugg
+}
+
+// addAudit adds a record to the audit trail of the cities, the caller must hold the lock.
+func (s *Store) addAudit(uid int, action string, entityID int, details any, now int64) error {
+	b, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	s.audit = append(s.audit, models.Audit{
+		ID:        len(s.audit) + 1,
+		UID:       uid,
+		Action:    action,
+		Entity:    database.AuditEntityCity,
+		EntityID:  entityID,
+		Details:   string(b),
+		CreatedAt: now,
+	})
+
+	return nil
+}
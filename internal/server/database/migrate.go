@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/schema"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	migrationLockName    = "geospace_migrations" // name of the lock of the migrations in MariaDB
+	migrationLockKey     = 7235431961842475616   // key of the advisory lock of the migrations in PostgreSQL
+	migrationLockTimeout = 60                    // seconds to wait for the lock of the migrations in MariaDB
+)
+
+// ErrSchemaNewer is returned if the schema of the database was migrated
+// by a newer version of the application than this one.
+var ErrSchemaNewer = errors.New("schema of the database is newer than the application knows")
+
+// MigrationState is the state of the migration in the database.
+type MigrationState struct {
+	Name      string // name of the migration, "<version>_<name>"
+	Version   int    // version of the schema after the migration
+	AppliedAt int64  // date when the migration was applied formated by Unix timestamp, 0 if pending
+}
+
+// Applied checks whether the migration is applied.
+func (m MigrationState) Applied() bool {
+	return m.AppliedAt != 0
+}
+
+// SchemaStatus contains the version of the schema in the database
+// and the states of the migrations known to the application.
+type SchemaStatus struct {
+	Migrations []MigrationState // migrations known to the application ordered by version
+	Version    int              // the latest applied version, 0 if the schema is empty
+	Latest     int              // the latest version known to the application
+}
+
+// Migrate applies the pending migrations. It returns ErrSchemaNewer
+// if the database has a newer version of the schema than the application knows.
+func (db *DB) Migrate(ctx context.Context) error {
+	_, err := db.MigrateUp(ctx)
+
+	return err
+}
+
+// MigrateUp applies the pending migrations and returns them. Every migration is applied
+// in its own transaction (MariaDB commits the changes of the schema at once) together with
+// the record in the table schema_migrations. Instances of the application starting at the
+// same time wait for each other by the lock, so every migration is applied only once.
+// The timeout of the queries is not applied to the migrations.
+func (db *DB) MigrateUp(ctx context.Context) ([]MigrationState, error) {
+	applied := make([]MigrationState, 0)
+
+	err := db.withMigrationLock(ctx, func(conn *sqlx.Conn, migrations []schema.Migration) error {
+		versions, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err := checkSchemaVersion(versions, migrations); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := versions[m.Version]; ok {
+				continue
+			}
+
+			state, err := db.runMigration(ctx, conn, m, true)
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", m.Name, err)
+			}
+
+			applied = append(applied, state)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown reverts the latest applied migrations (steps of them)
+// and returns them in the order of reverting.
+func (db *DB) MigrateDown(ctx context.Context, steps int) ([]MigrationState, error) {
+	reverted := make([]MigrationState, 0, steps)
+
+	err := db.withMigrationLock(ctx, func(conn *sqlx.Conn, migrations []schema.Migration) error {
+		versions, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// the migrations unknown to the application cannot be reverted
+		if err := checkSchemaVersion(versions, migrations); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := versions[m.Version]; !ok {
+				continue
+			}
+
+			state, err := db.runMigration(ctx, conn, m, false)
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", m.Name, err)
+			}
+
+			reverted = append(reverted, state)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus returns the version of the schema in the database
+// and the states of the migrations known to the application.
+func (db *DB) MigrationStatus(ctx context.Context) (SchemaStatus, error) {
+	var status SchemaStatus
+
+	migrations, err := schema.Migrations(db.driver)
+	if err != nil {
+		return status, err
+	}
+
+	if _, err := db.SQLX.ExecContext(ctx, schema.MigrationsTable[db.driver]); err != nil {
+		return status, err
+	}
+
+	versions, err := appliedMigrations(ctx, db.SQLX)
+	if err != nil {
+		return status, err
+	}
+
+	for version := range versions {
+		if version > status.Version {
+			status.Version = version
+		}
+	}
+
+	for _, m := range migrations {
+		status.Migrations = append(status.Migrations, MigrationState{
+			Name:      m.Name,
+			Version:   m.Version,
+			AppliedAt: versions[m.Version],
+		})
+		status.Latest = m.Version
+	}
+
+	return status, nil
+}
+
+// withMigrationLock calls fn with the connection holding the lock of the migrations.
+// The table of the applied migrations is created before.
+func (db *DB) withMigrationLock(ctx context.Context, fn func(*sqlx.Conn, []schema.Migration) error) error {
+	migrations, err := schema.Migrations(db.driver)
+	if err != nil {
+		return err
+	}
+
+	// the lock belongs to the connection, so all queries are made by it
+	conn, err := db.SQLX.Connx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if err := db.lockMigrations(ctx, conn); err != nil {
+		return err
+	}
+
+	defer db.unlockMigrations(conn)
+
+	if _, err := conn.ExecContext(ctx, schema.MigrationsTable[db.driver]); err != nil {
+		return err
+	}
+
+	return fn(conn, migrations)
+}
+
+// lockMigrations takes the lock of the migrations. SQLite has no such locks,
+// the transactions of the migrations lock the whole database there.
+func (db *DB) lockMigrations(ctx context.Context, conn *sqlx.Conn) error {
+	switch db.driver {
+	case config.DriverMariaDB:
+		var locked int
+		if err := conn.GetContext(ctx, &locked, `SELECT GET_LOCK(?, ?)`,
+			migrationLockName, migrationLockTimeout); err != nil {
+			return err
+		}
+
+		if locked != 1 {
+			return fmt.Errorf("lock of the migrations is not taken in %d seconds", migrationLockTimeout)
+		}
+	case config.DriverPostgres:
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey)
+		return err
+	}
+
+	return nil
+}
+
+// unlockMigrations releases the lock of the migrations. The lock is released
+// even if the context is canceled, it is also released on closing the connection.
+func (db *DB) unlockMigrations(conn *sqlx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(migrationLockTimeout)*time.Second)
+	defer cancel()
+
+	switch db.driver {
+	case config.DriverMariaDB:
+		_, _ = conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, migrationLockName)
+	case config.DriverPostgres:
+		_, _ = conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+	}
+}
+
+// runMigration applies (up is true) or reverts the migration in the transaction.
+// The migration is skipped if it was applied or reverted by another instance meanwhile.
+func (db *DB) runMigration(ctx context.Context, conn *sqlx.Conn, m schema.Migration, up bool) (MigrationState, error) {
+	state := MigrationState{Name: m.Name, Version: m.Version}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return state, err
+	}
+
+	defer tx.Rollback()
+
+	var count int
+	err = tx.GetContext(ctx, &count, tx.Rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), m.Version)
+	if err != nil {
+		return state, err
+	}
+
+	if (count > 0) == up {
+		return state, tx.Commit()
+	}
+
+	statements := m.Down
+	if up {
+		statements = m.Up
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return state, err
+		}
+	}
+
+	if up {
+		state.AppliedAt = time.Now().Unix()
+		_, err = tx.ExecContext(ctx, tx.Rebind(`INSERT INTO schema_migrations (version, name, applied_at)
+		VALUES (?, ?, ?)`), m.Version, m.Name, state.AppliedAt)
+	} else {
+		_, err = tx.ExecContext(ctx, tx.Rebind(`DELETE FROM schema_migrations WHERE version = ?`), m.Version)
+	}
+
+	if err != nil {
+		return state, err
+	}
+
+	return state, tx.Commit()
+}
+
+// appliedMigrations returns the dates of the applied migrations by versions.
+func appliedMigrations(ctx context.Context, q sqlx.QueryerContext) (map[int]int64, error) {
+	var rows []struct {
+		Version   int   `db:"version"`
+		AppliedAt int64 `db:"applied_at"`
+	}
+
+	if err := sqlx.SelectContext(ctx, q, &rows, `SELECT version, applied_at FROM schema_migrations`); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+
+	return versions, nil
+}
+
+// checkSchemaVersion returns ErrSchemaNewer if some of the applied
+// migrations are unknown to the application.
+func checkSchemaVersion(versions map[int]int64, migrations []schema.Migration) error {
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+
+	unknown := make([]int, 0)
+
+	for version := range versions {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Ints(unknown)
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	return fmt.Errorf("%w: version %d is applied, the latest known is %d",
+		ErrSchemaNewer, unknown[len(unknown)-1], latest)
+}
@@ -0,0 +1,79 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+func TestSQLiteMigrations(t *testing.T) {
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "geospace.db"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+
+	applied, err := db.MigrateUp(ctx)
+	if err != nil || len(applied) == 0 {
+		t.Fatalf("MigrateUp: %v, %v", applied, err)
+	}
+
+	if applied, err := db.MigrateUp(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("second MigrateUp: %v, %v", applied, err)
+	}
+
+	status, err := db.MigrationStatus(ctx)
+	if err != nil || status.Version != status.Latest || !status.Migrations[0].Applied() {
+		t.Fatalf("MigrationStatus: %+v, %v", status, err)
+	}
+
+	reverted, err := db.MigrateDown(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != status.Latest {
+		t.Fatalf("MigrateDown: %v, %v", reverted, err)
+	}
+
+	// the latest migration adds the proposed fields of the suggestions,
+	// the time of the change of the password of the previous migration is kept
+	if _, err := db.CreateSuggestion(ctx, models.Suggestion{UID: 1, Fields: "name"}); err == nil {
+		t.Error("column of the proposed fields is kept after reverting the latest migration")
+	}
+
+	if err := db.ChangePassword(ctx, 1, "secret", 1); err != nil {
+		t.Errorf("column of the change of the password is reverted with the latest migration: %v", err)
+	}
+
+	if _, err := db.ConsumeUserToken(ctx, models.TokenVerifyEmail, "token"); !errors.Is(err, database.ErrTokenNotFound) {
+		t.Errorf("table of the tokens is reverted with the latest migration: %v", err)
+	}
+
+	if _, err := db.CountCities(ctx); err != nil {
+		t.Errorf("tables of the initial migration are reverted: %v", err)
+	}
+
+	if applied, err := db.MigrateUp(ctx); err != nil || len(applied) != 1 {
+		t.Errorf("MigrateUp after down: %v, %v", applied, err)
+	}
+
+	// the schema migrated by a newer application
+	_, err = db.SQLX.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at)
+	VALUES (?, 'future', 1)`, status.Latest+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Migrate(ctx); !errors.Is(err, database.ErrSchemaNewer) {
+		t.Errorf("Migrate: want ErrSchemaNewer, got %v", err)
+	}
+
+	if _, err := db.MigrateDown(ctx, 1); !errors.Is(err, database.ErrSchemaNewer) {
+		t.Errorf("MigrateDown: want ErrSchemaNewer, got %v", err)
+	}
+}
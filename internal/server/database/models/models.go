@@ -1,6 +1,27 @@
 // Package models contains data structures for current app.
 package models
 
+import "strings"
+
+// roles of the users
+const (
+	RoleUser   = "user"   // ordinary user
+	RoleEditor = "editor" // user who reviews suggestions
+)
+
+// purposes of the tokens sent to the users by email
+const (
+	TokenVerifyEmail   = "verify_email"   // confirmation of the email after the registration
+	TokenResetPassword = "reset_password" // reset of the forgotten password
+)
+
+// statuses of the suggestions
+const (
+	SuggestionPending  = "pending"  // suggestion is waiting for review
+	SuggestionApproved = "approved" // suggestion was approved and applied
+	SuggestionRejected = "rejected" // suggestion was rejected
+)
+
 type (
 	City struct {
 		Name             string  `db:"name" json:"name"`                                     // Name of the city
@@ -9,6 +30,8 @@ type (
 		CountryCode      string  `db:"country_code" json:"country_code,omitempty"`           // Code of country with this city located
 		Country          string  `db:"country" json:"country"`                               // Name of the country with this city located
 		Timezone         string  `db:"timezone" json:"timezone,omitempty"`                   // Name of the timezone with this city located
+		Source           string  `db:"source" json:"source,omitempty"`                       // Name of the dataset the city was imported from
+		ExternalID       string  `db:"external_id" json:"external_id,omitempty"`             // ID of the city in the dataset
 		CreatedAt        int64   `db:"created_at" json:"created_at,omitempty"`               // Date when the city was created formated by Unix timestamp
 		ID               int     `db:"cid" json:"city_id"`                                   // ID of the city (inside application)
 		Latitude         float64 `db:"latitude" json:"latitude"`                             // Latitude of the city
@@ -19,7 +42,155 @@ type (
 		Name      string `db:"name"`       // Name of the user
 		Email     string `db:"email"`      // Email of the user
 		Password  string `db:"password"`   // Password of the user
+		Role      string `db:"role"`       // Role of the user
 		UID       int    `db:"uid"`        // ID of the user
 		CreatedAt int64  `db:"created_at"` // Date when the user was created
+		Verified  bool   `db:"verified"`   // Email of the user is confirmed
+
+		// Time of the last change of the password in unix seconds,
+		// the tokens issued before it are revoked
+		PasswordChangedAt int64 `db:"password_changed_at"`
+	}
+
+	// UserToken is the token sent to the user by email, only its hash is stored.
+	UserToken struct {
+		Hash      string `db:"token_hash"` // SHA-256 of the token in hex
+		Purpose   string `db:"purpose"`    // Purpose of the token
+		UID       int    `db:"uid"`        // ID of the user
+		ExpiresAt int64  `db:"expires_at"` // Date when the token expires formated by Unix timestamp
+		CreatedAt int64  `db:"created_at"` // Date when the token was created
+	}
+
+	// Country is the country of the cities.
+	Country struct {
+		Code string `db:"country_code" json:"country_code"` // Code of the country
+		Name string `db:"country" json:"country"`           // Name of the country
+	}
+
+	// Suggestion is a correction of an existing city or a new city
+	// proposed by the user and waiting for review by the editor.
+	Suggestion struct {
+		Name             string  `db:"name" json:"name"`                               // Proposed name of the city
+		NameASCII        string  `db:"name_ascii" json:"name_ascii"`                   // Proposed name of the city ASCII
+		AlternativeNames string  `db:"alternative_names" json:"alternative_names"`     // Proposed alternative names of the city
+		CountryCode      string  `db:"country_code" json:"country_code"`               // Proposed code of country
+		Country          string  `db:"country" json:"country"`                         // Proposed name of the country
+		Timezone         string  `db:"timezone" json:"timezone"`                       // Proposed timezone
+		Comment          string  `db:"comment" json:"comment,omitempty"`               // Comment of the user
+		Status           string  `db:"status" json:"status"`                           // Status of the suggestion
+		ReviewComment    string  `db:"review_comment" json:"review_comment,omitempty"` // Comment of the editor
+		CreatedAt        int64   `db:"created_at" json:"created_at"`                   // Date when the suggestion was created
+		ReviewedAt       int64   `db:"reviewed_at" json:"reviewed_at,omitempty"`       // Date when the suggestion was reviewed
+		ID               int     `db:"sid" json:"suggestion_id"`                       // ID of the suggestion
+		UID              int     `db:"uid" json:"uid"`                                 // ID of the user who proposed
+		ReviewerID       int     `db:"reviewer_id" json:"reviewer_id,omitempty"`       // ID of the editor who reviewed
+		CityID           int     `db:"cid" json:"city_id"`                             // ID of the corrected city, 0 for a new city
+		Latitude         float64 `db:"latitude" json:"latitude"`                       // Proposed latitude
+		Longitude        float64 `db:"longitude" json:"longitude"`                     // Proposed longitude
+		Fields           string  `db:"fields" json:"fields,omitempty"`                 // Proposed fields separated by commas, all fields if empty
+	}
+
+	// FieldChange is a difference of one field between
+	// the current city and the suggestion.
+	FieldChange struct {
+		Field    string `json:"field"`    // Name of the field
+		Current  any    `json:"current"`  // Current value
+		Proposed any    `json:"proposed"` // Proposed value
+	}
+
+	// Audit is a record of the action performed over the data.
+	Audit struct {
+		Action    string `db:"action" json:"action"`         // Name of the action
+		Entity    string `db:"entity" json:"entity"`         // Name of the changed entity
+		Details   string `db:"details" json:"details"`       // Details of the action in JSON
+		CreatedAt int64  `db:"created_at" json:"created_at"` // Date when the action was performed
+		ID        int    `db:"aid" json:"audit_id"`          // ID of the record
+		UID       int    `db:"uid" json:"uid"`               // ID of the user who performed the action
+		EntityID  int    `db:"entity_id" json:"entity_id"`   // ID of the changed entity
 	}
 )
+
+// City returns the city which will be saved after approval of the suggestion.
+func (s Suggestion) City() City {
+	return City{
+		ID:               s.CityID,
+		Name:             s.Name,
+		NameASCII:        s.NameASCII,
+		AlternativeNames: s.AlternativeNames,
+		CountryCode:      s.CountryCode,
+		Country:          s.Country,
+		Timezone:         s.Timezone,
+		Latitude:         s.Latitude,
+		Longitude:        s.Longitude,
+	}
+}
+
+// Diff returns the list of the proposed fields which differ between the city and the suggestion.
+// For a new city the current city must be empty.
+func (s Suggestion) Diff(current City) []FieldChange {
+	proposed := s.City()
+	changes := make([]FieldChange, 0)
+
+	add := func(field string, cur, prop any) {
+		if s.Proposes(field) && cur != prop {
+			changes = append(changes, FieldChange{Field: field, Current: cur, Proposed: prop})
+		}
+	}
+
+	add("name", current.Name, proposed.Name)
+	add("name_ascii", current.NameASCII, proposed.NameASCII)
+	add("alternative_names", current.AlternativeNames, proposed.AlternativeNames)
+	add("country_code", current.CountryCode, proposed.CountryCode)
+	add("country", current.Country, proposed.Country)
+	add("timezone", current.Timezone, proposed.Timezone)
+	add("latitude", current.Latitude, proposed.Latitude)
+	add("longitude", current.Longitude, proposed.Longitude)
+
+	return changes
+}
+
+// Proposes reports whether the field is proposed by the suggestion.
+// The suggestions without the list of the fields propose all fields.
+func (s Suggestion) Proposes(field string) bool {
+	if s.Fields == "" {
+		return true
+	}
+
+	for _, f := range strings.Split(s.Fields, ",") {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply returns the current city with the proposed fields of the suggestion,
+// other fields keep the changes made after the suggestion was created.
+func (s Suggestion) Apply(current City) City {
+	city := current
+	proposed := s.City()
+
+	set := func(field string, dst *string, value string) {
+		if s.Proposes(field) {
+			*dst = value
+		}
+	}
+
+	set("name", &city.Name, proposed.Name)
+	set("name_ascii", &city.NameASCII, proposed.NameASCII)
+	set("alternative_names", &city.AlternativeNames, proposed.AlternativeNames)
+	set("country_code", &city.CountryCode, proposed.CountryCode)
+	set("country", &city.Country, proposed.Country)
+	set("timezone", &city.Timezone, proposed.Timezone)
+
+	if s.Proposes("latitude") {
+		city.Latitude = proposed.Latitude
+	}
+
+	if s.Proposes("longitude") {
+		city.Longitude = proposed.Longitude
+	}
+
+	return city
+}
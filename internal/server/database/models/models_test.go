@@ -0,0 +1,112 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+func TestSuggestionDiff(t *testing.T) {
+	current := models.City{
+		ID:          1,
+		Name:        "Rome",
+		NameASCII:   "Rome",
+		CountryCode: "IT",
+		Country:     "Italy",
+		Timezone:    "Europe/Rome",
+		Latitude:    41.89193,
+		Longitude:   12.51133,
+	}
+
+	tests := []struct {
+		name       string
+		current    models.City
+		suggestion models.Suggestion
+		fields     []string
+	}{
+		{
+			name:    "Correction without changes",
+			current: current,
+			suggestion: models.Suggestion{
+				CityID: 1, Name: "Rome", NameASCII: "Rome", CountryCode: "IT",
+				Country: "Italy", Timezone: "Europe/Rome", Latitude: 41.89193, Longitude: 12.51133,
+			},
+		},
+		{
+			name:    "Correction of name and coordinates",
+			current: current,
+			suggestion: models.Suggestion{
+				CityID: 1, Name: "Roma", NameASCII: "Rome", CountryCode: "IT",
+				Country: "Italy", Timezone: "Europe/Rome", Latitude: 41.9, Longitude: 12.51133,
+			},
+			fields: []string{"name", "latitude"},
+		},
+		{
+			name: "New city",
+			suggestion: models.Suggestion{
+				Name: "Tivoli", CountryCode: "IT", Latitude: 41.96, Longitude: 12.8,
+			},
+			fields: []string{"name", "country_code", "latitude", "longitude"},
+		},
+		{
+			name: "Correction of name after the change of timezone",
+			current: models.City{
+				ID: 1, Name: "Rome", NameASCII: "Rome", CountryCode: "IT",
+				Country: "Italy", Timezone: "CET", Latitude: 41.89193, Longitude: 12.51133,
+			},
+			suggestion: models.Suggestion{
+				CityID: 1, Name: "Roma", NameASCII: "Rome", CountryCode: "IT",
+				Country: "Italy", Timezone: "Europe/Rome", Latitude: 41.89193, Longitude: 12.51133,
+				Fields: "name",
+			},
+			fields: []string{"name"},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			changes := tt.suggestion.Diff(tt.current)
+			if len(changes) != len(tt.fields) {
+				t.Fatalf("expected %d changes, got %d: %v", len(tt.fields), len(changes), changes)
+			}
+
+			for i, field := range tt.fields {
+				if changes[i].Field != field {
+					t.Errorf("expected change of field %s, got %s", field, changes[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestionApply(t *testing.T) {
+	t.Parallel()
+
+	// the timezone of the city was changed after the suggestion was created
+	current := models.City{
+		ID: 1, Name: "Rome", NameASCII: "Rome", CountryCode: "IT", Country: "Italy",
+		Timezone: "CET", Latitude: 41.89193, Longitude: 12.51133, Source: "geonames", CreatedAt: 1,
+	}
+
+	s := models.Suggestion{
+		CityID: 1, Name: "Roma", NameASCII: "Rome", CountryCode: "IT",
+		Country: "Italy", Timezone: "Europe/Rome", Latitude: 41.9, Longitude: 12.51133,
+		Fields: "name,latitude",
+	}
+
+	expected := current
+	expected.Name = "Roma"
+	expected.Latitude = 41.9
+
+	if city := s.Apply(current); city != expected {
+		t.Errorf("expected %+v, got %+v", expected, city)
+	}
+
+	// a new city gets all fields
+	s.CityID, s.Fields = 0, ""
+	if city := s.Apply(models.City{}); city != s.City() {
+		t.Errorf("expected %+v, got %+v", s.City(), city)
+	}
+}
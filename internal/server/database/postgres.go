@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+const metersInKm = 1000 // meters in one kilometer, PostGIS measures geography in meters
+
+// OpenPostgres performs connecting to PostgreSQL by the libpq connection string
+// or URL. The extension PostGIS must be available on the server.
+func OpenPostgres(dsn string, timeout time.Duration) (*DB, error) {
+	return connect("pgx", config.DriverPostgres, dsn, timeout, config.Pool{})
+}
+
+// queryNearbyPostgres selects the cities at a distance until n km from the coordinates.
+// ST_DWithin uses the GiST index of the location of the cities.
+func (db *DB) queryNearbyPostgres(ctx context.Context, lat, lon float64, distance int) (*sqlx.Rows, error) {
+	return db.reader().QueryxContext(ctx, `SELECT cid, name, country, latitude, longitude
+	FROM cities WHERE ST_DWithin(location, ST_MakePoint($1, $2)::geography, $3)
+	ORDER BY cid`, lon, lat, float64(distance*metersInKm))
+}
+
+// findNearestPostgres selects the city nearest to the coordinates,
+// the operator <-> orders the cities by the GiST index (KNN search).
+func (db *DB) findNearestPostgres(ctx context.Context, lat, lon float64) (models.City, error) {
+	var city models.City
+
+	err := db.reader().GetContext(ctx, &city, `SELECT cid, name, name_ascii, country_code,
+	country, timezone, latitude, longitude FROM cities
+	ORDER BY location <-> ST_MakePoint($1, $2)::geography LIMIT 1`, lon, lat)
+
+	return city, err
+}
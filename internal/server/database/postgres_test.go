@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// envPostgresDSN is the environment variable with the connection string of the
+// local PostgreSQL with PostGIS for the integration tests, they are skipped if it is empty.
+const envPostgresDSN = "GEOSPACE_TEST_POSTGRES"
+
+func openPostgres(t *testing.T) *database.DB {
+	t.Helper()
+
+	dsn := os.Getenv(envPostgresDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", envPostgresDSN)
+	}
+
+	db, err := database.OpenPostgres(dsn, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	// every test starts with the empty database
+	_, err = db.SQLX.ExecContext(context.Background(),
+		`DROP TABLE IF EXISTS cities, users, user_tokens, suggestions, audit_log, import_checkpoints`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db)
+
+	return db
+}
+
+func TestPostgresCities(t *testing.T) {
+	testCitiesQueries(t, openPostgres(t))
+}
+
+func TestPostgresUsersAndSuggestions(t *testing.T) {
+	testUsersAndSuggestions(t, openPostgres(t))
+}
+
+func TestPostgresNewCityBySuggestion(t *testing.T) {
+	db, ctx := openPostgres(t), context.Background()
+
+	uid, err := db.CreateUser(ctx, "editor", "editor@example.com", "secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sid, err := db.CreateSuggestion(ctx, models.Suggestion{UID: uid, Name: "Wollongong",
+		CountryCode: "AU", Country: "Australia", Latitude: -34.424, Longitude: 150.89345})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	city, err := db.ApproveSuggestion(ctx, sid, uid, "")
+	if err != nil || city.ID == 0 {
+		t.Fatalf("ApproveSuggestion: %+v, %v", city, err)
+	}
+
+	// the new city is found by the index of the locations
+	nearby, err := db.FindObjectsNearByCoord(ctx, -33.86785, 151.20732, 100)
+	if err != nil || len(nearby) != 2 || nearby[1].ID != city.ID {
+		t.Errorf("FindObjectsNearByCoord: %v, %v", nearby, err)
+	}
+
+	if nearest, err := db.FindNearestCity(ctx, -34.5, 150.8); err != nil || nearest.ID != city.ID {
+		t.Errorf("FindNearestCity: %+v, %v", nearest, err)
+	}
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// roles of the database servers in the statistics of the pools
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// PoolStats contains the statistics of the pool of connections to the database server.
+type PoolStats struct {
+	Name              string `json:"name"`                 // primary or address of the replica
+	Role              string `json:"role"`                 // RolePrimary or RoleReplica
+	Healthy           bool   `json:"healthy"`              // false if the replica failed the health check
+	MaxOpen           int    `json:"max_open"`             // maximum number of open connections, 0 if unlimited
+	Open              int    `json:"open"`                 // number of open connections
+	InUse             int    `json:"in_use"`               // number of connections in use
+	Idle              int    `json:"idle"`                 // number of idle connections
+	WaitCount         int64  `json:"wait_count"`           // total number of connections waited for
+	WaitDuration      int64  `json:"wait_duration_ms"`     // total time waited for new connections in milliseconds
+	MaxIdleClosed     int64  `json:"max_idle_closed"`      // connections closed due to the limit of idle connections
+	MaxIdleTimeClosed int64  `json:"max_idle_time_closed"` // connections closed due to the idle time
+	MaxLifetimeClosed int64  `json:"max_lifetime_closed"`  // connections closed due to the lifetime
+}
+
+// replica is the read replica of the database server.
+type replica struct {
+	db      *sqlx.DB
+	name    string      // address of the replica
+	healthy atomic.Bool // result of the last health check
+}
+
+// openReplicas opens the pools of connections to the read replicas and starts
+// their health checks. Unavailable replicas do not prevent the start,
+// the reads are routed to the primary until the replicas are up.
+func (db *DB) openReplicas(driverName string, cfg config.Cfg) error {
+	for i, dsn := range cfg.ReplicaDSNs() {
+		conn, err := sqlx.Open(driverName, dsn)
+		if err != nil {
+			return err
+		}
+
+		setPool(conn, cfg.CfgDatabase.Pool)
+
+		db.replicas = append(db.replicas, &replica{
+			db:   conn,
+			name: replicaName(cfg.CfgDatabase.Replicas[i]),
+		})
+	}
+
+	db.checkReplicas()
+	db.done = make(chan struct{})
+
+	go db.watchReplicas(cfg.CfgDatabase.Pool.GetReplicaCheckInterval(), db.done)
+
+	return nil
+}
+
+// reader returns the database for the reads tolerant to the replication lag:
+// the healthy replicas in turn or the primary if all replicas are down.
+func (db *DB) reader() *sqlx.DB {
+	n := uint32(len(db.replicas))
+	if n == 0 {
+		return db.SQLX
+	}
+
+	start := db.next.Add(1)
+
+	for i := uint32(0); i < n; i++ {
+		if r := db.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return db.SQLX
+}
+
+// watchReplicas checks the replicas every interval until the storage is closed.
+func (db *DB) watchReplicas(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			db.checkReplicas()
+		}
+	}
+}
+
+// checkReplicas pings the replicas, the replica failed the ping
+// is excluded from the reads until the next successful check.
+func (db *DB) checkReplicas() {
+	for _, r := range db.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), db.timeout)
+		err := r.db.PingContext(ctx)
+		cancel()
+
+		r.healthy.Store(err == nil)
+	}
+}
+
+// closeReplicas stops the health checks and closes the replicas.
+func (db *DB) closeReplicas() {
+	if db.done != nil {
+		close(db.done)
+		db.done = nil
+	}
+
+	for _, r := range db.replicas {
+		r.db.Close()
+	}
+}
+
+// PoolStats returns the statistics of the pools of connections
+// to the primary and the replicas.
+func (db *DB) PoolStats() []PoolStats {
+	stats := []PoolStats{poolStats(db.SQLX, RolePrimary, RolePrimary, true)}
+
+	for _, r := range db.replicas {
+		stats = append(stats, poolStats(r.db, r.name, RoleReplica, r.healthy.Load()))
+	}
+
+	return stats
+}
+
+// poolStats converts the statistics of the pool of connections.
+func poolStats(db *sqlx.DB, name, role string, healthy bool) PoolStats {
+	s := db.Stats()
+
+	return PoolStats{
+		Name:              name,
+		Role:              role,
+		Healthy:           healthy,
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDuration:      s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
+
+// replicaName returns the address of the replica.
+func replicaName(r config.Replica) string {
+	if r.UnixSocket != "" {
+		return r.UnixSocket
+	}
+
+	host := r.Host
+	if host == "" {
+		host = config.DefaultHost
+	}
+
+	if r.Port == 0 {
+		return host
+	}
+
+	return fmt.Sprintf("%s:%d", host, r.Port)
+}
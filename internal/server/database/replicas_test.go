@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+func TestReplicaFailover(t *testing.T) {
+	ctx := context.Background()
+
+	open := func(name string, city models.City) *DB {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), name), time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = db.SQLX.NamedExecContext(ctx, `INSERT INTO cities (name, name_ascii, alternative_names,
+		country_code, country, timezone, latitude, longitude, created_at)
+		VALUES (:name, :name_ascii, :alternative_names, :country_code, :country, :timezone,
+		:latitude, :longitude, :created_at)`, &city)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return db
+	}
+
+	primary := open("primary.db", models.City{Name: "Rome", Country: "Italy"})
+	defer primary.Close()
+
+	// the replica differs from the primary to see where the reads are routed
+	replicaDB := open("replica.db", models.City{Name: "Milan", Country: "Italy"})
+	primary.replicas = []*replica{{db: replicaDB.SQLX, name: "replica"}}
+
+	primary.checkReplicas()
+
+	if city, err := primary.FindCity(ctx, "Milan"); err != nil || city.Name != "Milan" {
+		t.Errorf("read is not routed to the replica: %+v, %v", city, err)
+	}
+
+	if count, err := primary.CountCities(ctx); err != nil || count != 1 {
+		t.Errorf("CountCities: %d, %v", count, err)
+	}
+
+	// the replica is down
+	replicaDB.SQLX.Close()
+	primary.checkReplicas()
+
+	if city, err := primary.FindCity(ctx, "Rome"); err != nil || city.Name != "Rome" {
+		t.Errorf("read is not routed to the primary: %+v, %v", city, err)
+	}
+
+	stats := primary.PoolStats()
+	if len(stats) != 2 || stats[0].Role != RolePrimary || stats[1].Healthy {
+		t.Errorf("PoolStats: %+v", stats)
+	}
+}
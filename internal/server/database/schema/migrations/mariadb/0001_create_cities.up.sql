@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS cities (
+	cid INT auto_increment NULL,
+	name varchar(100) NULL,
+	name_ascii varchar(100) NULL,
+	alternative_names TEXT NULL,
+	country_code varchar(2) NULL,
+	country varchar(100) NULL,
+	timezone varchar(100) NULL,
+	latitude FLOAT NULL,
+	longitude FLOAT NULL,
+	created_at INT NULL,
+	CONSTRAINT cities_PK PRIMARY KEY (cid),
+	FULLTEXT KEY (name,alternative_names),
+	INDEX latitude_idx (latitude),
+	INDEX longitude_idx (longitude)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
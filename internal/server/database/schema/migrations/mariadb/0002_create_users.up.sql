@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS users (
+	uid INT auto_increment NULL,
+	name varchar(100) NULL,
+	email varchar(100) NULL,
+	password varchar(256) NULL,
+	created_at INT NULL,
+	CONSTRAINT users_PK PRIMARY KEY (uid),
+	FULLTEXT KEY (name,email)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE cities
+	DROP INDEX IF EXISTS source_external_idx,
+	DROP COLUMN IF EXISTS external_id,
+	DROP COLUMN IF EXISTS source;
@@ -0,0 +1,4 @@
+ALTER TABLE cities
+	ADD COLUMN IF NOT EXISTS source varchar(50) NOT NULL DEFAULT '' AFTER longitude,
+	ADD COLUMN IF NOT EXISTS external_id varchar(100) NOT NULL DEFAULT '' AFTER source,
+	ADD INDEX IF NOT EXISTS source_external_idx (source, external_id);
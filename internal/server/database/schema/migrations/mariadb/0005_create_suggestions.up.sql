@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS suggestions (
+	sid INT auto_increment NOT NULL,
+	uid INT NOT NULL,
+	cid INT NOT NULL DEFAULT 0,
+	name varchar(100) NOT NULL DEFAULT '',
+	name_ascii varchar(100) NOT NULL DEFAULT '',
+	alternative_names TEXT NOT NULL,
+	country_code varchar(2) NOT NULL DEFAULT '',
+	country varchar(100) NOT NULL DEFAULT '',
+	timezone varchar(100) NOT NULL DEFAULT '',
+	latitude FLOAT NOT NULL DEFAULT 0,
+	longitude FLOAT NOT NULL DEFAULT 0,
+	comment TEXT NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'pending',
+	reviewer_id INT NOT NULL DEFAULT 0,
+	review_comment TEXT NOT NULL,
+	created_at INT NOT NULL DEFAULT 0,
+	reviewed_at INT NOT NULL DEFAULT 0,
+	CONSTRAINT suggestions_PK PRIMARY KEY (sid),
+	INDEX status_idx (status),
+	INDEX uid_idx (uid)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
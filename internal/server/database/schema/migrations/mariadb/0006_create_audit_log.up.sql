@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	aid INT auto_increment NOT NULL,
+	uid INT NOT NULL DEFAULT 0,
+	action varchar(50) NOT NULL DEFAULT '',
+	entity varchar(50) NOT NULL DEFAULT '',
+	entity_id INT NOT NULL DEFAULT 0,
+	details TEXT NOT NULL,
+	created_at INT NOT NULL DEFAULT 0,
+	CONSTRAINT audit_log_PK PRIMARY KEY (aid),
+	INDEX entity_idx (entity, entity_id)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
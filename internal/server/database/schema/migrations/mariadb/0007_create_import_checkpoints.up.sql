@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+	source varchar(50) NOT NULL,
+	fingerprint varchar(100) NOT NULL DEFAULT '',
+	position INT NOT NULL DEFAULT 0,
+	stats TEXT NOT NULL,
+	updated_at INT NOT NULL DEFAULT 0,
+	CONSTRAINT import_checkpoints_PK PRIMARY KEY (source)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified TINYINT(1) NOT NULL DEFAULT 1 AFTER role;
+CREATE TABLE IF NOT EXISTS user_tokens (
+	token_hash char(64) NOT NULL,
+	purpose varchar(20) NOT NULL,
+	uid INT NOT NULL,
+	expires_at INT NOT NULL DEFAULT 0,
+	created_at INT NOT NULL DEFAULT 0,
+	CONSTRAINT user_tokens_PK PRIMARY KEY (token_hash),
+	KEY user_tokens_uid_idx (uid, purpose)
+)
+	ENGINE=InnoDB
+	DEFAULT CHARSET=utf8mb4
+	COLLATE=utf8mb4_general_ci;
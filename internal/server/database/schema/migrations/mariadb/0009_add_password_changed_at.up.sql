@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at INT NOT NULL DEFAULT 0 AFTER verified;
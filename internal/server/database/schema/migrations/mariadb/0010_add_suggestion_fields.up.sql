@@ -0,0 +1 @@
+ALTER TABLE suggestions ADD COLUMN IF NOT EXISTS fields varchar(200) NOT NULL DEFAULT '' AFTER longitude;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS import_checkpoints;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS suggestions;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS cities;
@@ -0,0 +1,85 @@
+-- Text columns compared by the queries have the citext type, so names are compared
+-- case-insensitively as by the collation of the tables in MariaDB. The location of the city
+-- is the generated geography column indexed by GiST for the nearby and nearest queries.
+
+CREATE EXTENSION IF NOT EXISTS citext;
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS cities (
+	cid SERIAL PRIMARY KEY,
+	name CITEXT NOT NULL DEFAULT '',
+	name_ascii CITEXT NOT NULL DEFAULT '',
+	alternative_names CITEXT NOT NULL DEFAULT '',
+	country_code CITEXT NOT NULL DEFAULT '',
+	country CITEXT NOT NULL DEFAULT '',
+	timezone TEXT NOT NULL DEFAULT '',
+	latitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	longitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	location GEOGRAPHY(Point, 4326) GENERATED ALWAYS AS
+		(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED,
+	source TEXT NOT NULL DEFAULT '',
+	external_id TEXT NOT NULL DEFAULT '',
+	created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS cities_name_idx ON cities (name);
+
+CREATE INDEX IF NOT EXISTS cities_source_external_idx ON cities (source, external_id);
+
+CREATE INDEX IF NOT EXISTS cities_location_idx ON cities USING GIST (location);
+
+CREATE TABLE IF NOT EXISTS users (
+	uid SERIAL PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT '',
+	email CITEXT NOT NULL DEFAULT '',
+	password TEXT NOT NULL DEFAULT '',
+	role TEXT NOT NULL DEFAULT 'user',
+	created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS users_email_idx ON users (email);
+
+CREATE TABLE IF NOT EXISTS suggestions (
+	sid SERIAL PRIMARY KEY,
+	uid INTEGER NOT NULL,
+	cid INTEGER NOT NULL DEFAULT 0,
+	name TEXT NOT NULL DEFAULT '',
+	name_ascii TEXT NOT NULL DEFAULT '',
+	alternative_names TEXT NOT NULL,
+	country_code TEXT NOT NULL DEFAULT '',
+	country TEXT NOT NULL DEFAULT '',
+	timezone TEXT NOT NULL DEFAULT '',
+	latitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	longitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	comment TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	reviewer_id INTEGER NOT NULL DEFAULT 0,
+	review_comment TEXT NOT NULL,
+	created_at BIGINT NOT NULL DEFAULT 0,
+	reviewed_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS suggestions_status_idx ON suggestions (status);
+
+CREATE INDEX IF NOT EXISTS suggestions_uid_idx ON suggestions (uid);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	aid SERIAL PRIMARY KEY,
+	uid INTEGER NOT NULL DEFAULT 0,
+	action TEXT NOT NULL DEFAULT '',
+	entity TEXT NOT NULL DEFAULT '',
+	entity_id INTEGER NOT NULL DEFAULT 0,
+	details TEXT NOT NULL,
+	created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id);
+
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+	source TEXT NOT NULL PRIMARY KEY,
+	fingerprint TEXT NOT NULL DEFAULT '',
+	position INTEGER NOT NULL DEFAULT 0,
+	stats TEXT NOT NULL,
+	updated_at BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+	token_hash TEXT PRIMARY KEY,
+	purpose TEXT NOT NULL,
+	uid INTEGER NOT NULL,
+	expires_at BIGINT NOT NULL DEFAULT 0,
+	created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS user_tokens_uid_idx ON user_tokens (uid, purpose);
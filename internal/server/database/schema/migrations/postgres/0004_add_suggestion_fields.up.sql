@@ -0,0 +1 @@
+ALTER TABLE suggestions ADD COLUMN IF NOT EXISTS fields TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,80 @@
+-- Names are compared case-insensitively as by the collation of the tables in MariaDB,
+-- the collation NOCASE folds only Latin letters.
+
+CREATE TABLE IF NOT EXISTS cities (
+	cid INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT COLLATE NOCASE NULL,
+	name_ascii TEXT COLLATE NOCASE NULL,
+	alternative_names TEXT COLLATE NOCASE NULL,
+	country_code TEXT COLLATE NOCASE NULL,
+	country TEXT COLLATE NOCASE NULL,
+	timezone TEXT NULL,
+	latitude REAL NULL,
+	longitude REAL NULL,
+	source TEXT NOT NULL DEFAULT '',
+	external_id TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NULL
+);
+
+CREATE INDEX IF NOT EXISTS cities_name_idx ON cities (name);
+
+CREATE INDEX IF NOT EXISTS cities_source_external_idx ON cities (source, external_id);
+
+CREATE INDEX IF NOT EXISTS cities_latitude_idx ON cities (latitude);
+
+CREATE INDEX IF NOT EXISTS cities_longitude_idx ON cities (longitude);
+
+CREATE TABLE IF NOT EXISTS users (
+	uid INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NULL,
+	email TEXT COLLATE NOCASE NULL,
+	password TEXT NULL,
+	role TEXT NOT NULL DEFAULT 'user',
+	created_at INTEGER NULL
+);
+
+CREATE INDEX IF NOT EXISTS users_email_idx ON users (email);
+
+CREATE TABLE IF NOT EXISTS suggestions (
+	sid INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid INTEGER NOT NULL,
+	cid INTEGER NOT NULL DEFAULT 0,
+	name TEXT NOT NULL DEFAULT '',
+	name_ascii TEXT NOT NULL DEFAULT '',
+	alternative_names TEXT NOT NULL,
+	country_code TEXT NOT NULL DEFAULT '',
+	country TEXT NOT NULL DEFAULT '',
+	timezone TEXT NOT NULL DEFAULT '',
+	latitude REAL NOT NULL DEFAULT 0,
+	longitude REAL NOT NULL DEFAULT 0,
+	comment TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	reviewer_id INTEGER NOT NULL DEFAULT 0,
+	review_comment TEXT NOT NULL,
+	created_at INTEGER NOT NULL DEFAULT 0,
+	reviewed_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS suggestions_status_idx ON suggestions (status);
+
+CREATE INDEX IF NOT EXISTS suggestions_uid_idx ON suggestions (uid);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	aid INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid INTEGER NOT NULL DEFAULT 0,
+	action TEXT NOT NULL DEFAULT '',
+	entity TEXT NOT NULL DEFAULT '',
+	entity_id INTEGER NOT NULL DEFAULT 0,
+	details TEXT NOT NULL,
+	created_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id);
+
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+	source TEXT NOT NULL PRIMARY KEY,
+	fingerprint TEXT NOT NULL DEFAULT '',
+	position INTEGER NOT NULL DEFAULT 0,
+	stats TEXT NOT NULL,
+	updated_at INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1 @@
+ALTER TABLE suggestions DROP COLUMN fields;
@@ -0,0 +1 @@
+ALTER TABLE suggestions ADD COLUMN fields TEXT NOT NULL DEFAULT '';
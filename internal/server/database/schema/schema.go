@@ -1,43 +1,181 @@
 // Package schema is the structure of a database described.
+//
+// The schema is changed by the numbered migrations embedded into the application.
+// Migrations of every driver are in the directory "migrations/<driver>", the files
+// are named "<version>_<name>.up.sql" and "<version>_<name>.down.sql". Versions start
+// from 1 without gaps. Statements of the file are separated by the semicolon
+// at the end of the line.
 package schema
 
-// City represents command SQL for creating a cities table.
-var City = `
-	CREATE TABLE cities (
-		cid INT auto_increment NULL,
-		name varchar(100) NULL,
-		name_ascii varchar(100) NULL,
-		alternative_names TEXT NULL,
-		country_code varchar(2) NULL,
-		country varchar(100) NULL,
-		timezone varchar(100) NULL,
-		latitude FLOAT NULL,
-		longitude FLOAT NULL,
-		created_at INT NULL,
-		CONSTRAINT cities_PK PRIMARY KEY (cid),
-		FULLTEXT KEY (name,alternative_names),
-		INDEX latitude_idx (latitude),
-		INDEX longitude_idx (longitude)
-	)
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
 
-		ENGINE=InnoDB
-		DEFAULT CHARSET=utf8mb4
-		COLLATE=utf8mb4_general_ci;
-`
-
-// User represents command SQL for creating a users table.
-var User = `
-	CREATE TABLE users (
-		uid INT auto_increment NULL,
-		name varchar(100) NULL,
-		email varchar(100) NULL,
-		password varchar(256) NULL,
-		created_at INT NULL,
-		CONSTRAINT users_PK PRIMARY KEY (uid),
-		FULLTEXT KEY (name,email)
+//go:embed migrations
+var migrations embed.FS
+
+// Migration is the numbered change of the schema.
+type Migration struct {
+	Name    string   // name of the migration
+	Up      []string // statements applying the change
+	Down    []string // statements reverting the change
+	Version int      // version of the schema after the migration
+}
+
+// MigrationsTable represents commands SQL for creating the table of the applied
+// migrations by the drivers of the database.
+var MigrationsTable = map[string]string{
+	"mariadb": `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT NOT NULL,
+		name varchar(255) NOT NULL DEFAULT '',
+		applied_at INT NOT NULL DEFAULT 0,
+		CONSTRAINT schema_migrations_PK PRIMARY KEY (version)
 	)
 
 		ENGINE=InnoDB
 		DEFAULT CHARSET=utf8mb4
 		COLLATE=utf8mb4_general_ci;
-`
+	`,
+	"postgres": `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		applied_at BIGINT NOT NULL DEFAULT 0
+	)`,
+	"sqlite": `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		applied_at INTEGER NOT NULL DEFAULT 0
+	)`,
+}
+
+// Migrations returns the migrations of the driver of the database ordered by version.
+func Migrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for the driver %q", driver)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: want suffix .up.sql or .down.sql", name)
+		}
+
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: want name <version>_<name>", name)
+		}
+
+		b, err := migrations.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: base}
+			byVersion[version] = m
+		}
+
+		if m.Name != base {
+			return nil, fmt.Errorf("migration %s: version %d is used by %s", name, version, m.Name)
+		}
+
+		if title == "" {
+			return nil, fmt.Errorf("migration %s: name is empty", name)
+		}
+
+		if direction == "up" {
+			m.Up = SplitStatements(string(b))
+		} else {
+			m.Down = SplitStatements(string(b))
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		list = append(list, *m)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	for i, m := range list {
+		switch {
+		case m.Version != i+1:
+			return nil, fmt.Errorf("migration %s: version %d is missing", m.Name, i+1)
+		case len(m.Up) == 0 || len(m.Down) == 0:
+			return nil, fmt.Errorf("migration %s: both up and down statements are required", m.Name)
+		}
+	}
+
+	return list, nil
+}
+
+// SplitStatements splits the script into statements separated
+// by the semicolon at the end of the line. Empty statements are skipped.
+func SplitStatements(script string) []string {
+	statements := make([]string, 0)
+
+	var current strings.Builder
+
+	for _, line := range strings.SplitAfter(script, "\n") {
+		current.WriteString(line)
+
+		if !strings.HasSuffix(strings.TrimSpace(line), ";") {
+			continue
+		}
+
+		if statement := strings.TrimSpace(current.String()); statement != ";" {
+			statements = append(statements, statement)
+		}
+
+		current.Reset()
+	}
+
+	if statement := strings.TrimSpace(current.String()); statement != "" && !onlyComments(statement) {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
+
+// cutDirection cuts the suffix ".up.sql" or ".down.sql" of the file name.
+func cutDirection(name string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+
+	return "", "", false
+}
+
+// onlyComments checks whether the text contains only the comments "--".
+func onlyComments(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+
+	return true
+}
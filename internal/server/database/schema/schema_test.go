@@ -0,0 +1,49 @@
+package schema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/database/schema"
+)
+
+func TestMigrations(t *testing.T) {
+	for _, driver := range []string{"mariadb", "postgres", "sqlite"} {
+		migrations, err := schema.Migrations(driver)
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+
+		if len(migrations) == 0 || migrations[0].Version != 1 || !strings.HasPrefix(migrations[0].Name, "0001_") {
+			t.Errorf("%s: unexpected migrations %v", driver, migrations)
+		}
+
+		if _, ok := schema.MigrationsTable[driver]; !ok {
+			t.Errorf("%s: no table of the migrations", driver)
+		}
+	}
+
+	if _, err := schema.Migrations("memory"); err == nil {
+		t.Error("want error for the driver without migrations")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+	name TEXT DEFAULT ';'
+);
+
+DROP TABLE b;;
+-- trailing comment
+`
+	want := []string{
+		"-- comment\nCREATE TABLE a (\n\tname TEXT DEFAULT ';'\n);",
+		"DROP TABLE b;;",
+	}
+
+	if got := schema.SplitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// sqlitePragmas are set on every connection to the SQLite database: the journal
+// lets readers work during writes, writers wait for the lock instead of failing.
+var sqlitePragmas = []string{
+	"journal_mode(WAL)",
+	"busy_timeout(5000)",
+}
+
+// OpenSQLite performs opening the embedded SQLite database in the file,
+// the file is created if it does not exist. Use ":memory:" for a temporary
+// database, it lives while the storage is opened.
+func OpenSQLite(path string, timeout time.Duration) (*DB, error) {
+	// transactions take the write lock at once, so they are never upgraded
+	params := url.Values{}
+	params.Set("_txlock", "immediate")
+
+	for _, pragma := range sqlitePragmas {
+		params.Add("_pragma", pragma)
+	}
+
+	db, err := sqlx.Connect("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// every connection to ":memory:" opens its own database
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	return &DB{
+		SQLX:    db,
+		driver:  config.DriverSQLite,
+		timeout: timeout,
+	}, nil
+}
@@ -0,0 +1,272 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+var testCities = []models.City{
+	{Name: "Rome", AlternativeNames: "Roma,Рим,", CountryCode: "IT", Country: "Italy", Latitude: 41.89193, Longitude: 12.51133, ExternalID: "1"},
+	{Name: "Milan", AlternativeNames: "Milano,", CountryCode: "IT", Country: "Italy", Latitude: 45.46427, Longitude: 9.18951, ExternalID: "2"},
+	{Name: "Rome", CountryCode: "US", Country: "United States", Latitude: 34.25704, Longitude: -85.16467, ExternalID: "3"},
+	{Name: "Sydney", CountryCode: "AU", Country: "Australia", Latitude: -33.86785, Longitude: 151.20732, ExternalID: "4"},
+	{Name: "Newcastle", CountryCode: "AU", Country: "Australia", Latitude: -32.92953, Longitude: 151.7801, ExternalID: "5"},
+}
+
+func openSQLite(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "geospace.db"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	migrateTestDB(t, db)
+
+	return db
+}
+
+// migrateTestDB creates the schema and imports the test cities.
+func migrateTestDB(t *testing.T, db *database.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	// migration is idempotent
+	for i := 0; i < 2; i++ {
+		if err := db.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sync, err := db.BeginCitySync(ctx, "test", database.SyncOptions{Resumable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sync.Close()
+
+	if err := sync.Write(testCities, len(testCities)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sync.Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteCities(t *testing.T) {
+	testCitiesQueries(t, openSQLite(t))
+}
+
+func TestSQLiteLargeBatch(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	// the batch needs more placeholders than one query allows
+	cities := make([]models.City, 5000)
+	for i := range cities {
+		cities[i] = models.City{Name: fmt.Sprintf("City %d", i), CountryCode: "IT", Country: "Italy",
+			Latitude: float64(i%180) - 89, Longitude: 10, ExternalID: strconv.Itoa(i)}
+	}
+
+	sync, err := db.BeginCitySync(ctx, "large", database.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sync.Close()
+
+	if err := sync.Write(cities, len(cities)); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats, err := sync.Finish(); err != nil || stats.Inserted != len(cities) {
+		t.Fatalf("Finish: %+v, %v", stats, err)
+	}
+
+	if count, err := db.CountCities(ctx); err != nil || count != len(cities)+len(testCities) {
+		t.Errorf("CountCities: %d, %v", count, err)
+	}
+}
+
+func TestSQLiteUsersAndSuggestions(t *testing.T) {
+	testUsersAndSuggestions(t, openSQLite(t))
+}
+
+// testCitiesQueries checks the queries of the cities, the test cities must be imported.
+func testCitiesQueries(t *testing.T, db *database.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	city, err := db.FindCity(ctx, "Рим, it")
+	if err != nil || city.Name != "Rome" || city.CountryCode != "IT" {
+		t.Errorf("FindCity: %+v, %v", city, err)
+	}
+
+	var ambiguous *database.AmbiguousCityError
+	if _, err := db.ResolveCity(ctx, "rome"); !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Errorf("ResolveCity: want ambiguous error, got %v", err)
+	}
+
+	if city, err := db.ResolveCity(ctx, "Rome, US"); err != nil || city.Country != "United States" {
+		t.Errorf("ResolveCity by country code: %+v, %v", city, err)
+	}
+
+	found, err := db.FindCities(ctx, []string{"Milano", "Atlantis"})
+	if err != nil || len(found) != 1 || found["Milano"].Name != "Milan" {
+		t.Errorf("FindCities: %v, %v", found, err)
+	}
+
+	// the southern and eastern hemispheres
+	nearby, err := db.FindObjectsNearByCoord(ctx, -33.86785, 151.20732, 300)
+	if err != nil || len(nearby) != 2 {
+		t.Errorf("FindObjectsNearByCoord: %v, %v", nearby, err)
+	}
+
+	if city, err := db.FindNearestCity(ctx, 45.4, 9.2); err != nil || city.Name != "Milan" {
+		t.Errorf("FindNearestCity: %+v, %v", city, err)
+	}
+
+	countries, err := db.ListCountries(ctx)
+	if err != nil || len(countries) != 3 || countries[0] != (models.Country{Code: "AU", Name: "Australia"}) {
+		t.Errorf("ListCountries: %v, %v", countries, err)
+	}
+
+	if db.HasCheckpoint(ctx, "test") {
+		t.Error("checkpoint is kept after the finished import")
+	}
+}
+
+// testUserTokens checks the tokens sent by email and the verification of the user.
+func testUserTokens(t *testing.T, db *database.DB, uid int) {
+	t.Helper()
+
+	ctx, now := context.Background(), time.Now().Unix()
+
+	for _, token := range []models.UserToken{
+		{Hash: "expired", Purpose: models.TokenResetPassword, UID: uid, ExpiresAt: now - 1, CreatedAt: now - 3600},
+		{Hash: "previous", Purpose: models.TokenVerifyEmail, UID: uid, ExpiresAt: now + 3600, CreatedAt: now},
+		{Hash: "current", Purpose: models.TokenVerifyEmail, UID: uid, ExpiresAt: now + 3600, CreatedAt: now},
+	} {
+		if err := db.CreateUserToken(ctx, token); err != nil {
+			t.Fatalf("CreateUserToken %s: %v", token.Hash, err)
+		}
+	}
+
+	// the previous token of the purpose and the expired token are removed
+	for _, hash := range []string{"previous", "expired"} {
+		if _, err := db.ConsumeUserToken(ctx, models.TokenVerifyEmail, hash); !errors.Is(err, database.ErrTokenNotFound) {
+			t.Errorf("ConsumeUserToken %s: want ErrTokenNotFound, got %v", hash, err)
+		}
+	}
+
+	if _, err := db.ConsumeUserToken(ctx, models.TokenResetPassword, "current"); !errors.Is(err, database.ErrTokenNotFound) {
+		t.Errorf("ConsumeUserToken of another purpose: want ErrTokenNotFound, got %v", err)
+	}
+
+	if id, err := db.ConsumeUserToken(ctx, models.TokenVerifyEmail, "current"); err != nil || id != uid {
+		t.Fatalf("ConsumeUserToken: %d, %v", id, err)
+	}
+
+	// the token is used once
+	if _, err := db.ConsumeUserToken(ctx, models.TokenVerifyEmail, "current"); !errors.Is(err, database.ErrTokenNotFound) {
+		t.Errorf("ConsumeUserToken again: want ErrTokenNotFound, got %v", err)
+	}
+
+	if err := db.VerifyUser(ctx, uid); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, err := db.GetUserByID(ctx, uid); err != nil || !user.Verified {
+		t.Errorf("user is not verified: %+v, %v", user, err)
+	}
+
+	if err := db.ChangePassword(ctx, uid, "changed", 1700000000); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, err := db.GetUserByID(ctx, uid); err != nil || user.Password != "changed" || user.PasswordChangedAt != 1700000000 {
+		t.Errorf("password is not changed: %+v, %v", user, err)
+	}
+}
+
+// testUsersAndSuggestions checks the users and the moderation of the suggestions.
+func testUsersAndSuggestions(t *testing.T, db *database.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	uid, err := db.CreateUser(ctx, "user", "user@example.com", "secret", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.CreateUser(ctx, "user", "USER@example.com", "secret", true); !errors.Is(err, database.ErrUserAlreadyExists) {
+		t.Errorf("CreateUser: want ErrUserAlreadyExists, got %v", err)
+	}
+
+	if user, err := db.GetUserByID(ctx, uid); err != nil || user.Email != "user@example.com" || user.Role != models.RoleUser {
+		t.Errorf("GetUserByID: %+v, %v", user, err)
+	}
+
+	testUserTokens(t, db, uid)
+
+	milan, err := db.FindCity(ctx, "Milan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := models.Suggestion{UID: uid, CityID: milan.ID, Name: "Milan", AlternativeNames: "Milano,Милан,",
+		CountryCode: "IT", Country: "Italy", Latitude: milan.Latitude, Longitude: milan.Longitude}
+
+	sid, err := db.CreateSuggestion(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	city, err := db.ApproveSuggestion(ctx, sid, uid, "ok")
+	if err != nil || city.ID != milan.ID {
+		t.Fatalf("ApproveSuggestion: %+v, %v", city, err)
+	}
+
+	if city, err := db.FindCity(ctx, "Милан"); err != nil || city.ID != milan.ID {
+		t.Errorf("approved suggestion is not applied: %+v, %v", city, err)
+	}
+
+	if err := db.RejectSuggestion(ctx, sid, uid, ""); !errors.Is(err, database.ErrSuggestionReviewed) {
+		t.Errorf("RejectSuggestion: want ErrSuggestionReviewed, got %v", err)
+	}
+
+	// the suggestion based on the row before the approval keeps the approved alternative names
+	stale := milan
+	stale.Name = "Milano"
+
+	sid, err = db.CreateSuggestion(ctx, models.Suggestion{UID: uid, CityID: milan.ID, Name: stale.Name,
+		AlternativeNames: stale.AlternativeNames, CountryCode: stale.CountryCode, Country: stale.Country,
+		Latitude: stale.Latitude, Longitude: stale.Longitude, Fields: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	city, err = db.ApproveSuggestion(ctx, sid, uid, "")
+	if err != nil || city.Name != "Milano" || city.AlternativeNames != "Milano,Милан," {
+		t.Errorf("ApproveSuggestion of the stale suggestion: %+v, %v", city, err)
+	}
+
+	records, err := db.ListAudit(ctx, database.AuditEntityCity, milan.ID)
+	if err != nil || len(records) != 2 || records[0].Action != database.AuditSuggestionApproved {
+		t.Errorf("ListAudit: %v, %v", records, err)
+	}
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// Cities is the repository of the cities.
+type Cities interface {
+	// GetCity returns the city by id.
+	GetCity(ctx context.Context, cid int) (models.City, error)
+	// CountCities returns quantity of the cities.
+	CountCities(ctx context.Context) (int, error)
+	// FindCity returns the city by name, the name can contain the country: "Rome, Italy".
+	FindCity(ctx context.Context, cityRaw string) (models.City, error)
+	// FindCities returns the cities by names, names of the cities not found are missing.
+	FindCities(ctx context.Context, citiesRaw []string) (map[string]models.City, error)
+	// ResolveCity returns the city by name, ErrCityNotFound if there is no such city
+	// and AmbiguousCityError if the name matches several cities.
+	ResolveCity(ctx context.Context, cityRaw string) (models.City, error)
+	// FindObjectsNearByName returns the city and the cities at a distance until n km from it.
+	FindObjectsNearByName(ctx context.Context, departure string, distance int) (models.City, []models.City, error)
+	// FindObjectsNearByCoord returns the cities at a distance until n km from the coordinates.
+	FindObjectsNearByCoord(ctx context.Context, lat, lon float64, distance int) ([]models.City, error)
+	// EachObjectNearByCoord calls fn for every city at a distance until n km from the coordinates.
+	EachObjectNearByCoord(ctx context.Context, lat, lon float64, distance int, fn func(models.City) error) error
+	// FindNearestCity returns the city nearest to the coordinates.
+	FindNearestCity(ctx context.Context, lat, lon float64) (models.City, error)
+	// ListCountries returns the countries of the cities ordered by name.
+	ListCountries(ctx context.Context) ([]models.Country, error)
+	// ExportCities calls fn for every city selected by the filter ordered by id.
+	ExportCities(ctx context.Context, filter CityFilter, fn func(models.City) error) error
+}
+
+// Users is the repository of the users.
+type Users interface {
+	// CreateUser creates the user and returns its id, ErrUserAlreadyExists if the email is taken.
+	// The user is not verified until the email is confirmed.
+	CreateUser(ctx context.Context, name, email, password string, verified bool) (int, error)
+	// GetUser returns the user by email.
+	GetUser(ctx context.Context, email string) (models.User, error)
+	// GetUserByID returns the user by id.
+	GetUserByID(ctx context.Context, uid int) (models.User, error)
+	// UpdatePassword replaces the encrypted password of the user.
+	UpdatePassword(ctx context.Context, uid int, password string) error
+	// ChangePassword replaces the encrypted password of the user changed at the time
+	// in unix seconds, the tokens of the user issued before it are revoked.
+	ChangePassword(ctx context.Context, uid int, password string, changedAt int64) error
+	// VerifyUser marks the email of the user as confirmed.
+	VerifyUser(ctx context.Context, uid int) error
+	// CreateUserToken stores the token sent to the user by email, the previous
+	// tokens of the user with the same purpose are removed.
+	CreateUserToken(ctx context.Context, token models.UserToken) error
+	// ConsumeUserToken removes the token by its hash and returns the id of its user,
+	// ErrTokenNotFound if there is no such token of the purpose or it is expired.
+	ConsumeUserToken(ctx context.Context, purpose, hash string) (int, error)
+}
+
+// Suggestions is the repository of the suggestions of the users and the audit trail.
+type Suggestions interface {
+	// CreateSuggestion creates the pending suggestion and returns its id.
+	CreateSuggestion(ctx context.Context, s models.Suggestion) (int, error)
+	// GetSuggestion returns the suggestion by id, ErrSuggestionNotFound if there is no such one.
+	GetSuggestion(ctx context.Context, sid int) (models.Suggestion, error)
+	// ListSuggestions returns the suggestions by status (any if empty) of the user (all if 0).
+	ListSuggestions(ctx context.Context, status string, uid int) ([]models.Suggestion, error)
+	// ApproveSuggestion applies the suggestion to the cities and returns the city after changes.
+	ApproveSuggestion(ctx context.Context, sid, reviewerID int, comment string) (models.City, error)
+	// RejectSuggestion marks the suggestion as rejected.
+	RejectSuggestion(ctx context.Context, sid, reviewerID int, comment string) error
+	// ListAudit returns the records of the audit trail for the entity.
+	ListAudit(ctx context.Context, entity string, entityID int) ([]models.Audit, error)
+}
+
+// CityImporter synchronizes the cities with the datasets.
+type CityImporter interface {
+	// BeginCitySync starts the synchronization of the cities of the source.
+	BeginCitySync(ctx context.Context, source string, opts SyncOptions) (CitySyncer, error)
+	// HasCheckpoint checks whether there is the interrupted import of the source.
+	HasCheckpoint(ctx context.Context, source string) bool
+}
+
+// CitySyncer writes the cities of the dataset by batches, see CitySync.
+type CitySyncer interface {
+	// Position returns quantity of the records processed before the checkpoint.
+	Position() int
+	// Stats returns the current results of the synchronization.
+	Stats() ImportStats
+	// Seen marks the city as present in the dataset without writing it.
+	Seen(externalID string)
+	// Skip counts the invalid record of the dataset.
+	Skip(externalID string)
+	// Write saves the batch of the cities.
+	Write(cities []models.City, position int) error
+	// Finish deletes the cities absent in the dataset and commits the changes.
+	Finish() (ImportStats, error)
+	// Close rolls back the not finished changes.
+	Close() error
+}
+
+// Migrator changes the versioned schema of the storage.
+type Migrator interface {
+	// MigrateUp applies the pending migrations and returns them.
+	MigrateUp(ctx context.Context) ([]MigrationState, error)
+	// MigrateDown reverts the latest applied migrations and returns them.
+	MigrateDown(ctx context.Context, steps int) ([]MigrationState, error)
+	// MigrationStatus returns the version of the schema and the states of the migrations.
+	MigrationStatus(ctx context.Context) (SchemaStatus, error)
+}
+
+// PoolReporter reports the statistics of the pools of connections to the database servers.
+type PoolReporter interface {
+	// PoolStats returns the statistics of the primary and the replicas.
+	PoolStats() []PoolStats
+}
+
+// Store is the storage of all data of the application.
+type Store interface {
+	Cities
+	Users
+	Suggestions
+	CityImporter
+
+	// Migrate creates or upgrades the schema of the storage, ErrSchemaNewer
+	// if the schema is newer than the application knows.
+	Migrate(ctx context.Context) error
+	// Ping checks the connection to the storage.
+	Ping(ctx context.Context) error
+	// Close closes the storage.
+	Close() error
+}
+
+// check that DB implements the interfaces
+var (
+	_ Store        = (*DB)(nil)
+	_ Migrator     = (*DB)(nil)
+	_ PoolReporter = (*DB)(nil)
+	_ CitySyncer   = (*CitySync)(nil)
+)
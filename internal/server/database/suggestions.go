@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// actions of the audit trail
+const (
+	AuditSuggestionApproved = "suggestion_approved"
+	AuditSuggestionRejected = "suggestion_rejected"
+	AuditEntityCity         = "city"
+	SourceSuggestions       = "suggestions" // source of the cities added by suggestions
+)
+
+// typical errors
+var (
+	ErrSuggestionNotFound = errors.New("suggestion is not found")
+	ErrSuggestionReviewed = errors.New("suggestion has already been reviewed")
+	ErrCityNotFound       = errors.New("city is not found")
+)
+
+// CreateSuggestion performs a create suggestion of the user to database
+// and returns id of the created suggestion.
+func (db *DB) CreateSuggestion(ctx context.Context, s models.Suggestion) (int, error) {
+	ctx, cancel := db.queryContext(ctx, "create_suggestion")
+	defer cancel()
+
+	s.Status = models.SuggestionPending
+	s.CreatedAt = time.Now().Unix()
+
+	return db.insertNamed(ctx, db.SQLX, `INSERT INTO suggestions (uid, cid, name, name_ascii,
+	alternative_names, country_code, country, timezone, latitude, longitude,
+	fields, comment, status, review_comment, created_at)
+	VALUES (:uid, :cid, :name, :name_ascii, :alternative_names, :country_code,
+	:country, :timezone, :latitude, :longitude, :fields, :comment, :status, '', :created_at)`, "sid", &s)
+}
+
+// GetSuggestion provides a get suggestion by id from database.
+func (db *DB) GetSuggestion(ctx context.Context, sid int) (models.Suggestion, error) {
+	ctx, cancel := db.queryContext(ctx, "get_suggestion")
+	defer cancel()
+
+	var s models.Suggestion
+	err := db.SQLX.GetContext(ctx, &s, db.SQLX.Rebind(`SELECT * FROM suggestions WHERE sid = ?`), sid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrSuggestionNotFound
+	}
+
+	return s, err
+}
+
+// ListSuggestions provides a get list of suggestions filtered by status.
+// If status is empty suggestions with any status are returned,
+// if uid is 0 suggestions of all users are returned.
+func (db *DB) ListSuggestions(ctx context.Context, status string, uid int) ([]models.Suggestion, error) {
+	ctx, cancel := db.queryContext(ctx, "list_suggestions")
+	defer cancel()
+
+	suggestions := make([]models.Suggestion, 0)
+
+	err := db.SQLX.SelectContext(ctx, &suggestions, db.SQLX.Rebind(`SELECT * FROM suggestions
+	WHERE (? = '' OR status = ?) AND (? = 0 OR uid = ?)
+	ORDER BY sid`), status, status, uid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// ApproveSuggestion applies the suggestion to the cities table, marks it as approved
+// and records the action in the audit trail. All changes are made in one transaction.
+// The timeout of the queries limits the whole transaction. Returns the city after changes.
+func (db *DB) ApproveSuggestion(ctx context.Context, sid, reviewerID int, comment string) (models.City, error) {
+	ctx, cancel := db.queryContext(ctx, "approve_suggestion")
+	defer cancel()
+
+	var city models.City
+
+	tx, err := db.SQLX.BeginTxx(ctx, nil)
+	if err != nil {
+		return city, err
+	}
+
+	defer tx.Rollback()
+
+	s, err := db.lockPendingSuggestion(ctx, tx, sid)
+	if err != nil {
+		return city, err
+	}
+
+	var current models.City
+	if s.CityID != 0 {
+		err = tx.GetContext(ctx, &current, tx.Rebind(`SELECT cid, name, name_ascii, alternative_names,
+		country_code, country, timezone, latitude, longitude, source, external_id, created_at
+		FROM cities WHERE cid = ?`+db.forUpdate()), s.CityID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return city, ErrCityNotFound
+		}
+
+		if err != nil {
+			return city, err
+		}
+	}
+
+	changes := s.Diff(current)
+	now := time.Now().Unix()
+	city = s.Apply(current)
+
+	if s.CityID == 0 {
+		city.CreatedAt = now
+		city.Source = SourceSuggestions
+		city.ExternalID = strconv.Itoa(sid)
+
+		city.ID, err = db.insertNamed(ctx, tx, `INSERT INTO cities (name, name_ascii,
+		alternative_names, country_code, country, timezone, latitude, longitude,
+		source, external_id, created_at)
+		VALUES (:name, :name_ascii, :alternative_names, :country_code, :country,
+		:timezone, :latitude, :longitude, :source, :external_id, :created_at)`, "cid", &city)
+		if err != nil {
+			return city, err
+		}
+	} else {
+		_, err = tx.NamedExecContext(ctx, `UPDATE cities SET name = :name, name_ascii = :name_ascii,
+		alternative_names = :alternative_names, country_code = :country_code,
+		country = :country, timezone = :timezone, latitude = :latitude,
+		longitude = :longitude WHERE cid = :cid`, &city)
+		if err != nil {
+			return city, err
+		}
+	}
+
+	err = reviewSuggestion(ctx, tx, sid, reviewerID, models.SuggestionApproved, comment, now)
+	if err != nil {
+		return city, err
+	}
+
+	details := struct {
+		SuggestionID int                  `json:"suggestion_id"`
+		Comment      string               `json:"comment,omitempty"`
+		Changes      []models.FieldChange `json:"changes"`
+	}{
+		SuggestionID: sid,
+		Comment:      comment,
+		Changes:      changes,
+	}
+
+	err = addAudit(ctx, tx, reviewerID, AuditSuggestionApproved, AuditEntityCity, city.ID, details, now)
+	if err != nil {
+		return city, err
+	}
+
+	return city, tx.Commit()
+}
+
+// RejectSuggestion marks the suggestion as rejected
+// and records the action in the audit trail.
+func (db *DB) RejectSuggestion(ctx context.Context, sid, reviewerID int, comment string) error {
+	ctx, cancel := db.queryContext(ctx, "reject_suggestion")
+	defer cancel()
+
+	tx, err := db.SQLX.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	s, err := db.lockPendingSuggestion(ctx, tx, sid)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+
+	err = reviewSuggestion(ctx, tx, sid, reviewerID, models.SuggestionRejected, comment, now)
+	if err != nil {
+		return err
+	}
+
+	details := struct {
+		SuggestionID int    `json:"suggestion_id"`
+		Comment      string `json:"comment,omitempty"`
+	}{
+		SuggestionID: sid,
+		Comment:      comment,
+	}
+
+	err = addAudit(ctx, tx, reviewerID, AuditSuggestionRejected, AuditEntityCity, s.CityID, details, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListAudit provides a get records of the audit trail for the entity.
+func (db *DB) ListAudit(ctx context.Context, entity string, entityID int) ([]models.Audit, error) {
+	ctx, cancel := db.queryContext(ctx, "list_audit")
+	defer cancel()
+
+	records := make([]models.Audit, 0)
+
+	err := db.SQLX.SelectContext(ctx, &records, db.SQLX.Rebind(`SELECT * FROM audit_log
+	WHERE entity = ? AND entity_id = ? ORDER BY aid`), entity, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// lockPendingSuggestion selects the suggestion for update
+// and returns error if it is not pending.
+func (db *DB) lockPendingSuggestion(ctx context.Context, tx *sqlx.Tx, sid int) (models.Suggestion, error) {
+	var s models.Suggestion
+
+	err := tx.GetContext(ctx, &s, tx.Rebind(`SELECT * FROM suggestions WHERE sid = ?`+db.forUpdate()), sid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrSuggestionNotFound
+	}
+
+	if err != nil {
+		return s, err
+	}
+
+	if s.Status != models.SuggestionPending {
+		return s, ErrSuggestionReviewed
+	}
+
+	return s, nil
+}
+
+// reviewSuggestion sets status and reviewer of the suggestion.
+func reviewSuggestion(ctx context.Context, tx *sqlx.Tx, sid, reviewerID int, status, comment string, now int64) error {
+	_, err := tx.ExecContext(ctx, tx.Rebind(`UPDATE suggestions SET status = ?, reviewer_id = ?,
+	review_comment = ?, reviewed_at = ? WHERE sid = ?`),
+		status, reviewerID, comment, now, sid)
+
+	return err
+}
+
+// addAudit inserts a record to the audit trail.
+func addAudit(ctx context.Context, tx *sqlx.Tx, uid int, action, entity string, entityID int, details any, now int64) error {
+	b, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, tx.Rebind(`INSERT INTO audit_log (uid, action, entity, entity_id, details, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)`), uid, action, entity, entityID, string(b), now)
+
+	return err
+}
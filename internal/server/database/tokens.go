@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// ErrTokenNotFound is returned if the token does not exist, is expired or is already used.
+var ErrTokenNotFound = errors.New("token is invalid or expired")
+
+// CreateUserToken stores the token sent to the user by email. The previous tokens
+// of the user with the same purpose and the expired tokens of all users are removed.
+func (db *DB) CreateUserToken(ctx context.Context, token models.UserToken) error {
+	ctx, cancel := db.queryContext(ctx, "create_user_token")
+	defer cancel()
+
+	tx, err := db.SQLX.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, tx.Rebind(`DELETE FROM user_tokens
+	WHERE (uid = ? AND purpose = ?) OR expires_at < ?`), token.UID, token.Purpose, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx, `INSERT INTO user_tokens (token_hash, purpose, uid, expires_at, created_at)
+	VALUES (:token_hash, :purpose, :uid, :expires_at, :created_at)`, &token)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeUserToken removes the token by its hash and returns the id of its user,
+// ErrTokenNotFound if there is no such token of the purpose or it is expired.
+// The token is used once: of the concurrent requests only one removes it.
+func (db *DB) ConsumeUserToken(ctx context.Context, purpose, hash string) (int, error) {
+	ctx, cancel := db.queryContext(ctx, "consume_user_token")
+	defer cancel()
+
+	var token models.UserToken
+	err := db.SQLX.GetContext(ctx, &token, db.SQLX.Rebind(`SELECT token_hash, purpose, uid, expires_at, created_at
+	FROM user_tokens WHERE token_hash = ? AND purpose = ?`), hash, purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTokenNotFound
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := db.SQLX.ExecContext(ctx, db.SQLX.Rebind(`DELETE FROM user_tokens WHERE token_hash = ?`), hash)
+	if err != nil {
+		return 0, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 || token.ExpiresAt < time.Now().Unix() {
+		return 0, ErrTokenNotFound
+	}
+
+	return token.UID, nil
+}
@@ -0,0 +1,127 @@
+// Package exporter performs export of the cities in different formats.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// typical errors
+var (
+	ErrUnknownFormat = errors.New("unknown format of the export")
+	ErrInvalidBBox   = errors.New("bbox must contain 4 numbers: min longitude, min latitude, max longitude, max latitude")
+)
+
+// Encoder writes the cities in the format of the export.
+type Encoder interface {
+	// Begin writes the beginning of the document.
+	Begin() error
+	// Write writes the city.
+	Write(city models.City) error
+	// End writes the end of the document and flushes the buffered data.
+	End() error
+}
+
+// Format describes the format of the export.
+type Format struct {
+	New         func(w io.Writer) Encoder // constructor of the encoder
+	ContentType string                    // MIME type of the document
+	Extension   string                    // extension of the file
+}
+
+var formats = map[string]Format{}
+
+// Register makes the format available by the name.
+// It is intended to be called from init functions.
+func Register(name string, format Format) {
+	formats[name] = format
+}
+
+// Formats returns names of the registered formats.
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns the format by the name.
+func Lookup(name string) (Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return format, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+
+	return format, nil
+}
+
+// Run writes the cities selected by the filter to w in the format.
+// Cities are streamed from the database one by one until the context is canceled.
+// The end of the document is not written on the error, so the truncated GeoJSON
+// or KML is invalid and cannot be taken for the complete export.
+func Run(ctx context.Context, db database.Cities, filter database.CityFilter, format Format, w io.Writer) (int, error) {
+	var count int
+
+	enc := format.New(w)
+	if err := enc.Begin(); err != nil {
+		return count, err
+	}
+
+	err := db.ExportCities(ctx, filter, func(city models.City) error {
+		count++
+
+		return enc.Write(city)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, enc.End()
+}
+
+// ParseBBox parses the bounding box in the format of GeoJSON:
+// "min longitude,min latitude,max longitude,max latitude".
+// Returns nil for the empty string.
+func ParseBBox(s string) (*database.BBox, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, ErrInvalidBBox
+	}
+
+	values := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, ErrInvalidBBox
+		}
+
+		values = append(values, v)
+	}
+
+	box := &database.BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+
+	switch {
+	case box.MinLat > box.MaxLat,
+		box.MinLat < -90 || box.MaxLat > 90,
+		box.MinLon < -180 || box.MaxLon > 180:
+		return nil, ErrInvalidBBox
+	}
+
+	return box, nil
+}
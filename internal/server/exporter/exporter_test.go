@@ -0,0 +1,217 @@
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/exporter"
+	"github.com/alaleks/geospace/internal/server/importer"
+)
+
+var cities = []models.City{
+	{ID: 1, Name: "Rome", NameASCII: "Rome", AlternativeNames: "Roma,Рим", CountryCode: "IT",
+		Country: "Italy", Timezone: "Europe/Rome", Latitude: 41.89193, Longitude: 12.51133,
+		Source: "geonames", ExternalID: "3169070"},
+	{ID: 2, Name: `Sant'Angelo "Lodigiano" & Co`, CountryCode: "IT", Country: "Italy",
+		Latitude: 45.23, Longitude: 9.4, Source: "geonames", ExternalID: "3167000"},
+}
+
+// encode writes the cities in the format and returns the document.
+func encode(t *testing.T, name string) []byte {
+	t.Helper()
+
+	format, err := exporter.Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	enc := format.New(&buf)
+	if err := enc.Begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, city := range cities {
+		if err := enc.Write(city); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := enc.End(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFormats(t *testing.T) {
+	t.Run("GeoJSON", func(t *testing.T) {
+		var collection struct {
+			Type     string             `json:"type"`
+			Features []exporter.Feature `json:"features"`
+		}
+
+		if err := json.Unmarshal(encode(t, exporter.FormatGeoJSON), &collection); err != nil {
+			t.Fatal(err)
+		}
+
+		if collection.Type != "FeatureCollection" || len(collection.Features) != len(cities) {
+			t.Fatalf("invalid feature collection: %+v", collection)
+		}
+
+		if collection.Features[0].Geometry.Coordinates != [2]float64{12.51133, 41.89193} {
+			t.Errorf("invalid coordinates: %v", collection.Features[0].Geometry.Coordinates)
+		}
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(string(encode(t, exporter.FormatNDJSON))), "\n")
+		if len(lines) != len(cities) {
+			t.Fatalf("expected %d lines, got %d", len(cities), len(lines))
+		}
+
+		var city models.City
+		if err := json.Unmarshal([]byte(lines[1]), &city); err != nil {
+			t.Fatal(err)
+		}
+
+		if city.Name != cities[1].Name {
+			t.Errorf("expected %s, got %s", cities[1].Name, city.Name)
+		}
+	})
+
+	t.Run("KML", func(t *testing.T) {
+		var doc struct {
+			Placemarks []struct {
+				Name        string `xml:"name"`
+				Coordinates string `xml:"Point>coordinates"`
+			} `xml:"Document>Placemark"`
+		}
+
+		if err := xml.Unmarshal(encode(t, exporter.FormatKML), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(doc.Placemarks) != len(cities) {
+			t.Fatalf("expected %d placemarks, got %d", len(cities), len(doc.Placemarks))
+		}
+
+		if doc.Placemarks[1].Name != cities[1].Name || doc.Placemarks[1].Coordinates != "9.4,45.23" {
+			t.Errorf("invalid placemark: %+v", doc.Placemarks[1])
+		}
+	})
+}
+
+// TestRoundTrip checks that the exported CSV and GeoJSON can be imported back.
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{exporter.FormatCSV, exporter.FormatGeoJSON} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cities."+name)
+			if err := os.WriteFile(path, encode(t, name), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			format, err := importer.New(config.Import{Format: name, Path: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			i := 0
+			err = format.Read(func(rec importer.Record) error {
+				want := cities[i]
+				want.ID, want.Source = 0, ""
+				i++
+
+				if rec.Err != nil || rec.City != want {
+					t.Errorf("city was imported incorrectly: %v\n got %+v\nwant %+v", rec.Err, rec.City, want)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if i != len(cities) {
+				t.Errorf("expected %d cities, got %d", len(cities), i)
+			}
+		})
+	}
+}
+
+// failingCities is the database failing the export after the cities.
+type failingCities struct {
+	database.Cities
+}
+
+func (failingCities) ExportCities(_ context.Context, _ database.CityFilter, fn func(models.City) error) error {
+	for _, city := range cities {
+		if err := fn(city); err != nil {
+			return err
+		}
+	}
+
+	return errConnection
+}
+
+var errConnection = errors.New("connection is lost")
+
+// TestRunInterrupted checks that the interrupted export is not taken for the complete one.
+func TestRunInterrupted(t *testing.T) {
+	for _, name := range []string{exporter.FormatGeoJSON, exporter.FormatKML} {
+		t.Run(name, func(t *testing.T) {
+			format, err := exporter.Lookup(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+
+			count, err := exporter.Run(context.Background(), failingCities{}, database.CityFilter{}, format, &buf)
+			if !errors.Is(err, errConnection) || count != len(cities) {
+				t.Fatalf("expected the error after %d cities, got %d: %v", len(cities), count, err)
+			}
+
+			if bytes.Contains(buf.Bytes(), []byte("]}")) || bytes.Contains(buf.Bytes(), []byte("</kml>")) {
+				t.Errorf("end of the interrupted document is written: %s", buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		bbox  string
+		valid bool
+	}{
+		{name: "Empty", bbox: "", valid: true},
+		{name: "Valid", bbox: "6.6,36.6,18.5,47.1", valid: true},
+		{name: "Crossing antimeridian", bbox: "170,-20,-170,-10", valid: true},
+		{name: "Not enough numbers", bbox: "6.6,36.6,18.5"},
+		{name: "Not a number", bbox: "6.6,south,18.5,47.1"},
+		{name: "Latitudes swapped", bbox: "6.6,47.1,18.5,36.6"},
+		{name: "Out of range", bbox: "6.6,36.6,181,47.1"},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := exporter.ParseBBox(tt.bbox); (err == nil) != tt.valid {
+				t.Errorf("unexpected result of parsing %q: %v", tt.bbox, err)
+			}
+		})
+	}
+}
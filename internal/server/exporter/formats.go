@@ -0,0 +1,231 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// names of the formats
+const (
+	FormatGeoJSON = "geojson"
+	FormatCSV     = "csv"
+	FormatKML     = "kml"
+	FormatNDJSON  = "ndjson"
+)
+
+// header of the CSV export, the columns are named as the fields
+// of the city in the CSV import, so the export can be imported back
+var csvHeader = []string{
+	"city_id", "name", "name_ascii", "alternative_names", "country_code",
+	"country", "timezone", "latitude", "longitude", "source", "external_id",
+}
+
+func init() {
+	Register(FormatGeoJSON, Format{
+		New:         func(w io.Writer) Encoder { return &geoJSON{w: bufio.NewWriter(w)} },
+		ContentType: "application/geo+json",
+		Extension:   ".geojson",
+	})
+	Register(FormatCSV, Format{
+		New:         func(w io.Writer) Encoder { return &csvEncoder{w: csv.NewWriter(w)} },
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   ".csv",
+	})
+	Register(FormatKML, Format{
+		New:         func(w io.Writer) Encoder { return &kml{w: bufio.NewWriter(w)} },
+		ContentType: "application/vnd.google-earth.kml+xml",
+		Extension:   ".kml",
+	})
+	Register(FormatNDJSON, Format{
+		New: func(w io.Writer) Encoder {
+			buf := bufio.NewWriter(w)
+			return &ndjson{w: buf, enc: json.NewEncoder(buf)}
+		},
+		ContentType: "application/x-ndjson",
+		Extension:   ".ndjson",
+	})
+}
+
+// Feature represents the city as the GeoJSON point feature.
+type Feature struct {
+	Properties map[string]any `json:"properties"`
+	Type       string         `json:"type"`
+	Geometry   Point          `json:"geometry"`
+	ID         int            `json:"id"`
+}
+
+// Point represents the GeoJSON point geometry.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// NewFeature converts the city to the GeoJSON point feature.
+// The properties are named as the fields of the city in the GeoJSON import.
+func NewFeature(city models.City) Feature {
+	return Feature{
+		Type: "Feature",
+		ID:   city.ID,
+		Geometry: Point{
+			Type:        "Point",
+			Coordinates: [2]float64{city.Longitude, city.Latitude},
+		},
+		Properties: map[string]any{
+			"name":              city.Name,
+			"name_ascii":        city.NameASCII,
+			"alternative_names": city.AlternativeNames,
+			"country_code":      city.CountryCode,
+			"country":           city.Country,
+			"timezone":          city.Timezone,
+			"source":            city.Source,
+			"external_id":       city.ExternalID,
+		},
+	}
+}
+
+// geoJSON writes the cities as the GeoJSON FeatureCollection.
+type geoJSON struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (g *geoJSON) Begin() error {
+	_, err := g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (g *geoJSON) Write(city models.City) error {
+	b, err := json.Marshal(NewFeature(city))
+	if err != nil {
+		return err
+	}
+
+	if g.count > 0 {
+		if err := g.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+
+	g.count++
+	_, err = g.w.Write(b)
+
+	return err
+}
+
+func (g *geoJSON) End() error {
+	if _, err := g.w.WriteString("]}\n"); err != nil {
+		return err
+	}
+
+	return g.w.Flush()
+}
+
+// csvEncoder writes the cities as CSV with header.
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (c *csvEncoder) Begin() error {
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvEncoder) Write(city models.City) error {
+	return c.w.Write([]string{
+		strconv.Itoa(city.ID), city.Name, city.NameASCII, city.AlternativeNames,
+		city.CountryCode, city.Country, city.Timezone,
+		strconv.FormatFloat(city.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(city.Longitude, 'f', -1, 64),
+		city.Source, city.ExternalID,
+	})
+}
+
+func (c *csvEncoder) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// kml writes the cities as placemarks of the KML document.
+type kml struct {
+	w *bufio.Writer
+}
+
+// kmlPlacemark represents the city as the KML placemark.
+type kmlPlacemark struct {
+	XMLName      xml.Name  `xml:"Placemark"`
+	ID           string    `xml:"id,attr"`
+	Name         string    `xml:"name"`
+	ExtendedData []kmlData `xml:"ExtendedData>Data"`
+	Coordinates  string    `xml:"Point>coordinates"`
+}
+
+// kmlData represents the extended data of the placemark.
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+func (k *kml) Begin() error {
+	_, err := k.w.WriteString(xml.Header +
+		`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>cities</name>` + "\n")
+	return err
+}
+
+func (k *kml) Write(city models.City) error {
+	placemark := kmlPlacemark{
+		ID:   "city-" + strconv.Itoa(city.ID),
+		Name: city.Name,
+		ExtendedData: []kmlData{
+			{Name: "city_id", Value: strconv.Itoa(city.ID)},
+			{Name: "name_ascii", Value: city.NameASCII},
+			{Name: "alternative_names", Value: city.AlternativeNames},
+			{Name: "country_code", Value: city.CountryCode},
+			{Name: "country", Value: city.Country},
+			{Name: "timezone", Value: city.Timezone},
+		},
+		Coordinates: strconv.FormatFloat(city.Longitude, 'f', -1, 64) + "," +
+			strconv.FormatFloat(city.Latitude, 'f', -1, 64),
+	}
+
+	b, err := xml.Marshal(placemark)
+	if err != nil {
+		return err
+	}
+
+	if _, err := k.w.Write(b); err != nil {
+		return err
+	}
+
+	return k.w.WriteByte('\n')
+}
+
+func (k *kml) End() error {
+	if _, err := k.w.WriteString("</Document></kml>\n"); err != nil {
+		return err
+	}
+
+	return k.w.Flush()
+}
+
+// ndjson writes the cities as JSON objects separated by new lines.
+type ndjson struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjson) Begin() error {
+	return nil
+}
+
+func (n *ndjson) Write(city models.City) error {
+	return n.enc.Encode(city)
+}
+
+func (n *ndjson) End() error {
+	return n.w.Flush()
+}
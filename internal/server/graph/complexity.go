@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// limits of the queries
+const (
+	MaxComplexity = 1000 // maximum complexity of the query
+	MaxDepth      = 8    // maximum nesting of the fields
+)
+
+// typical errors
+var (
+	ErrTooComplex     = fmt.Errorf("query is too complex, maximum complexity is %d", MaxComplexity)
+	ErrTooDeep        = fmt.Errorf("query is too deep, maximum depth is %d", MaxDepth)
+	ErrNoOperation    = errors.New("operation is not found")
+	ErrManyOperations = errors.New("operationName is required for the document with several operations")
+)
+
+// complexity estimates the number of the fields the query resolves. Every field
+// costs 1, the cost of the selections of the lists is multiplied by the
+// expected length of the list: the limit of the nearby cities or the number
+// of the names passed to the field.
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// checkComplexity checks the operation of the document against MaxComplexity and MaxDepth.
+func checkComplexity(doc *ast.Document, operationName string, variables map[string]any) error {
+	var op *ast.OperationDefinition
+
+	c := complexity{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			switch {
+			case operationName == "" && op != nil:
+				return ErrManyOperations
+			case operationName == "" || def.Name != nil && def.Name.Value == operationName:
+				op = def
+			}
+		}
+	}
+
+	if op == nil {
+		return ErrNoOperation
+	}
+
+	cost, depth := c.selectionSet(op.SelectionSet, 0)
+
+	switch {
+	case depth > MaxDepth:
+		return ErrTooDeep
+	case cost > MaxComplexity:
+		return ErrTooComplex
+	}
+
+	return nil
+}
+
+// selectionSet returns the cost and the depth of the selections.
+func (c complexity) selectionSet(set *ast.SelectionSet, level int) (int, int) {
+	if set == nil || level > MaxDepth {
+		return 0, level
+	}
+
+	cost, depth := 0, level
+
+	for _, sel := range set.Selections {
+		var selCost, selDepth int
+
+		switch sel := sel.(type) {
+		case *ast.Field:
+			selCost, selDepth = c.selectionSet(sel.SelectionSet, level+1)
+			selCost = 1 + selCost*c.multiplier(sel)
+			if selDepth < level+1 {
+				selDepth = level + 1
+			}
+		case *ast.InlineFragment:
+			selCost, selDepth = c.selectionSet(sel.SelectionSet, level)
+		case *ast.FragmentSpread:
+			if frag, ok := c.fragments[sel.Name.Value]; ok {
+				selCost, selDepth = c.selectionSet(frag.SelectionSet, level)
+			}
+		}
+
+		cost += selCost
+		if selDepth > depth {
+			depth = selDepth
+		}
+	}
+
+	return cost, depth
+}
+
+// multiplier returns the expected length of the list returned by the field.
+func (c complexity) multiplier(field *ast.Field) int {
+	switch field.Name.Value {
+	case "nearby":
+		if limit, ok := c.argument(field, "limit").(int); ok && limit > 0 {
+			return limit
+		}
+
+		return DefaultNearbyLimit
+	case "distanceTo", "cities":
+		for _, name := range []string{"cities", "names"} {
+			if list, ok := c.argument(field, name).([]any); ok && len(list) > 0 {
+				return len(list)
+			}
+		}
+	}
+
+	return 1
+}
+
+// argument returns the value of the argument of the field, variables are substituted.
+func (c complexity) argument(field *ast.Field, name string) any {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == name {
+			return c.value(arg.Value)
+		}
+	}
+
+	return nil
+}
+
+// value converts the value of the argument, only values affecting the cost are converted.
+func (c complexity) value(v ast.Value) any {
+	switch v := v.(type) {
+	case *ast.Variable:
+		switch value := c.variables[v.Name.Value].(type) {
+		case float64: // numbers of JSON
+			return int(value)
+		default:
+			return value
+		}
+	case *ast.IntValue:
+		var n int
+		if _, err := fmt.Sscan(v.Value, &n); err != nil {
+			return nil
+		}
+
+		return n
+	case *ast.ListValue:
+		list := make([]any, len(v.Values))
+		for i, item := range v.Values {
+			list[i] = c.value(item)
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
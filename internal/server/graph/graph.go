@@ -0,0 +1,358 @@
+// Package graph implements the GraphQL API of the application, which lets
+// clients fetch a city, its neighbours and distances to other cities
+// in one request.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/pkg/distance"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// limits of the lists
+const (
+	DefaultNearbyLimit = 20  // default number of the nearby cities
+	MaxNearbyLimit     = 100 // maximum number of the nearby cities
+	MaxNames           = 100 // maximum number of the names in one field
+)
+
+// typical errors
+var (
+	ErrInvalidDistance = errors.New("distance must be a positive number of km")
+	ErrInvalidLimit    = errors.New("limit must be in range [1, 100]")
+	ErrTooManyNames    = errors.New("too many names, maximum is 100")
+	ErrInvalidCoord    = errors.New("coordinates are out of range")
+)
+
+// Graph contains the schema of the GraphQL API.
+type Graph struct {
+	db     database.Cities
+	schema graphql.Schema
+}
+
+// Request is the body of the GraphQL request.
+type Request struct {
+	Variables     map[string]any `json:"variables"`
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+}
+
+// NearbyCity is the city near the other city.
+type NearbyCity struct {
+	City     models.City
+	Distance int
+}
+
+// New creates the schema of the GraphQL API.
+func New(db database.Cities) (*Graph, error) {
+	g := &Graph{db: db}
+
+	city := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "City",
+		Description: "City of the dataset",
+		Fields: graphql.Fields{
+			"id":          cityField(graphql.NewNonNull(graphql.Int), func(c models.City) any { return c.ID }),
+			"name":        cityField(graphql.NewNonNull(graphql.String), func(c models.City) any { return c.Name }),
+			"nameAscii":   cityField(graphql.String, func(c models.City) any { return c.NameASCII }),
+			"countryCode": cityField(graphql.String, func(c models.City) any { return c.CountryCode }),
+			"country":     cityField(graphql.NewNonNull(graphql.String), func(c models.City) any { return c.Country }),
+			"timezone":    cityField(graphql.String, func(c models.City) any { return c.Timezone }),
+			"latitude":    cityField(graphql.NewNonNull(graphql.Float), func(c models.City) any { return c.Latitude }),
+			"longitude":   cityField(graphql.NewNonNull(graphql.Float), func(c models.City) any { return c.Longitude }),
+		},
+	})
+
+	nearbyCity := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "NearbyCity",
+		Description: "City and the distance to it in km",
+		Fields: graphql.Fields{
+			"city": &graphql.Field{
+				Type: graphql.NewNonNull(city),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(NearbyCity).City, nil
+				},
+			},
+			"distance": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(NearbyCity).Distance, nil
+				},
+			},
+		},
+	})
+
+	city.AddFieldConfig("nearby", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nearbyCity))),
+		Description: "Cities within the distance in km, the nearest first",
+		Args: graphql.FieldConfigArgument{
+			"distance": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"limit":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultNearbyLimit},
+		},
+		Resolve: g.resolveNearby,
+	})
+
+	city.AddFieldConfig("distanceTo", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(nearbyCity)),
+		Description: "Distances in km by the straight line to the cities, null if the city is not found",
+		Args: graphql.FieldConfigArgument{
+			"cities": &graphql.ArgumentConfig{Type: namesType},
+		},
+		Resolve: g.resolveDistanceTo,
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"city": &graphql.Field{
+				Type:        city,
+				Description: "City by name, the name can contain the country: Moscow, Russia",
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: g.resolveCity,
+			},
+			"cities": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(city)),
+				Description: "Cities by names, null if the city is not found",
+				Args: graphql.FieldConfigArgument{
+					"names": &graphql.ArgumentConfig{Type: namesType},
+				},
+				Resolve: g.resolveCities,
+			},
+			"reverse": &graphql.Field{
+				Type:        city,
+				Description: "City nearest to the coordinates",
+				Args: graphql.FieldConfigArgument{
+					"latitude":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+					"longitude": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+				},
+				Resolve: g.resolveReverse,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	g.schema = schema
+
+	return g, nil
+}
+
+// namesType is the type of the arguments with the list of the names.
+var namesType = graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))
+
+// Execute executes the request, queries exceeding the limits are rejected before execution.
+func (g *Graph) Execute(ctx context.Context, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+
+	validation := graphql.ValidateDocument(&g.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := checkComplexity(doc, req.OperationName, req.Variables); err != nil {
+		return errorResult(err)
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        g.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(ctx, newCityLoader(ctx, g.db.FindCities)),
+	})
+}
+
+// Handler serves GraphQL requests. The request is sent by POST in JSON
+// or by GET with the parameters query, operationName and variables.
+func (g *Graph) Handler(c *fiber.Ctx) error {
+	var req Request
+
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(errorResult(err))
+			}
+		}
+	} else if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResult(err))
+	}
+
+	return c.JSON(g.Execute(c.UserContext(), req))
+}
+
+// errorResult returns the result with the error of the request.
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+}
+
+// cityField returns the field of the city.
+func cityField(typ graphql.Output, value func(models.City) any) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(models.City)), nil
+		},
+	}
+}
+
+// resolveCity resolves the city by name.
+func (g *Graph) resolveCity(p graphql.ResolveParams) (any, error) {
+	load := loaderFrom(p.Context).Load(p.Args["name"].(string))
+
+	return func() (any, error) {
+		return nullable(load())
+	}, nil
+}
+
+// resolveCities resolves the cities by names, each city is resolved separately,
+// so the city not found does not fail others.
+func (g *Graph) resolveCities(p graphql.ResolveParams) (any, error) {
+	names, err := namesArg(p.Args["names"])
+	if err != nil {
+		return nil, err
+	}
+
+	loader := loaderFrom(p.Context)
+	cities := make([]any, len(names))
+
+	for i, name := range names {
+		load := loader.Load(name)
+		cities[i] = func() (any, error) {
+			return nullable(load())
+		}
+	}
+
+	return cities, nil
+}
+
+// resolveReverse resolves the city nearest to the coordinates.
+func (g *Graph) resolveReverse(p graphql.ResolveParams) (any, error) {
+	lat, lon := p.Args["latitude"].(float64), p.Args["longitude"].(float64)
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, ErrInvalidCoord
+	}
+
+	return nullable(g.db.FindNearestCity(p.Context, lat, lon))
+}
+
+// resolveNearby resolves the cities near the city.
+func (g *Graph) resolveNearby(p graphql.ResolveParams) (any, error) {
+	departure := p.Source.(models.City)
+
+	dist, _ := p.Args["distance"].(int)
+	if dist <= 0 {
+		return nil, ErrInvalidDistance
+	}
+
+	limit, _ := p.Args["limit"].(int)
+	if limit < 1 || limit > MaxNearbyLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	var nearby []NearbyCity
+
+	err := g.db.EachObjectNearByCoord(p.Context, departure.Latitude, departure.Longitude, dist, func(city models.City) error {
+		if city.ID == departure.ID {
+			return nil
+		}
+
+		nearby = append(nearby, toNearbyCity(departure, city))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].Distance < nearby[j].Distance
+	})
+
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+
+	return nearby, nil
+}
+
+// resolveDistanceTo resolves the distances from the city to other cities.
+func (g *Graph) resolveDistanceTo(p graphql.ResolveParams) (any, error) {
+	departure := p.Source.(models.City)
+
+	names, err := namesArg(p.Args["cities"])
+	if err != nil {
+		return nil, err
+	}
+
+	loader := loaderFrom(p.Context)
+	distances := make([]any, len(names))
+
+	for i, name := range names {
+		load := loader.Load(name)
+		distances[i] = func() (any, error) {
+			city, err := load()
+			if err != nil {
+				return nil, err
+			}
+
+			return toNearbyCity(departure, city), nil
+		}
+	}
+
+	return distances, nil
+}
+
+// namesArg converts the argument with the list of the names.
+func namesArg(arg any) ([]string, error) {
+	list, _ := arg.([]any)
+	if len(list) > MaxNames {
+		return nil, ErrTooManyNames
+	}
+
+	names := make([]string, 0, len(list))
+	for _, name := range list {
+		names = append(names, name.(string))
+	}
+
+	return names, nil
+}
+
+// nullable returns null instead of the empty city.
+func nullable(city models.City, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return city, nil
+}
+
+// toNearbyCity returns the city with the distance to it from the departure.
+func toNearbyCity(departure, city models.City) NearbyCity {
+	return NearbyCity{
+		City: city,
+		Distance: int(distance.CalcGreatCircle(departure.Latitude, departure.Longitude,
+			city.Latitude, city.Longitude)),
+	}
+}
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// recorder records the names of the cities fetched by one query.
+type recorder struct {
+	database.Cities
+	batches [][]string
+}
+
+func (r *recorder) FindCities(ctx context.Context, names []string) (map[string]models.City, error) {
+	batch := append([]string(nil), names...)
+	sort.Strings(batch)
+	r.batches = append(r.batches, batch)
+
+	return r.Cities.FindCities(ctx, names)
+}
+
+func TestExecute(t *testing.T) {
+	db := &recorder{Cities: memory.New(
+		models.City{Name: "Rome", Country: "Italy", Latitude: 41.89, Longitude: 12.48},
+		models.City{Name: "Milan", Country: "Italy", Latitude: 45.46, Longitude: 9.19},
+	)}
+
+	g, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := g.Execute(context.Background(), Request{
+		Query: `query($to: [String!]!) {
+			rome: city(name: "Rome") { name distanceTo(cities: $to) { distance city { name } } }
+			milan: city(name: "Milan") { country }
+		}`,
+		Variables: map[string]any{"to": []any{"Milan", "Atlantis"}},
+	})
+
+	data, _ := json.Marshal(result.Data)
+	expected := `{"milan":{"country":"Italy"},"rome":{"distanceTo":[{"city":{"name":"Milan"},"distance":476},null],"name":"Rome"}}`
+
+	if string(data) != expected {
+		t.Errorf("expected data %s, got %s", expected, data)
+	}
+
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "not found") {
+		t.Errorf("expected the error of the city not found, got %v", result.Errors)
+	}
+
+	// names of the same level are fetched together, Milan is fetched once
+	if want := [][]string{{"Milan", "Rome"}, {"Atlantis"}}; !reflect.DeepEqual(db.batches, want) {
+		t.Errorf("expected batches %v, got %v", want, db.batches)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	g, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		err   error
+	}{
+		{
+			name:  "Complexity",
+			query: `{ city(name: "Rome") { nearby(distance: 100, limit: 100) { city { nearby(distance: 100, limit: 100) { distance } } } } }`,
+			err:   ErrTooComplex,
+		},
+		{
+			name:  "Complexity of variables",
+			query: `query($n: Int) { city(name: "Rome") { nearby(distance: 100, limit: $n) { city { nearby(distance: 100, limit: $n) { distance } } } } }`,
+			vars:  map[string]any{"n": float64(50)},
+			err:   ErrTooComplex,
+		},
+		{
+			name: "Depth",
+			query: `{ city(name: "Rome") { nearby(distance: 1, limit: 1) { city { nearby(distance: 1, limit: 1) { city {
+				nearby(distance: 1, limit: 1) { city { nearby(distance: 1, limit: 1) { city { name } } } } } } } } } }`,
+			err: ErrTooDeep,
+		},
+		{
+			name:  "Fragments",
+			query: `{ city(name: "Rome") { ...near } } fragment near on City { nearby(distance: 1, limit: 100) { city { ...far } } } fragment far on City { nearby(distance: 1, limit: 100) { distance } }`,
+			err:   ErrTooComplex,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := g.Execute(context.Background(), Request{Query: tt.query, Variables: tt.vars})
+			if len(result.Errors) != 1 || result.Errors[0].Message != tt.err.Error() {
+				t.Errorf("expected %q, got %v", tt.err, result.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// maxLoadBatch is the maximum number of names of the cities fetched with one query.
+const maxLoadBatch = 100
+
+// fetchFunc fetches the cities by names, names of the cities not found are missing.
+type fetchFunc func(ctx context.Context, names []string) (map[string]models.City, error)
+
+// loaded is the result of the loading of one city.
+type loaded struct {
+	err  error
+	city models.City
+}
+
+// cityLoader batches loading of the cities by names during one request.
+// Load only queues the name, the queued names are fetched together when
+// the result of any of them is needed. Results are cached until the end
+// of the request, so every name is fetched once.
+type cityLoader struct {
+	ctx     context.Context // context of the request
+	fetch   fetchFunc
+	cache   map[string]loaded
+	pending []string
+	mu      sync.Mutex
+}
+
+type loaderKey struct{}
+
+// newCityLoader creates a new loader of the cities for the request.
+func newCityLoader(ctx context.Context, fetch fetchFunc) *cityLoader {
+	return &cityLoader{
+		ctx:   ctx,
+		fetch: fetch,
+		cache: make(map[string]loaded),
+	}
+}
+
+// withLoader returns the context carrying the loader.
+func withLoader(ctx context.Context, l *cityLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+// loaderFrom returns the loader of the request.
+func loaderFrom(ctx context.Context) *cityLoader {
+	l, _ := ctx.Value(loaderKey{}).(*cityLoader)
+	return l
+}
+
+// Load queues the name of the city and returns the function waiting for the city.
+func (l *cityLoader) Load(name string) func() (models.City, error) {
+	name = strings.TrimSpace(name)
+
+	l.mu.Lock()
+	if _, ok := l.cache[name]; !ok && !l.isPending(name) {
+		l.pending = append(l.pending, name)
+	}
+	l.mu.Unlock()
+
+	return func() (models.City, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.cache[name]; !ok {
+			l.dispatch()
+		}
+
+		res := l.cache[name]
+
+		return res.city, res.err
+	}
+}
+
+// isPending checks if the name is already queued.
+func (l *cityLoader) isPending(name string) bool {
+	for _, p := range l.pending {
+		if p == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dispatch fetches all queued names, the caller must hold the lock.
+func (l *cityLoader) dispatch() {
+	for len(l.pending) > 0 {
+		batch := l.pending
+		if len(batch) > maxLoadBatch {
+			batch = batch[:maxLoadBatch]
+		}
+
+		l.pending = l.pending[len(batch):]
+
+		cities, err := l.fetch(l.ctx, batch)
+		for _, name := range batch {
+			city, ok := cities[name]
+
+			switch {
+			case err != nil:
+				l.cache[name] = loaded{err: err}
+			case !ok:
+				l.cache[name] = loaded{err: database.ErrCityNotFound}
+			default:
+				l.cache[name] = loaded{city: city}
+			}
+		}
+	}
+}
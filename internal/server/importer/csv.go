@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// FormatCSV is a format of the CSV file with header.
+const FormatCSV = "csv"
+
+// ErrMissingColumn is returned if the mapped column is absent in the header.
+var ErrMissingColumn = errors.New("column is missing in the header")
+
+// CSV reads cities from the CSV file with header. The columns
+// are mapped to the fields of the city by the configuration.
+type CSV struct {
+	columns   map[string]string
+	path      string
+	delimiter rune
+}
+
+func init() {
+	Register(FormatCSV, NewCSV)
+}
+
+// NewCSV creates the CSV format. By default the columns are named
+// as the fields of the city and delimited by comma.
+func NewCSV(cfg config.Import) (Format, error) {
+	delimiter := ','
+	if cfg.Delimiter != "" {
+		r, size := utf8.DecodeRuneInString(cfg.Delimiter)
+		if size != len(cfg.Delimiter) {
+			return nil, fmt.Errorf("delimiter must be one character: %q", cfg.Delimiter)
+		}
+
+		delimiter = r
+	}
+
+	return &CSV{
+		path:      cfg.Path,
+		columns:   mapColumns(cfg.Columns),
+		delimiter: delimiter,
+	}, nil
+}
+
+// Fingerprint returns a value which changes when the files of the dataset change.
+func (c *CSV) Fingerprint() string {
+	return fingerprint(c.path)
+}
+
+// Read reads the dataset and calls fn for every record.
+// Records with invalid values are passed with the error.
+func (c *CSV) Read(fn func(Record) error) error {
+	f, err := openDataset(c.path, hasExt(".csv", ".tsv", ".txt"))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = c.delimiter
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return err
+	}
+
+	// index of the column for every mapped field
+	indexes := make(map[string]int, len(c.columns))
+	for field, column := range c.columns {
+		indexes[field] = -1
+
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				indexes[field] = i
+
+				break
+			}
+		}
+	}
+
+	for _, field := range []string{FieldName, FieldLatitude, FieldLongitude} {
+		if indexes[field] < 0 {
+			return fmt.Errorf("%w: %s", ErrMissingColumn, c.columns[field])
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(Record{Line: parseErr.StartLine, Err: parseErr.Err}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		line, _ := r.FieldPos(0)
+		rec := Record{Line: line}
+
+		if len(record) != len(header) {
+			rec.Err = ErrColumnsCount
+		}
+
+		for field, i := range indexes {
+			if i < 0 || i >= len(record) {
+				continue
+			}
+
+			if err := setField(&rec.City, field, record[i]); err != nil && rec.Err == nil {
+				rec.Err = err
+			}
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// mapColumns returns mapping of the fields of the city to the names
+// of columns or properties. Fields which are not specified are mapped
+// to the columns with the same name.
+func mapColumns(columns map[string]string) map[string]string {
+	mapping := map[string]string{
+		FieldName:             FieldName,
+		FieldNameASCII:        FieldNameASCII,
+		FieldAlternativeNames: FieldAlternativeNames,
+		FieldCountryCode:      FieldCountryCode,
+		FieldCountry:          FieldCountry,
+		FieldTimezone:         FieldTimezone,
+		FieldLatitude:         FieldLatitude,
+		FieldLongitude:        FieldLongitude,
+		FieldExternalID:       FieldExternalID,
+	}
+
+	for field, column := range columns {
+		if _, ok := mapping[field]; ok && column != "" {
+			mapping[field] = column
+		}
+	}
+
+	return mapping
+}
+
+// setField parses the value and sets it to the field of the city.
+func setField(city *models.City, field, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch field {
+	case FieldName:
+		city.Name = value
+	case FieldNameASCII:
+		city.NameASCII = value
+	case FieldAlternativeNames:
+		city.AlternativeNames = value
+	case FieldCountryCode:
+		city.CountryCode = strings.ToUpper(value)
+	case FieldCountry:
+		city.Country = value
+	case FieldTimezone:
+		city.Timezone = value
+	case FieldExternalID:
+		city.ExternalID = value
+	case FieldLatitude, FieldLongitude:
+		coord, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+
+		if field == FieldLatitude {
+			city.Latitude = coord
+		} else {
+			city.Longitude = coord
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+const (
+	FormatGeoJSON = "geojson" // format of the GeoJSON FeatureCollection
+	geometryPoint = "Point"   // type of the geometry of the city
+)
+
+// ErrInvalidGeoJSON is returned if the file is not a FeatureCollection.
+var ErrInvalidGeoJSON = errors.New("invalid GeoJSON FeatureCollection")
+
+// GeoJSON reads cities from the point features of the FeatureCollection.
+// The properties are mapped to the fields of the city by the configuration.
+type GeoJSON struct {
+	properties map[string]string
+	path       string
+}
+
+// feature represents a feature of the GeoJSON.
+type feature struct {
+	ID         any            `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Geometry   struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+func init() {
+	Register(FormatGeoJSON, NewGeoJSON)
+}
+
+// NewGeoJSON creates the GeoJSON format. By default the properties
+// are named as the fields of the city.
+func NewGeoJSON(cfg config.Import) (Format, error) {
+	return &GeoJSON{
+		path:       cfg.Path,
+		properties: mapColumns(cfg.Columns),
+	}, nil
+}
+
+// Fingerprint returns a value which changes when the files of the dataset change.
+func (g *GeoJSON) Fingerprint() string {
+	return fingerprint(g.path)
+}
+
+// Read reads the dataset and calls fn for every point feature,
+// the features with other geometries are skipped.
+// Features are decoded one by one without loading the whole file.
+func (g *GeoJSON) Read(fn func(Record) error) error {
+	f, err := openDataset(g.path, hasExt(".geojson", ".json"))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ErrInvalidGeoJSON
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if key, _ := tok.(string); key != "features" {
+			// skip value of other members
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return ErrInvalidGeoJSON
+		}
+
+		for n := 1; dec.More(); n++ {
+			var ft feature
+
+			err := dec.Decode(&ft)
+			if err != nil && !isTypeError(err) {
+				return fmt.Errorf("feature %d: %w", n, err)
+			}
+
+			if err == nil && ft.Geometry.Type != geometryPoint {
+				continue
+			}
+
+			rec := Record{Line: n, Err: err}
+			if rec.Err == nil {
+				rec.City, rec.Err = g.city(ft)
+			}
+
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// city converts the point feature to the city.
+func (g *GeoJSON) city(ft feature) (models.City, error) {
+	var coordinates []float64
+	if err := json.Unmarshal(ft.Geometry.Coordinates, &coordinates); err != nil {
+		return models.City{}, err
+	}
+
+	if len(coordinates) < 2 {
+		return models.City{}, ErrInvalidGeoJSON
+	}
+
+	city := models.City{
+		Longitude:  coordinates[0],
+		Latitude:   coordinates[1],
+		ExternalID: propertyString(ft.ID),
+	}
+
+	for field, property := range g.properties {
+		if field == FieldLatitude || field == FieldLongitude {
+			continue
+		}
+
+		value, ok := ft.Properties[property]
+		if !ok {
+			continue
+		}
+
+		if err := setField(&city, field, propertyString(value)); err != nil {
+			return city, err
+		}
+	}
+
+	return city, nil
+}
+
+// propertyString converts the value of the property to string,
+// the arrays are joined by comma.
+func propertyString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, propertyString(item))
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
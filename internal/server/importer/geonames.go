@@ -0,0 +1,299 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+// FormatGeoNames is a format of the GeoNames dumps (cities500, cities15000 etc).
+// See https://download.geonames.org/export/dump/readme.txt
+const FormatGeoNames = "geonames"
+
+// columns of the GeoNames cities file
+const (
+	gnID = iota
+	gnName
+	gnASCIIName
+	gnAlternateNames
+	gnLatitude
+	gnLongitude
+	gnFeatureClass
+	gnFeatureCode
+	gnCountryCode
+	gnCC2
+	gnAdmin1
+	gnAdmin2
+	gnAdmin3
+	gnAdmin4
+	gnPopulation
+	gnElevation
+	gnDEM
+	gnTimezone
+	gnModificationDate
+	gnColumns
+)
+
+// columns of the GeoNames alternateNames file
+const (
+	anGeonameID  = 1
+	anLanguage   = 2
+	anName       = 3
+	anColloquial = 6
+	anHistoric   = 7
+	anColumns    = 8
+)
+
+// columns of the GeoNames countryInfo file
+const (
+	ciISO     = 0
+	ciCountry = 4
+	ciColumns = 5
+)
+
+// maxLineSize is the maximal size of the line of GeoNames files
+// (alternate names of the city can be up to 10000 characters).
+const maxLineSize = 1 << 20
+
+// alternate names with these pseudo language codes are links and codes, not names
+var skipLanguages = map[string]bool{
+	"link": true, "post": true, "iata": true, "icao": true, "faac": true,
+	"abbr": true, "wkdt": true, "unlc": true, "fr_1793": true,
+}
+
+// GeoNames reads cities from the GeoNames dump. The alternate names
+// and the names of countries are taken from the additional files if specified.
+type GeoNames struct {
+	path           string
+	alternateNames string
+	countries      string
+}
+
+func init() {
+	Register(FormatGeoNames, NewGeoNames)
+}
+
+// NewGeoNames creates the GeoNames format.
+func NewGeoNames(cfg config.Import) (Format, error) {
+	return &GeoNames{
+		path:           cfg.Path,
+		alternateNames: cfg.AlternateNames,
+		countries:      cfg.Countries,
+	}, nil
+}
+
+// Fingerprint returns a value which changes when the files of the dataset change.
+func (g *GeoNames) Fingerprint() string {
+	return fingerprint(g.path, g.alternateNames, g.countries)
+}
+
+// Read reads the dataset and calls fn for every record.
+// Lines with invalid values are passed with the error.
+func (g *GeoNames) Read(fn func(Record) error) error {
+	countries, err := g.readCountries()
+	if err != nil {
+		return err
+	}
+
+	altNames, err := g.readAlternateNames()
+	if err != nil {
+		return err
+	}
+
+	return g.readCities(func(line int, fields []string) error {
+		if len(fields) < gnColumns {
+			return fn(Record{Line: line, Err: ErrColumnsCount})
+		}
+
+		rec := Record{Line: line}
+
+		lat, err := strconv.ParseFloat(fields[gnLatitude], 64)
+		if err != nil {
+			rec.Err = fmt.Errorf("invalid latitude: %w", err)
+		}
+
+		lon, err := strconv.ParseFloat(fields[gnLongitude], 64)
+		if err != nil && rec.Err == nil {
+			rec.Err = fmt.Errorf("invalid longitude: %w", err)
+		}
+
+		names := splitNames(fields[gnAlternateNames])
+		if id, err := strconv.Atoi(fields[gnID]); err == nil {
+			names = mergeNames(names, altNames[id])
+		}
+
+		country, ok := countries[fields[gnCountryCode]]
+		if !ok {
+			country = fields[gnCountryCode]
+		}
+
+		rec.City = models.City{
+			Name:             fields[gnName],
+			NameASCII:        fields[gnASCIIName],
+			AlternativeNames: strings.Join(names, ","),
+			CountryCode:      fields[gnCountryCode],
+			Country:          country,
+			Timezone:         fields[gnTimezone],
+			Latitude:         lat,
+			Longitude:        lon,
+			ExternalID:       fields[gnID],
+		}
+
+		return fn(rec)
+	})
+}
+
+// readCities reads the cities file and calls fn for the fields of every line.
+func (g *GeoNames) readCities(fn func(line int, fields []string) error) error {
+	f, err := openDataset(g.path, hasExt(".txt"))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return readTSV(f, fn)
+}
+
+// readAlternateNames returns the alternate names of the cities from the dataset.
+// To save memory only the names of the cities from the cities file are kept.
+func (g *GeoNames) readAlternateNames() (map[int][]string, error) {
+	if g.alternateNames == "" {
+		return nil, nil
+	}
+
+	names := make(map[int][]string)
+
+	err := g.readCities(func(_ int, fields []string) error {
+		if id, err := strconv.Atoi(fields[gnID]); err == nil {
+			names[id] = nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := openDataset(g.alternateNames, func(name string) bool {
+		return strings.HasPrefix(name, "alternateNames") && filepath.Ext(name) == ".txt"
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	err = readTSV(f, func(_ int, fields []string) error {
+		if len(fields) < anColumns || skipLanguages[fields[anLanguage]] ||
+			fields[anColloquial] == "1" || fields[anHistoric] == "1" {
+			return nil
+		}
+
+		id, err := strconv.Atoi(fields[anGeonameID])
+		if err != nil {
+			return nil
+		}
+
+		if list, ok := names[id]; ok {
+			names[id] = append(list, fields[anName])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// readCountries returns the names of the countries by ISO codes.
+func (g *GeoNames) readCountries() (map[string]string, error) {
+	countries := make(map[string]string)
+	if g.countries == "" {
+		return countries, nil
+	}
+
+	f, err := openDataset(g.countries, hasExt(".txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	err = readTSV(f, func(_ int, fields []string) error {
+		if len(fields) >= ciColumns {
+			countries[fields[ciISO]] = fields[ciCountry]
+		}
+
+		return nil
+	})
+
+	return countries, err
+}
+
+// readTSV reads the tab separated file and calls fn for every line.
+// Empty lines and comments are skipped.
+func readTSV(r io.Reader, fn func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if err := fn(line, strings.Split(text, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// splitNames splits the list of names separated by comma.
+func splitNames(list string) []string {
+	if list == "" {
+		return nil
+	}
+
+	names := strings.Split(list, ",")
+	result := names[:0]
+
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// mergeNames appends to the list the names which are not in it yet.
+func mergeNames(names, extra []string) []string {
+	if len(extra) == 0 {
+		return names
+	}
+
+	seen := make(map[string]bool, len(names)+len(extra))
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	for _, name := range extra {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
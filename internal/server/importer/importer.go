@@ -0,0 +1,400 @@
+// Package importer performs import of the datasets of cities in different formats.
+package importer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/gen2brain/go-unarr"
+	"go.uber.org/zap"
+)
+
+// names of the fields of the city which can be mapped
+// to CSV columns or GeoJSON properties
+const (
+	FieldName             = "name"
+	FieldNameASCII        = "name_ascii"
+	FieldAlternativeNames = "alternative_names"
+	FieldCountryCode      = "country_code"
+	FieldCountry          = "country"
+	FieldTimezone         = "timezone"
+	FieldLatitude         = "latitude"
+	FieldLongitude        = "longitude"
+	FieldExternalID       = "external_id"
+)
+
+// typical errors
+var (
+	ErrUnknownFormat   = errors.New("unknown format of the dataset")
+	ErrEmptyPath       = errors.New("path to the dataset cannot be empty")
+	ErrFileNotFound    = errors.New("dataset file is not found in the archive")
+	ErrInvalidName     = errors.New("name of the city cannot be empty")
+	ErrInvalidLocation = errors.New("coordinates of the city are out of range")
+	ErrColumnsCount    = errors.New("wrong number of columns")
+)
+
+// Record is a city read from the dataset.
+type Record struct {
+	Err  error       // error of parsing of the record, the record is skipped on import
+	City models.City // city from the record
+	Line int         // line of the record in the file or number of the record for JSON formats
+}
+
+// Format reads the dataset of cities.
+type Format interface {
+	// Read reads the dataset record by record and calls fn for every record.
+	// Reading is stopped on the first error returned by fn.
+	Read(fn func(Record) error) error
+	// Fingerprint returns a value which changes when the files of the dataset change.
+	Fingerprint() string
+}
+
+// Constructor creates a new Format from the import configuration.
+type Constructor func(cfg config.Import) (Format, error)
+
+var formats = map[string]Constructor{}
+
+// Register makes the format available by the name.
+// It is intended to be called from init functions.
+func Register(name string, constructor Constructor) {
+	formats[name] = constructor
+}
+
+// Formats returns names of the registered formats.
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns the format of the dataset specified in the configuration.
+// If the format is not specified the sample dataset is used.
+func New(cfg config.Import) (Format, error) {
+	if cfg.Format == "" {
+		cfg.Format = FormatSample
+	}
+
+	constructor, ok := formats[cfg.Format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, cfg.Format)
+	}
+
+	return constructor(cfg)
+}
+
+// Validate checks that the city can be saved to the database.
+func Validate(city models.City) error {
+	switch {
+	case strings.TrimSpace(city.Name) == "":
+		return ErrInvalidName
+	case city.Latitude < -90 || city.Latitude > 90,
+		city.Longitude < -180 || city.Longitude > 180:
+		return ErrInvalidLocation
+	}
+
+	return nil
+}
+
+// openDataset opens the file of the dataset. If the path is an archive,
+// the first entry accepted by match is opened without extraction to disk.
+func openDataset(path string, match func(name string) bool) (io.ReadCloser, error) {
+	if path == "" {
+		return nil, ErrEmptyPath
+	}
+
+	if !isArchive(path) {
+		return os.Open(path)
+	}
+
+	a, err := unarr.NewArchive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = a.Entry()
+		if err != nil {
+			a.Close()
+
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+			}
+
+			return nil, err
+		}
+
+		if match(filepath.Base(a.Name())) {
+			return &archiveEntry{archive: a, left: a.Size()}, nil
+		}
+	}
+}
+
+// isArchive checks by extension whether the file is an archive.
+func isArchive(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip", ".rar", ".7z", ".tar":
+		return true
+	default:
+		return false
+	}
+}
+
+// hasExt returns a function matching file names with one of the extensions.
+func hasExt(exts ...string) func(name string) bool {
+	return func(name string) bool {
+		for _, ext := range exts {
+			if strings.EqualFold(filepath.Ext(name), ext) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// archiveEntry reads the current entry of the archive.
+type archiveEntry struct {
+	archive *unarr.Archive
+	left    int
+}
+
+// Read reads no more than the rest of the entry,
+// because the archive fails to read beyond the end of the entry.
+func (e *archiveEntry) Read(b []byte) (int, error) {
+	if e.left <= 0 || len(b) == 0 {
+		return 0, io.EOF
+	}
+
+	if len(b) > e.left {
+		b = b[:e.left]
+	}
+
+	n, err := e.archive.Read(b)
+	if err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	e.left -= n
+
+	return n, nil
+}
+
+// Close closes the archive.
+func (e *archiveEntry) Close() error {
+	return e.archive.Close()
+}
+
+// DefaultBatchSize is quantity of the cities written to the database at once.
+const DefaultBatchSize = 500
+
+// Options contains the parameters of the import.
+type Options struct {
+	Logger    *zap.SugaredLogger // logger for progress and invalid records, can be nil
+	Source    string             // name of the dataset, cities are matched by external id inside the source
+	BatchSize int                // quantity of the cities written at once, DefaultBatchSize by default
+	DryRun    bool               // if true changes are not saved
+	Resumable bool               // if true every batch is committed and the import can be resumed
+}
+
+// RowError is an error of the record of the dataset.
+type RowError struct {
+	Err        error
+	ExternalID string
+	Line       int
+}
+
+// Error returns the error with the line of the record.
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the error of the record.
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// Result contains the results of the import.
+type Result struct {
+	Errors []RowError           // invalid records which were skipped
+	Stats  database.ImportStats // quantities of the changed cities
+}
+
+// Run reads the dataset record by record and synchronizes the cities of the source
+// in the database with it by batches. Invalid records are skipped and collected
+// in the result. In the resumable mode the records processed before
+// the checkpoint of the previous interrupted import are not written again.
+// The import is stopped on cancellation of the context.
+func Run(ctx context.Context, db database.CityImporter, format Format, opts Options) (Result, error) {
+	var result Result
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	sync, err := db.BeginCitySync(ctx, opts.Source, database.SyncOptions{
+		Fingerprint: format.Fingerprint(),
+		DryRun:      opts.DryRun,
+		Resumable:   opts.Resumable,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	defer sync.Close()
+
+	resumeFrom := sync.Position()
+	if resumeFrom > 0 {
+		logInfo(opts.Logger, "import resumed from checkpoint", "source", opts.Source, "position", resumeFrom)
+	}
+
+	var (
+		position int
+		batch    = make([]models.City, 0, opts.BatchSize)
+	)
+
+	flush := func() error {
+		if err := sync.Write(batch, position); err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+		stats := sync.Stats()
+		metrics.ImportProgress(opts.Source, position, stats.Inserted, stats.Updated,
+			stats.Unchanged, stats.Deleted, stats.Skipped)
+		logInfo(opts.Logger, "import progress", "source", opts.Source, "processed", position,
+			"inserted", stats.Inserted, "updated", stats.Updated,
+			"unchanged", stats.Unchanged, "skipped", stats.Skipped)
+
+		return nil
+	}
+
+	err = format.Read(func(rec Record) error {
+		position++
+
+		city := rec.City
+		if rec.Err == nil {
+			rec.Err = Validate(city)
+		}
+
+		if rec.Err == nil && city.ExternalID == "" {
+			city.ExternalID = SyntheticID(city)
+		}
+
+		if position <= resumeFrom {
+			sync.Seen(city.ExternalID)
+			return nil
+		}
+
+		if rec.Err != nil {
+			rowErr := RowError{Line: rec.Line, ExternalID: city.ExternalID, Err: rec.Err}
+			result.Errors = append(result.Errors, rowErr)
+			sync.Skip(city.ExternalID)
+
+			if opts.Logger != nil {
+				opts.Logger.Warnw("invalid record is skipped", "source", opts.Source,
+					"line", rowErr.Line, "error", rowErr.Err.Error())
+			}
+
+			return nil
+		}
+
+		batch = append(batch, city)
+		if len(batch) < opts.BatchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+
+	result.Stats, err = sync.Finish()
+	if err != nil {
+		return result, err
+	}
+
+	stats := result.Stats
+	metrics.ImportProgress(opts.Source, position, stats.Inserted, stats.Updated,
+		stats.Unchanged, stats.Deleted, stats.Skipped)
+
+	if !opts.DryRun {
+		metrics.ImportFinished(opts.Source)
+	}
+
+	logInfo(opts.Logger, "import finished", "source", opts.Source, "results", result.Stats.String())
+
+	return result, nil
+}
+
+// logInfo writes the message to the log if the logger is set.
+func logInfo(logger *zap.SugaredLogger, msg string, keysAndValues ...any) {
+	if logger != nil {
+		logger.Infow(msg, keysAndValues...)
+	}
+}
+
+// fingerprint returns the hash of paths, sizes and modification times of the files.
+func fingerprint(paths ...string) string {
+	h := sha1.New()
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		fmt.Fprint(h, path)
+
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprint(h, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SyntheticID returns the external id for the city from the dataset without ids.
+// It is built from the country code, the name and the rounded coordinates,
+// so it stays the same while these fields are not changed in the dataset.
+func SyntheticID(city models.City) string {
+	key := fmt.Sprintf("%s:%s:%.3f:%.3f", city.CountryCode, city.Name, city.Latitude, city.Longitude)
+	sum := sha1.Sum([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// SourceName returns the name of the source from the configuration,
+// the name of the format is used by default.
+func SourceName(cfg config.Import) string {
+	switch {
+	case cfg.Source != "":
+		return cfg.Source
+	case cfg.Format != "":
+		return cfg.Format
+	default:
+		return FormatSample
+	}
+}
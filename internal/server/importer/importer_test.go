@@ -0,0 +1,369 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/importer"
+)
+
+const (
+	csvData = `id;city;lat;lon;cc;country;alt
+r1;Rome;41.89193;12.51133;it;Italy;"Roma,Рим"
+m1;Milan;45.46427;9.18951;IT;Italy;
+`
+	geoJSONData = `{
+	"type": "FeatureCollection",
+	"name": "cities",
+	"features": [
+		{"type": "Feature", "id": 3169070, "geometry": {"type": "Point", "coordinates": [12.51133, 41.89193]},
+		 "properties": {"name": "Rome", "country_code": "IT", "country": "Italy",
+		 "alternative_names": ["Roma", "Рим"], "timezone": "Europe/Rome"}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]},
+		 "properties": {"name": "Road"}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [9.18951, 45.46427]},
+		 "properties": {"name": "Milan", "country_code": "IT", "country": "Italy"}}
+	]
+}`
+	geoNamesData = "3169070\tRome\tRome\tRoma,Rome\t41.89193\t12.51133\tP\tPPLC\tIT\t\t07\tRM\t058091\t\t2318895\t\t20\tEurope/Rome\t2022-01-01\n" +
+		"3173435\tMilan\tMilan\tMilano\t45.46427\t9.18951\tP\tPPLA\tIT\t\t09\tMI\t015146\t\t1236837\t\t120\tEurope/Rome\t2022-01-01\n"
+	alternateNamesData = "1\t3169070\tru\tРим\t\t\t\t\t\t\n" +
+		"2\t3169070\tlink\thttps://en.wikipedia.org/wiki/Rome\t\t\t\t\t\t\n" +
+		"3\t3169070\tla\tRoma Antiqua\t\t\t\t1\t\t\n" +
+		"4\t9999999\tru\tНеизвестный\t\t\t\t\t\t\n"
+	countriesData = "#ISO\tISO3\tISO-Numeric\tfips\tCountry\n" +
+		"IT\tITA\t380\tIT\tItaly\n"
+)
+
+func TestFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	tests := []struct {
+		name   string
+		cfg    config.Import
+		cities []models.City
+	}{
+		{
+			name: "CSV with mapping of columns",
+			cfg: config.Import{
+				Format:    importer.FormatCSV,
+				Path:      write("cities.csv", csvData),
+				Delimiter: ";",
+				Columns: map[string]string{
+					"name": "city", "latitude": "lat", "longitude": "lon",
+					"country_code": "cc", "alternative_names": "alt", "external_id": "id",
+				},
+			},
+			cities: []models.City{
+				{Name: "Rome", CountryCode: "IT", Country: "Italy", AlternativeNames: "Roma,Рим",
+					Latitude: 41.89193, Longitude: 12.51133, ExternalID: "r1"},
+				{Name: "Milan", CountryCode: "IT", Country: "Italy", Latitude: 45.46427, Longitude: 9.18951,
+					ExternalID: "m1"},
+			},
+		},
+		{
+			name: "GeoJSON with point features",
+			cfg: config.Import{
+				Format: importer.FormatGeoJSON,
+				Path:   write("cities.geojson", geoJSONData),
+			},
+			cities: []models.City{
+				{Name: "Rome", CountryCode: "IT", Country: "Italy", AlternativeNames: "Roma,Рим",
+					Timezone: "Europe/Rome", Latitude: 41.89193, Longitude: 12.51133, ExternalID: "3169070"},
+				{Name: "Milan", CountryCode: "IT", Country: "Italy", Latitude: 45.46427, Longitude: 9.18951},
+			},
+		},
+		{
+			name: "GeoNames with alternate names and countries",
+			cfg: config.Import{
+				Format:         importer.FormatGeoNames,
+				Path:           write("cities500.txt", geoNamesData),
+				AlternateNames: write("alternateNamesV2.txt", alternateNamesData),
+				Countries:      write("countryInfo.txt", countriesData),
+			},
+			cities: []models.City{
+				{Name: "Rome", NameASCII: "Rome", CountryCode: "IT", Country: "Italy",
+					AlternativeNames: "Roma,Rome,Рим", Timezone: "Europe/Rome",
+					Latitude: 41.89193, Longitude: 12.51133, ExternalID: "3169070"},
+				{Name: "Milan", NameASCII: "Milan", CountryCode: "IT", Country: "Italy",
+					AlternativeNames: "Milano", Timezone: "Europe/Rome",
+					Latitude: 45.46427, Longitude: 9.18951, ExternalID: "3173435"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := importer.New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cities := make([]models.City, 0)
+			err = format.Read(func(rec importer.Record) error {
+				if rec.Err != nil {
+					t.Errorf("line %d: unexpected error: %v", rec.Line, rec.Err)
+				}
+
+				cities = append(cities, rec.City)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(cities) != len(tt.cities) {
+				t.Fatalf("expected %d cities, got %d: %v", len(tt.cities), len(cities), cities)
+			}
+
+			for i := range cities {
+				if cities[i] != tt.cities[i] {
+					t.Errorf("city %d was read incorrectly:\n got %+v\nwant %+v", i, cities[i], tt.cities[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSampleDefaultPath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sample"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(filepath.Join(root, "sample", "cities.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("cities.json")
+	if err == nil {
+		_, err = w.Write([]byte(`[{"name": "Rome", "country_code": "IT", "coordinates": {"lon": 12.51133, "lat": 41.89193}}]`))
+	}
+
+	if err != nil || zw.Close() != nil || f.Close() != nil {
+		t.Fatal(err)
+	}
+
+	// the archive is found next to the directory of the config file, not the working directory
+	t.Setenv(config.EnvConfig, filepath.Join(root, "cfg", "config.yaml"))
+	t.Setenv("GEOSPACE_DATABASE_DRIVER", "memory")
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := importer.New(cfg.Import)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cities []models.City
+	err = format.Read(func(rec importer.Record) error {
+		cities = append(cities, rec.City)
+		return nil
+	})
+	if err != nil || len(cities) != 1 || cities[0].Name != "Rome" {
+		t.Errorf("unexpected cities of the sample %v, %v", cities, err)
+	}
+
+	if _, err := importer.New(config.Import{}); !errors.Is(err, importer.ErrEmptyPath) {
+		t.Errorf("expected ErrEmptyPath without the config file, got %v", err)
+	}
+}
+
+func TestInvalidRecords(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		lines  []int
+	}{
+		{
+			name:   "CSV",
+			format: importer.FormatCSV,
+			data:   "name,latitude,longitude\nRome,41.89193,12.51133\nMilan,north,9.18951\nTurin,45.07049\n",
+			lines:  []int{3, 4},
+		},
+		{
+			name:   "GeoNames",
+			format: importer.FormatGeoNames,
+			data:   geoNamesData + "3165524\tTurin\n",
+			lines:  []int{3},
+		},
+		{
+			name:   "GeoJSON",
+			format: importer.FormatGeoJSON,
+			data: `{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": "12.5,41.9"}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [12.5, 41.9]},
+				 "properties": {"name": "Rome"}}]}`,
+			lines: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "dataset."+tt.format)
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			format, err := importer.New(config.Import{Format: tt.format, Path: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := make([]int, 0)
+			err = format.Read(func(rec importer.Record) error {
+				if rec.Err != nil {
+					lines = append(lines, rec.Line)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(lines) != fmt.Sprint(tt.lines) {
+				t.Errorf("expected errors at lines %v, got %v", tt.lines, lines)
+			}
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := importer.New(config.Import{Format: "xml"})
+	if err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		city  models.City
+		valid bool
+	}{
+		{name: "Valid city", city: models.City{Name: "Rome", Latitude: 41.9, Longitude: 12.5}, valid: true},
+		{name: "Empty name", city: models.City{Latitude: 41.9, Longitude: 12.5}},
+		{name: "Latitude out of range", city: models.City{Name: "Rome", Latitude: 91}},
+		{name: "Longitude out of range", city: models.City{Name: "Rome", Longitude: -181}},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := importer.Validate(tt.city); (err == nil) != tt.valid {
+				t.Errorf("unexpected result of validation: %v", err)
+			}
+		})
+	}
+}
+
+func TestSyntheticID(t *testing.T) {
+	rome := models.City{Name: "Rome", CountryCode: "IT", Latitude: 41.89193, Longitude: 12.51133}
+
+	moved := rome
+	moved.Latitude = 41.9
+
+	withAltNames := rome
+	withAltNames.AlternativeNames = "Roma"
+
+	if importer.SyntheticID(rome) != importer.SyntheticID(withAltNames) {
+		t.Error("id must not depend on the alternative names")
+	}
+
+	if importer.SyntheticID(rome) == importer.SyntheticID(moved) {
+		t.Error("id must depend on the coordinates")
+	}
+}
+
+// benchmarkCities is quantity of the cities in the datasets of benchmarks.
+const benchmarkCities = 150000
+
+func BenchmarkRead(b *testing.B) {
+	dir := b.TempDir()
+
+	var csvBuf, geoJSONBuf, geoNamesBuf strings.Builder
+
+	csvBuf.WriteString("external_id,name,country_code,latitude,longitude\n")
+	geoJSONBuf.WriteString(`{"type": "FeatureCollection", "features": [`)
+
+	for i := 0; i < benchmarkCities; i++ {
+		lat, lon := float64(i%180)-89.5, float64(i%360)-179.5
+
+		fmt.Fprintf(&csvBuf, "%d,City %d,IT,%f,%f\n", i, i, lat, lon)
+
+		if i > 0 {
+			geoJSONBuf.WriteString(",")
+		}
+
+		fmt.Fprintf(&geoJSONBuf, `{"type": "Feature", "id": %d, "geometry": {"type": "Point", "coordinates": [%f, %f]}, "properties": {"name": "City %d", "country_code": "IT"}}`,
+			i, lon, lat, i)
+		fmt.Fprintf(&geoNamesBuf, "%d\tCity %d\tCity %d\tCity,Town\t%f\t%f\tP\tPPL\tIT\t\t07\t\t\t\t1000\t\t20\tEurope/Rome\t2022-01-01\n",
+			i, i, i, lat, lon)
+	}
+
+	geoJSONBuf.WriteString("]}")
+
+	datasets := []struct {
+		format string
+		data   string
+	}{
+		{format: importer.FormatCSV, data: csvBuf.String()},
+		{format: importer.FormatGeoJSON, data: geoJSONBuf.String()},
+		{format: importer.FormatGeoNames, data: geoNamesBuf.String()},
+	}
+
+	for _, ds := range datasets {
+		path := filepath.Join(dir, "cities."+ds.format)
+		if err := os.WriteFile(path, []byte(ds.data), 0o600); err != nil {
+			b.Fatal(err)
+		}
+
+		format, err := importer.New(config.Import{Format: ds.format, Path: path})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+
+		b.Run(fmt.Sprintf("Read %d cities from %s", benchmarkCities, ds.format), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := format.Read(func(rec importer.Record) error {
+					if rec.Err == nil {
+						rec.Err = importer.Validate(rec.City)
+					}
+
+					return rec.Err
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
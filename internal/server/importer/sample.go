@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database/models"
+)
+
+const (
+	FormatSample = "sample"            // format of the sample dataset shipped with the project
+	samplePath   = "sample/cities.zip" // path to the sample archive from the root directory
+)
+
+// CityRaw represents a struct for data from json of the sample dataset.
+type CityRaw struct {
+	Name             string   `json:"name"`
+	NameASCII        string   `json:"ascii_name"`
+	CountryCode      string   `json:"country_code"`
+	CountryName      string   `json:"label_en"`
+	Timezone         string   `json:"timezone"`
+	AlternativeNames []string `json:"alternate_names"`
+	Coordinates      struct {
+		Lon float64 `json:"lon"`
+		Lat float64 `json:"lat"`
+	} `json:"coordinates"`
+}
+
+// Sample reads the sample dataset: JSON array of cities
+// packed in the zip archive.
+type Sample struct {
+	path string
+}
+
+func init() {
+	Register(FormatSample, NewSample)
+}
+
+// NewSample creates the format of the sample dataset.
+// If the path is not specified the archive from the project is used,
+// the root directory of the project is the parent of the directory of the config file.
+func NewSample(cfg config.Import) (Format, error) {
+	path := cfg.Path
+	if path == "" {
+		if cfg.GetConfigDir() == "" {
+			return nil, ErrEmptyPath
+		}
+
+		path = filepath.Join(cfg.GetConfigDir(), "..", samplePath)
+	}
+
+	return &Sample{path: path}, nil
+}
+
+// Fingerprint returns a value which changes when the files of the dataset change.
+func (s *Sample) Fingerprint() string {
+	return fingerprint(s.path)
+}
+
+// Read reads the dataset and calls fn for every city.
+// Cities are decoded one by one without loading the whole file.
+func (s *Sample) Read(fn func(Record) error) error {
+	f, err := openDataset(s.path, hasExt(".json"))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+
+	// skip opening bracket of the array
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for n := 1; dec.More(); n++ {
+		var raw CityRaw
+
+		err := dec.Decode(&raw)
+		if err != nil && !isTypeError(err) {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+
+		err = fn(Record{
+			Line: n,
+			Err:  err,
+			City: models.City{
+				Name:             raw.Name,
+				NameASCII:        raw.NameASCII,
+				AlternativeNames: strings.Join(raw.AlternativeNames, ","),
+				CountryCode:      raw.CountryCode,
+				Country:          raw.CountryName,
+				Timezone:         raw.Timezone,
+				Latitude:         raw.Coordinates.Lat,
+				Longitude:        raw.Coordinates.Lon,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isTypeError checks whether the error is a mismatch of the type of the value.
+// After such error the decoder can continue with the next value.
+func isTypeError(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+
+	return errors.As(err, &typeErr)
+}
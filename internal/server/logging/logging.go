@@ -0,0 +1,154 @@
+// Package logging writes the access log of the HTTP requests and gives
+// the handlers the logger of the request carrying its id, the ids
+// of the trace and the id of the authenticated user.
+package logging
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"math/rand"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+)
+
+// HeaderRequestID is the header of the id of the request. The id of the client
+// is kept if it is valid, otherwise a new one is assigned. The id is sent back
+// in the response.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLen is the maximum length of the id of the client.
+const maxRequestIDLen = 128
+
+// localRequest is the key of the locals keeping the log of the request.
+const localRequest = "logging"
+
+// request is the log of the request.
+type request struct {
+	logger *zap.SugaredLogger // logger with the ids of the request, the trace and the user
+	id     string
+}
+
+// nop is the logger of the requests not passed by the middleware.
+var nop = zap.NewNop().Sugar()
+
+// Middleware assigns the id to the request and writes the request to the log
+// after it is handled: method, route, status, latency, user id and client ip.
+// Requests with the status below 400 are written with the sample ratio of
+// the configuration, client and server errors are written always.
+// It must be used after the middleware of tracing to log the ids of the trace.
+func Middleware(logger *zap.SugaredLogger, cfg config.Log) fiber.Handler {
+	ratio := cfg.GetSampleRatio()
+
+	if logger == nil {
+		logger = nop
+	}
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		} else {
+			id = utils.CopyString(id)
+		}
+
+		c.Set(HeaderRequestID, id)
+
+		req := &request{
+			logger: tracing.Logger(c.UserContext(), logger).With("request_id", id),
+			id:     id,
+		}
+		c.Locals(localRequest, req)
+
+		err := c.Next()
+
+		route, status := metrics.RequestRoute(c, err)
+
+		log := req.logger.Infow
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log = req.logger.Errorw
+		case status >= fiber.StatusBadRequest:
+			log = req.logger.Warnw
+		case !sampled(ratio):
+			return err
+		}
+
+		// the id of the user is added to the logger by SetUser on authentication
+		log("request",
+			"method", utils.CopyString(c.Method()),
+			"route", route,
+			"path", utils.CopyString(c.Path()),
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"ip", utils.CopyString(c.IP()),
+		)
+
+		return err
+	}
+}
+
+// Logger returns the logger of the request, it discards the lines
+// if the request was not passed by the middleware.
+func Logger(c *fiber.Ctx) *zap.SugaredLogger {
+	if req, ok := c.Locals(localRequest).(*request); ok {
+		return req.logger
+	}
+
+	return nop
+}
+
+// RequestID returns the id of the request, empty if the request was not passed by the middleware.
+func RequestID(c *fiber.Ctx) string {
+	if req, ok := c.Locals(localRequest).(*request); ok {
+		return req.id
+	}
+
+	return ""
+}
+
+// SetUser adds the id of the authenticated user to the log of the request.
+func SetUser(c *fiber.Ctx, uid int) {
+	if req, ok := c.Locals(localRequest).(*request); ok {
+		req.logger = req.logger.With("user_id", uid)
+	}
+}
+
+// validRequestID checks that the id of the client is short and contains
+// only letters, digits and the separators, so it is safe for the log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9',
+			b == '-', b == '_', b == '.', b == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns the random id of 32 hex digits.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = crand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// sampled decides whether the request is written to the log.
+func sampled(ratio float64) bool {
+	return ratio >= 1 || rand.Float64() < ratio
+}
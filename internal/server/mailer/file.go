@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File writes the emails to the files of the directory for the local testing,
+// the file is named by the time of the sending and the recipient.
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile returns the mailer writing the emails to the directory.
+func NewFile(dir, from string) *File {
+	return &File{dir: dir, from: from}
+}
+
+// Send writes the email to the new file readable only by the owner,
+// the links of the emails let to log in as the user.
+func (f *File) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+
+	data, err := compose(f.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return err
+	}
+
+	to := strings.NewReplacer("/", "_", "\\", "_").Replace(msg.To)
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + to + ".eml"
+
+	return os.WriteFile(filepath.Join(f.dir, name), data, 0o600)
+}
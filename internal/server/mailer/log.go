@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Log writes the emails to the log for the local testing,
+// the links of the emails are written too, so it is not for production.
+type Log struct {
+	logger *zap.SugaredLogger
+	from   string
+}
+
+// NewLog returns the mailer writing the emails to the log.
+func NewLog(logger *zap.SugaredLogger, from string) *Log {
+	return &Log{logger: logger, from: from}
+}
+
+// Send writes the email to the log.
+func (l *Log) Send(_ context.Context, msg Message) error {
+	l.logger.Infow("email", "from", l.from, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+
+	return nil
+}
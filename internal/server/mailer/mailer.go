@@ -0,0 +1,104 @@
+// Package mailer sends the emails to the users by the SMTP server,
+// to the files of the directory or to the log for the local testing.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"go.uber.org/zap"
+)
+
+// maxAddress is the maximum length of the email address stored in the database.
+const maxAddress = 100
+
+// Message is the plain text email to the user.
+type Message struct {
+	To      string // address of the recipient
+	Subject string
+	Body    string
+}
+
+// Mailer sends the emails.
+type Mailer interface {
+	// Send sends the email, the context limits the time of the sending.
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer of the backend of the configuration,
+// nil if the emails are not sent.
+func New(cfg config.Mail, logger *zap.SugaredLogger) (Mailer, error) {
+	switch cfg.GetBackend() {
+	case config.MailNone:
+		return nil, nil
+	case config.MailSMTP:
+		return NewSMTP(cfg)
+	case config.MailFile:
+		return NewFile(cfg.Dir, cfg.From), nil
+	case config.MailLog:
+		return NewLog(logger, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown backend of the mail %q", cfg.Backend)
+	}
+}
+
+// ValidAddress checks that the string is the bare email address
+// with the domain, the name of the recipient is not allowed.
+func ValidAddress(address string) bool {
+	if len(address) > maxAddress {
+		return false
+	}
+
+	a, err := mail.ParseAddress(address)
+	if err != nil || a.Name != "" || a.Address != address {
+		return false
+	}
+
+	_, domain, _ := strings.Cut(address, "@")
+
+	return strings.Contains(strings.Trim(domain, "."), ".")
+}
+
+// compose returns the email with the headers, the body is encoded by quoted-printable.
+func compose(from string, msg Message, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+
+	headers := [][2]string{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+
+	for _, h := range headers {
+		// the values cannot start the next header
+		if strings.ContainsAny(h[1], "\r\n") {
+			return nil, fmt.Errorf("invalid header %s of the email", h[0])
+		}
+
+		fmt.Fprintf(&b, "%s: %s\r\n", h[0], h[1])
+	}
+
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/mailer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testMessage = mailer.Message{
+	To:      "user@example.com",
+	Subject: "Confirm the email",
+	Body:    "Open the link:\nhttp://localhost:3000/v1/email/verify?token=abc",
+}
+
+func TestValidAddress(t *testing.T) {
+	tests := map[string]bool{
+		"user@example.com":                        true,
+		"first.last+tag@mail.co.uk":               true,
+		"user@localhost":                          false,
+		"@example.com":                            false,
+		"user@":                                   false,
+		"user@@example.com":                       false,
+		"user name@example.com":                   false,
+		"User <user@example.com>":                 false,
+		"user@example.com\r\nBcc: x":              false,
+		strings.Repeat("a", 100) + "@example.com": false,
+	}
+
+	for address, want := range tests {
+		if got := mailer.ValidAddress(address); got != want {
+			t.Errorf("ValidAddress(%q): expected %v, got %v", address, want, got)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := mailer.New(config.Mail{Backend: config.MailFile, Dir: dir, From: "geospace@example.com"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-user@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one email, got %v, %v", files, err)
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"From: geospace@example.com\r\n", "To: user@example.com\r\n",
+		"Subject: Confirm the email\r\n", "verify?token=3Dabc"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("email does not contain %q:\n%s", want, b)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	m, err := mailer.New(config.Mail{Backend: config.MailLog}, zap.New(core).Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.FilterMessage("email").All()
+	if len(entries) != 1 || entries[0].ContextMap()["to"] != testMessage.To {
+		t.Errorf("unexpected log %+v", entries)
+	}
+
+	if m, err := mailer.New(config.Mail{}, nil); m != nil || err != nil {
+		t.Errorf("expected no mailer by default, got %v, %v", m, err)
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+
+	// the server without TLS and authentication accepts one email
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		var (
+			lines []string
+			data  bool
+			r     = bufio.NewReader(conn)
+		)
+
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				received <- lines
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+
+			switch {
+			case data && line == ".":
+				data = false
+				reply("250 queued")
+			case data:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				data = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	n, _ := strconv.Atoi(port)
+
+	m, err := mailer.New(config.Mail{Backend: config.MailSMTP, SMTPHost: host, SMTPPort: n,
+		From: "Geospace <geospace@example.com>"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	session := strings.Join(<-received, "\n")
+	for _, want := range []string{"MAIL FROM:<geospace@example.com>", "RCPT TO:<user@example.com>",
+		`From: "Geospace" <geospace@example.com>`, "Subject: Confirm the email"} {
+		if !strings.Contains(session, want) {
+			t.Errorf("session does not contain %q:\n%s", want, session)
+		}
+	}
+}
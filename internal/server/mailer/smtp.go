@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/config"
+)
+
+const (
+	smtpTimeout = 30 * time.Second // maximum time of the sending if the context has no deadline
+	smtpsPort   = 465              // port of the SMTP server with implicit TLS
+)
+
+// SMTP sends the emails by the SMTP server. The connection is encrypted
+// by STARTTLS if the server supports it or by TLS on the port 465.
+type SMTP struct {
+	host     string
+	addr     string // host:port
+	from     string // header From
+	sender   string // address of the sender for the command MAIL
+	user     string
+	password string
+}
+
+// NewSMTP returns the mailer by the SMTP server of the configuration.
+func NewSMTP(cfg config.Mail) (*SMTP, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("from of the mail: %w", err)
+	}
+
+	return &SMTP{
+		host:     cfg.SMTPHost,
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.GetSMTPPort())),
+		from:     from.String(),
+		sender:   from.Address,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+	}, nil
+}
+
+// Send sends the email by the SMTP server.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := compose(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp server %s: %w", s.addr, err)
+	}
+
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+
+	// PLAIN refuses to send the password over the connection without TLS except localhost
+	if s.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.sender); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// dial connects to the SMTP server, the deadline of the context is set to the connection.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if _, port, _ := net.SplitHostPort(s.addr); port == strconv.Itoa(smtpsPort) {
+		d := tls.Dialer{Config: &tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}}
+		conn, err = d.DialContext(ctx, "tcp", s.addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", s.addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
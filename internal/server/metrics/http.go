@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// routeUnmatched is the label of the requests not matching any route,
+// so the raw paths of the scanners do not create new series.
+const routeUnmatched = "unmatched"
+
+// Middleware counts the requests and their latency by the pattern of the route
+// ("/v1/editor/suggestions/:id"), not by the path of the request.
+func Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	route, status := RequestRoute(c, err)
+	ObserveRequest(utils.CopyString(c.Method()), route, status, time.Since(start))
+
+	return err
+}
+
+// RequestRoute returns the pattern of the route and the status of the handled request,
+// err is the error returned by the handlers. The error is written to the response by
+// the error handler after the middleware, so the status is taken from it.
+// The pattern is copied, strings of fiber refer to the reused buffers.
+func RequestRoute(c *fiber.Ctx, err error) (string, int) {
+	route := c.Route().Path
+	status := c.Response().StatusCode()
+
+	if err != nil {
+		status = fiber.StatusInternalServerError
+
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+
+			// the router returns these errors when no route matches
+			if status == fiber.StatusNotFound || status == fiber.StatusMethodNotAllowed {
+				route = routeUnmatched
+			}
+		}
+	}
+
+	return utils.CopyString(route), status
+}
+
+// Handler returns the handler exposing the metrics of the registry.
+func Handler() fiber.Handler {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
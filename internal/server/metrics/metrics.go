@@ -0,0 +1,146 @@
+// Package metrics collects the metrics of the server in the format of Prometheus:
+// requests and their latency by routes, queries to the database, pools of connections,
+// requests to the routing service, progress of the imports and failures of the authentication.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "geospace"
+
+// results of the requests to the routing service
+const (
+	RoutingSuccess = "success"
+	RoutingFailure = "failure"
+	RoutingTimeout = "timeout"
+)
+
+// reasons of the failures of the authentication
+const (
+	AuthMissingToken    = "missing_token"    // the request has no token
+	AuthInvalidToken    = "invalid_token"    // the token is expired or has invalid signature
+	AuthInvalidAPIKey   = "invalid_api_key"  // the gRPC call with the unknown API key
+	AuthUnknownUser     = "unknown_user"     // login with the email of no user
+	AuthInvalidPassword = "invalid_password" // login with the wrong password
+	AuthForbidden       = "forbidden"        // the user has no required role
+	AuthUnverified      = "unverified"       // the user has not confirmed the email
+)
+
+// Registry is the registry of the metrics of the server.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Quantity of the HTTP requests by the route pattern and the status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by the route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of the queries to the database by the operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"driver", "operation"})
+
+	routingRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routing_requests_total",
+		Help:      "Quantity of the requests of the distances to the routing service by the result.",
+	}, []string{"result"})
+
+	importProcessed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "import_processed_records",
+		Help:      "Quantity of the records of the dataset processed by the running or the last import.",
+	}, []string{"source"})
+
+	importCities = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "import_cities",
+		Help:      "Quantity of the cities changed by the running or the last import by the result.",
+	}, []string{"source", "result"})
+
+	importFinished = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "import_last_finished_timestamp_seconds",
+		Help:      "Time of the end of the last successful import.",
+	}, []string{"source"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Quantity of the failures of the authentication by the reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbDuration, routingRequests,
+		importProcessed, importCities, importFinished, authFailures,
+	)
+}
+
+// ObserveRequest counts the HTTP request to the route pattern.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveQuery records the duration of the query to the database.
+func ObserveQuery(driver, operation string, duration time.Duration) {
+	dbDuration.WithLabelValues(driver, operation).Observe(duration.Seconds())
+}
+
+// ObserveRouting counts the request to the routing service by its error.
+func ObserveRouting(err error) {
+	switch {
+	case err == nil:
+		routingRequests.WithLabelValues(RoutingSuccess).Inc()
+	case errors.Is(err, context.DeadlineExceeded):
+		routingRequests.WithLabelValues(RoutingTimeout).Inc()
+	default:
+		routingRequests.WithLabelValues(RoutingFailure).Inc()
+	}
+}
+
+// ImportProgress records the progress of the import of the source.
+func ImportProgress(source string, processed, inserted, updated, unchanged, deleted, skipped int) {
+	importProcessed.WithLabelValues(source).Set(float64(processed))
+
+	for result, n := range map[string]int{
+		"inserted":  inserted,
+		"updated":   updated,
+		"unchanged": unchanged,
+		"deleted":   deleted,
+		"skipped":   skipped,
+	} {
+		importCities.WithLabelValues(source, result).Set(float64(n))
+	}
+}
+
+// ImportFinished records the end of the successful import of the source.
+func ImportFinished(source string) {
+	importFinished.WithLabelValues(source).SetToCurrentTime()
+}
+
+// AuthFailure counts the failure of the authentication.
+func AuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
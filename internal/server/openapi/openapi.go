@@ -0,0 +1,237 @@
+// Package openapi contains the OpenAPI 3 document of the application,
+// serves it with the documentation and validates requests against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	//go:embed openapi.yaml
+	source []byte
+	//go:embed docs.html
+	docs []byte
+)
+
+// methods of the operations in the path item
+var methods = []string{
+	fiber.MethodGet, fiber.MethodPut, fiber.MethodPost, fiber.MethodDelete,
+	fiber.MethodOptions, fiber.MethodHead, fiber.MethodPatch, fiber.MethodTrace,
+}
+
+type (
+	// Document is the part of the OpenAPI document needed for validation of the requests.
+	Document struct {
+		Paths      map[string]map[string]*Operation `json:"paths"`
+		Components Components                       `json:"components"`
+		raw        []byte                           // the document in JSON
+		routes     []route                          // operations for matching of the requests
+	}
+
+	// Components contains reusable objects of the document.
+	Components struct {
+		Parameters    map[string]*Parameter   `json:"parameters"`
+		RequestBodies map[string]*RequestBody `json:"requestBodies"`
+		Schemas       map[string]*Schema      `json:"schemas"`
+	}
+
+	// Operation describes the route.
+	Operation struct {
+		RequestBody *RequestBody `json:"requestBody"`
+		Summary     string       `json:"summary"`
+		Parameters  []*Parameter `json:"parameters"`
+	}
+
+	// Parameter describes the parameter of the query, the path or the header.
+	Parameter struct {
+		Schema   *Schema `json:"schema"`
+		Ref      string  `json:"$ref"`
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required"`
+	}
+
+	// RequestBody describes the body of the request.
+	RequestBody struct {
+		Content  map[string]MediaType `json:"content"`
+		Ref      string               `json:"$ref"`
+		Required bool                 `json:"required"`
+	}
+
+	// MediaType contains the schema of the content.
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Schema describes the value.
+	Schema struct {
+		Minimum    *float64           `json:"minimum"`
+		Maximum    *float64           `json:"maximum"`
+		MinLength  *int               `json:"minLength"`
+		Items      *Schema            `json:"items"`
+		Properties map[string]*Schema `json:"properties"`
+		Ref        string             `json:"$ref"`
+		Type       string             `json:"type"`
+		Format     string             `json:"format"`
+		Enum       []any              `json:"enum"`
+		Required   []string           `json:"required"`
+		AllOf      []*Schema          `json:"allOf"`
+	}
+
+	// route is the operation with the path split to segments.
+	route struct {
+		op       *Operation
+		method   string
+		path     string
+		segments []string
+	}
+)
+
+// Load parses the embedded document.
+func Load() (*Document, error) {
+	return Parse(source)
+}
+
+// Parse parses the document in YAML or JSON.
+func Parse(data []byte) (*Document, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+
+	// yaml.v2 decodes objects to map[interface{}]interface{}, which JSON does not support
+	b, err := json.Marshal(convert(raw))
+	if err != nil {
+		return nil, fmt.Errorf("convert OpenAPI document to JSON: %w", err)
+	}
+
+	doc := &Document{raw: b}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+
+	for path, item := range doc.Paths {
+		for _, method := range methods {
+			op, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+
+			doc.routes = append(doc.routes, route{
+				op:       op,
+				method:   method,
+				path:     path,
+				segments: strings.Split(strings.Trim(path, "/"), "/"),
+			})
+		}
+	}
+
+	// paths without parameters have precedence over templated paths
+	sort.Slice(doc.routes, func(i, j int) bool {
+		pi, pj := strings.Count(doc.routes[i].path, "{"), strings.Count(doc.routes[j].path, "{")
+		if pi != pj {
+			return pi < pj
+		}
+
+		return doc.routes[i].path < doc.routes[j].path
+	})
+
+	return doc, nil
+}
+
+// Has checks that the document describes the operation. The path
+// can be in the format of OpenAPI (/cities/{id}) or of fiber (/cities/:id).
+func (d *Document) Has(method, path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + strings.TrimPrefix(s, ":") + "}"
+		}
+	}
+
+	path = "/" + strings.Join(segments, "/")
+	for _, r := range d.routes {
+		if r.method == method && r.path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Spec sends the document in JSON.
+func (d *Document) Spec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(d.raw)
+}
+
+// Docs sends the page of the documentation, which renders the document
+// without any external resources.
+func (d *Document) Docs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docs)
+}
+
+// match returns the operation of the request and values of the parameters of the path.
+func (d *Document) match(method, path string) (*Operation, map[string]string) {
+	if method == fiber.MethodHead {
+		method = fiber.MethodGet
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, r := range d.routes {
+		if r.method != method || len(r.segments) != len(segments) {
+			continue
+		}
+
+		params := make(map[string]string)
+		matched := true
+
+		for i, s := range r.segments {
+			if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+				params[s[1:len(s)-1]] = segments[i]
+				continue
+			}
+
+			if s != segments[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return r.op, params
+		}
+	}
+
+	return nil, nil
+}
+
+// convert replaces the maps of YAML with maps with string keys.
+func convert(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = convert(val)
+		}
+
+		return m
+	case []any:
+		for i, val := range v {
+			v[i] = convert(val)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
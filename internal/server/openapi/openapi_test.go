@@ -0,0 +1,134 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := fiber.New()
+	srv.Get("/openapi.json", doc.Spec)
+
+	resp, err := srv.Test(httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	var spec struct {
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		OpenAPI string                                `json:"openapi"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || len(spec.Paths) == 0 {
+		t.Errorf("invalid document: %+v", spec)
+	}
+
+	if !doc.Has(fiber.MethodPost, "/v1/editor/suggestions/:id/approve") {
+		t.Error("route with the parameter is not found")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := fiber.New()
+	srv.Use(doc.Validate(func(c *fiber.Ctx, err error) error {
+		if !errors.Is(err, openapi.ErrInvalidRequest) {
+			t.Errorf("error must wrap ErrInvalidRequest: %v", err)
+		}
+
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}))
+	srv.Use(func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		errMsg string
+	}{
+		{name: "Valid query", method: fiber.MethodGet, target: "/v1/api/find-by-coord?lat=41.9&lon=12.5&distanceTo=100"},
+		{name: "Missing parameter", method: fiber.MethodGet, target: "/v1/api/find-by-coord?lat=41.9&lon=12.5",
+			errMsg: "parameter distanceTo is required"},
+		{name: "Empty parameter", method: fiber.MethodGet, target: "/v1/api/distance?departure=&destination=Rome",
+			errMsg: "parameter departure is required"},
+		{name: "Not a number", method: fiber.MethodGet, target: "/v1/api/reverse?lat=north&lon=12.5",
+			errMsg: "parameter lat must be a number"},
+		{name: "Out of range", method: fiber.MethodGet, target: "/v1/api/reverse?lat=95&lon=12.5",
+			errMsg: "lat must be less than or equal to 90"},
+		{name: "Not in enum", method: fiber.MethodGet, target: "/v1/user/suggestions?status=unknown",
+			errMsg: "status must be one of"},
+		{name: "Invalid path parameter", method: fiber.MethodGet, target: "/v1/editor/suggestions/first",
+			errMsg: "parameter id must be an integer"},
+		{name: "Valid path parameter", method: fiber.MethodGet, target: "/v1/editor/suggestions/1"},
+		{name: "Valid body", method: fiber.MethodPost, target: "/v2/login",
+			body: `{"email":"user@example.com","password":"secret"}`},
+		{name: "Missing field", method: fiber.MethodPost, target: "/v2/login", body: `{"email":"user@example.com"}`,
+			errMsg: "body.password is required"},
+		{name: "Wrong type of field", method: fiber.MethodPost, target: "/v1/user/suggestions",
+			body: `{"name":"Rome","latitude":"north"}`, errMsg: "body.latitude must be a number"},
+		{name: "Missing body", method: fiber.MethodPost, target: "/v1/register", errMsg: "request body is required"},
+		{name: "Optional body", method: fiber.MethodPost, target: "/v1/editor/suggestions/1/approve"},
+		{name: "Unknown route", method: fiber.MethodGet, target: "/v3/anything?lat=north"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, body)
+			if tt.body != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+
+			resp, err := srv.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer resp.Body.Close()
+
+			msg, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.errMsg == "" {
+				if resp.StatusCode != fiber.StatusOK {
+					t.Errorf("request must be valid, got %s", msg)
+				}
+
+				return
+			}
+
+			if resp.StatusCode != fiber.StatusBadRequest || !strings.Contains(string(msg), tt.errMsg) {
+				t.Errorf("expected error %q, got %d %s", tt.errMsg, resp.StatusCode, msg)
+			}
+		})
+	}
+}
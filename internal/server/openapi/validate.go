@@ -0,0 +1,284 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrInvalidRequest is returned when the request does not match the document.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// prefixes of the references to the components
+const (
+	refParameters    = "#/components/parameters/"
+	refRequestBodies = "#/components/requestBodies/"
+	refSchemas       = "#/components/schemas/"
+)
+
+// Validate returns the middleware, which validates the parameters and the JSON body
+// of the request against the operation of the document and calls onError
+// with the error wrapping ErrInvalidRequest. Requests of the routes
+// unknown to the document are passed as is.
+func (d *Document) Validate(onError func(c *fiber.Ctx, err error) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		op, params := d.match(c.Method(), c.Path())
+		if op == nil {
+			return c.Next()
+		}
+
+		if err := d.validateRequest(c, op, params); err != nil {
+			return onError(c, fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+		}
+
+		return c.Next()
+	}
+}
+
+// validateRequest validates the request against the operation.
+func (d *Document) validateRequest(c *fiber.Ctx, op *Operation, pathParams map[string]string) error {
+	for _, p := range op.Parameters {
+		p = d.parameter(p)
+		if p == nil || p.Schema == nil {
+			continue
+		}
+
+		var (
+			raw     string
+			present bool
+		)
+
+		switch p.In {
+		case "query":
+			present = c.Context().QueryArgs().Has(p.Name)
+			raw = c.Query(p.Name)
+		case "path":
+			raw, present = pathParams[p.Name]
+		case "header":
+			raw = c.Get(p.Name)
+			present = raw != ""
+		default:
+			continue
+		}
+
+		if !present || raw == "" {
+			if p.Required {
+				return fmt.Errorf("parameter %s is required", p.Name)
+			}
+
+			continue
+		}
+
+		v, err := d.parseParameter(raw, p.Schema)
+		if err != nil {
+			return fmt.Errorf("parameter %s %v", p.Name, err)
+		}
+
+		if err := d.validateValue(p.Name, v, p.Schema); err != nil {
+			return err
+		}
+	}
+
+	return d.validateBody(c, op.RequestBody)
+}
+
+// validateBody validates the JSON body of the request. Bodies of other
+// media types are not validated.
+func (d *Document) validateBody(c *fiber.Ctx, body *RequestBody) error {
+	body = d.requestBody(body)
+	if body == nil {
+		return nil
+	}
+
+	if len(c.Body()) == 0 {
+		if body.Required {
+			return errors.New("request body is required")
+		}
+
+		return nil
+	}
+
+	mt, ok := body.Content[fiber.MIMEApplicationJSON]
+	if !ok || mt.Schema == nil || !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal(c.Body(), &v); err != nil {
+		return fmt.Errorf("request body is not valid JSON: %v", err)
+	}
+
+	return d.validateValue("body", v, mt.Schema)
+}
+
+// parseParameter converts the raw value of the parameter to the type of the schema.
+func (d *Document) parseParameter(raw string, s *Schema) (any, error) {
+	s = d.schema(s)
+	if s == nil {
+		return raw, nil
+	}
+
+	switch s.Type {
+	case "integer":
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+
+		return float64(v), nil
+	case "number":
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+
+		return v, nil
+	default:
+		return raw, nil
+	}
+}
+
+// validateValue validates the value decoded from JSON against the schema.
+func (d *Document) validateValue(name string, v any, s *Schema) error {
+	s = d.schema(s)
+	if s == nil {
+		return nil
+	}
+
+	for _, sub := range s.AllOf {
+		if err := d.validateValue(name, v, sub); err != nil {
+			return err
+		}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", name)
+		}
+
+		if s.MinLength != nil && len(strings.TrimSpace(str)) < *s.MinLength {
+			return fmt.Errorf("%s must contain at least %d characters", name, *s.MinLength)
+		}
+
+		if s.Format == "email" && !strings.Contains(str, "@") {
+			return fmt.Errorf("%s must be an email", name)
+		}
+	case "integer", "number":
+		num, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s must be a number", name)
+		}
+
+		if s.Type == "integer" && num != math.Trunc(num) {
+			return fmt.Errorf("%s must be an integer", name)
+		}
+
+		if s.Minimum != nil && num < *s.Minimum {
+			return fmt.Errorf("%s must be greater than or equal to %v", name, *s.Minimum)
+		}
+
+		if s.Maximum != nil && num > *s.Maximum {
+			return fmt.Errorf("%s must be less than or equal to %v", name, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", name)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", name)
+		}
+
+		for i, item := range items {
+			if err := d.validateValue(fmt.Sprintf("%s[%d]", name, i), item, s.Items); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be an object", name)
+		}
+
+		for _, field := range s.Required {
+			if _, ok := obj[field]; !ok {
+				return fmt.Errorf("%s.%s is required", name, field)
+			}
+		}
+
+		// fields are validated in order, so the error is the same for the same request
+		fields := make([]string, 0, len(s.Properties))
+		for field := range s.Properties {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			if val, ok := obj[field]; ok {
+				if err := d.validateValue(name+"."+field, val, s.Properties[field]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		for _, e := range s.Enum {
+			if e == v {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%s must be one of %v", name, s.Enum)
+	}
+
+	return nil
+}
+
+// parameter resolves the reference to the parameter.
+func (d *Document) parameter(p *Parameter) *Parameter {
+	if p != nil && p.Ref != "" {
+		return d.Components.Parameters[strings.TrimPrefix(p.Ref, refParameters)]
+	}
+
+	return p
+}
+
+// requestBody resolves the reference to the request body.
+func (d *Document) requestBody(b *RequestBody) *RequestBody {
+	if b != nil && b.Ref != "" {
+		return d.Components.RequestBodies[strings.TrimPrefix(b.Ref, refRequestBodies)]
+	}
+
+	return b
+}
+
+// schema resolves the reference to the schema.
+func (d *Document) schema(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, refSchemas)]
+	}
+
+	return s
+}
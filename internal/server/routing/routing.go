@@ -0,0 +1,182 @@
+// Package routing calculates distances by road using the routing service of OpenStreetMap (OSRM).
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaults of the routing service
+const (
+	DefaultBaseURL = "http://router.project-osrm.org" // public OSRM server
+	DefaultTimeout = 500 * time.Millisecond           // maximum time of the request
+)
+
+// typical errors
+var (
+	ErrNotAvailable = errors.New("no access to service")
+	ErrEmptyResults = errors.New("was get empty results")
+)
+
+// service is the address and the timeout of the routing service.
+type service struct {
+	baseURL string
+	timeout time.Duration
+}
+
+// current is the routing service, it is replaced on the reload of the configuration.
+var current atomic.Pointer[service]
+
+func init() {
+	Configure(DefaultBaseURL, DefaultTimeout)
+}
+
+// Configure sets the address and the timeout of the routing service,
+// the requests in flight are finished with the previous ones.
+func Configure(baseURL string, timeout time.Duration) {
+	current.Store(&service{baseURL: baseURL, timeout: timeout})
+}
+
+// Cacher keeps the distances between the points.
+type Cacher interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+}
+
+// Cache keeps the distances calculated by the routing service, nil disables caching.
+var Cache Cacher
+
+// Distance returns the distance between two points by road in km.
+// The distance is taken from the cache if it was calculated before.
+func Distance(ctx context.Context, lon1, lat1, lon2, lat2 float64) (int, error) {
+	if Cache == nil {
+		return distance(ctx, lon1, lat1, lon2, lat2)
+	}
+
+	// coordinates are rounded to about 10 m, so the close points share the entry
+	key := fmt.Sprintf("distance:%.4f,%.4f;%.4f,%.4f", lon1, lat1, lon2, lat2)
+
+	if value, ok := Cache.Get(ctx, key); ok {
+		if dist, err := strconv.Atoi(string(value)); err == nil {
+			return dist, nil
+		}
+	}
+
+	dist, err := distance(ctx, lon1, lat1, lon2, lat2)
+	if err != nil {
+		return 0, err
+	}
+
+	Cache.Set(ctx, key, []byte(strconv.Itoa(dist)))
+
+	return dist, nil
+}
+
+// distance requests the distance between two points by road in km
+// from the routing service and counts the result of the request.
+func distance(ctx context.Context, lon1, lat1, lon2, lat2 float64) (int, error) {
+	ctx, span := tracing.Start(ctx, "routing.distance", trace.SpanKindClient)
+	defer span.End()
+
+	dist, err := request(ctx, lon1, lat1, lon2, lat2)
+	metrics.ObserveRouting(err)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return dist, err
+}
+
+// request requests the distance between two points by road in km from the routing service.
+func request(ctx context.Context, lon1, lat1, lon2, lat2 float64) (int, error) {
+	srv := current.Load()
+
+	ctx, cancel := context.WithTimeout(ctx, srv.timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/route/v1/driving/%f,%f;%f,%f?overview=false", srv.baseURL, lon1, lat1, lon2, lat2)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// the routing service continues the trace if it supports it
+	trace.SpanFromContext(ctx).SetAttributes(semconv.ServerAddress(req.URL.Hostname()))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNotAvailable, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, ErrNotAvailable
+	}
+
+	var response struct {
+		Code   string `json:"code"`
+		Routes []struct {
+			Distance float64 `json:"distance"`
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNotAvailable, err)
+	}
+
+	if response.Code != "Ok" {
+		return 0, ErrNotAvailable
+	}
+
+	if len(response.Routes) == 0 {
+		return 0, ErrEmptyResults
+	}
+
+	return int(response.Routes[0].Distance / 1000), nil
+}
+
+// Ping checks that the routing service is reachable. Any response of the service
+// means it is reachable, its root does not answer with the status 200.
+func Ping(ctx context.Context) error {
+	srv := current.Load()
+
+	ctx, cancel := context.WithTimeout(ctx, srv.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, srv.baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotAvailable, err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return ErrNotAvailable
+	}
+
+	return nil
+}
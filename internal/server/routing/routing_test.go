@@ -0,0 +1,90 @@
+package routing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alaleks/geospace/internal/server/routing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		err    error
+		name   string
+		body   string
+		status int
+		delay  time.Duration
+		distKm int
+	}{
+		{name: "Route", status: http.StatusOK, body: `{"code":"Ok","routes":[{"distance":527431.5}]}`, distKm: 527},
+		{name: "No route", status: http.StatusOK, body: `{"code":"Ok","routes":[]}`, err: routing.ErrEmptyResults},
+		{name: "Error code", status: http.StatusOK, body: `{"code":"NoRoute"}`, err: routing.ErrNotAvailable},
+		{name: "Server error", status: http.StatusInternalServerError, err: routing.ErrNotAvailable},
+		{name: "Timeout", status: http.StatusOK, body: `{"code":"Ok"}`, delay: 2 * routing.DefaultTimeout,
+			err: routing.ErrNotAvailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.delay):
+				case <-r.Context().Done():
+					return
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			routing.Configure(srv.URL, routing.DefaultTimeout)
+
+			dist, err := routing.Distance(context.Background(), 12.5, 41.9, 9.19, 45.46)
+			if !errors.Is(err, tt.err) || dist != tt.distKm {
+				t.Errorf("expected %d, %v, got %d, %v", tt.distKm, tt.err, dist, err)
+			}
+		})
+	}
+}
+
+type mapCache map[string][]byte
+
+func (m mapCache) Get(_ context.Context, key string) ([]byte, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (m mapCache) Set(_ context.Context, key string, value []byte) {
+	m[key] = value
+}
+
+func TestDistanceCached(t *testing.T) {
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"code":"Ok","routes":[{"distance":527431.5}]}`))
+	}))
+	defer srv.Close()
+
+	routing.Configure(srv.URL, routing.DefaultTimeout)
+	routing.Cache = mapCache{}
+
+	defer func() { routing.Cache = nil }()
+
+	for i := 0; i < 3; i++ {
+		dist, err := routing.Distance(context.Background(), 12.5, 41.9, 9.19, 45.46)
+		if err != nil || dist != 527 {
+			t.Fatalf("expected 527, got %d, %v", dist, err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request to the service, got %d", requests)
+	}
+}
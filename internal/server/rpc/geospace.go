@@ -0,0 +1,314 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/alaleks/geospace/pkg/distance"
+	"github.com/alaleks/geospace/pkg/geospacepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxBatchSize is the maximum number of queries in BatchLookup.
+const MaxBatchSize = 1000
+
+// Distance returns the distance between two cities.
+func (s *Server) Distance(ctx context.Context, req *geospacepb.DistanceRequest) (*geospacepb.DistanceResponse, error) {
+	if err := requireName("departure", req.GetDeparture()); err != nil {
+		return nil, err
+	}
+
+	if err := requireName("destination", req.GetDestination()); err != nil {
+		return nil, err
+	}
+
+	departure, err := s.db.ResolveCity(ctx, req.GetDeparture())
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("departure: %w", err))
+	}
+
+	destination, err := s.db.ResolveCity(ctx, req.GetDestination())
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("destination: %w", err))
+	}
+
+	road, err := routing.Distance(ctx, departure.Longitude, departure.Latitude,
+		destination.Longitude, destination.Latitude)
+	if err != nil && req.GetRequireRoad() {
+		return nil, toStatus(fmt.Errorf("distance by road: %w", err))
+	}
+
+	return &geospacepb.DistanceResponse{
+		Departure:   toCity(departure),
+		Destination: toCity(destination),
+		DistanceStraight: int32(distance.CalcGreatCircle(
+			departure.Latitude, departure.Longitude,
+			destination.Latitude, destination.Longitude)),
+		DistanceRoad: int32(road),
+	}, nil
+}
+
+// NearbyByName returns the cities near the city of departure.
+func (s *Server) NearbyByName(ctx context.Context, req *geospacepb.NearbyByNameRequest) (*geospacepb.NearbyResponse, error) {
+	if err := requireName("departure", req.GetDeparture()); err != nil {
+		return nil, err
+	}
+
+	if err := requireDistance(req.GetDistance()); err != nil {
+		return nil, err
+	}
+
+	departure, err := s.db.ResolveCity(ctx, req.GetDeparture())
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("departure: %w", err))
+	}
+
+	resp := &geospacepb.NearbyResponse{Departure: toCity(departure)}
+
+	err = s.db.EachObjectNearByCoord(ctx, departure.Latitude, departure.Longitude, int(req.GetDistance()),
+		func(city models.City) error {
+			resp.Cities = append(resp.Cities, toCityNearby(city, departure.Latitude, departure.Longitude))
+			return nil
+		})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return resp, nil
+}
+
+// NearbyByCoord returns the cities near the coordinates.
+func (s *Server) NearbyByCoord(ctx context.Context, req *geospacepb.NearbyByCoordRequest) (*geospacepb.NearbyResponse, error) {
+	if err := requirePoint(req.GetPoint()); err != nil {
+		return nil, err
+	}
+
+	if err := requireDistance(req.GetDistance()); err != nil {
+		return nil, err
+	}
+
+	lat, lon := req.GetPoint().GetLatitude(), req.GetPoint().GetLongitude()
+	resp := &geospacepb.NearbyResponse{}
+
+	err := s.db.EachObjectNearByCoord(ctx, lat, lon, int(req.GetDistance()), func(city models.City) error {
+		resp.Cities = append(resp.Cities, toCityNearby(city, lat, lon))
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return resp, nil
+}
+
+// StreamNearby streams the cities near the city or the coordinates one by one.
+func (s *Server) StreamNearby(req *geospacepb.StreamNearbyRequest, stream geospacepb.Geospace_StreamNearbyServer) error {
+	ctx := stream.Context()
+
+	if err := requireDistance(req.GetDistance()); err != nil {
+		return err
+	}
+
+	var lat, lon float64
+
+	switch origin := req.GetOrigin().(type) {
+	case *geospacepb.StreamNearbyRequest_Departure:
+		if err := requireName("departure", origin.Departure); err != nil {
+			return err
+		}
+
+		departure, err := s.db.ResolveCity(ctx, origin.Departure)
+		if err != nil {
+			return toStatus(fmt.Errorf("departure: %w", err))
+		}
+
+		lat, lon = departure.Latitude, departure.Longitude
+	case *geospacepb.StreamNearbyRequest_Point:
+		if err := requirePoint(origin.Point); err != nil {
+			return err
+		}
+
+		lat, lon = origin.Point.GetLatitude(), origin.Point.GetLongitude()
+	default:
+		return status.Error(codes.InvalidArgument, "departure or point is required")
+	}
+
+	err := s.db.EachObjectNearByCoord(ctx, lat, lon, int(req.GetDistance()), func(city models.City) error {
+		// Send fails when the client has gone, which stops reading of the rows
+		return stream.Send(toCityNearby(city, lat, lon))
+	})
+
+	return toStatus(err)
+}
+
+// Reverse returns the city nearest to the coordinates.
+func (s *Server) Reverse(ctx context.Context, req *geospacepb.ReverseRequest) (*geospacepb.ReverseResponse, error) {
+	if err := requirePoint(req.GetPoint()); err != nil {
+		return nil, err
+	}
+
+	nearest, err := s.reverse(ctx, req.GetPoint())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &geospacepb.ReverseResponse{Nearest: nearest}, nil
+}
+
+// BatchLookup resolves several names of the cities and coordinates at once.
+func (s *Server) BatchLookup(ctx context.Context, req *geospacepb.BatchLookupRequest) (*geospacepb.BatchLookupResponse, error) {
+	if len(req.GetQueries()) > MaxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch cannot contain more than %d queries", MaxBatchSize)
+	}
+
+	resp := &geospacepb.BatchLookupResponse{
+		Results: make([]*geospacepb.LookupResult, 0, len(req.GetQueries())),
+	}
+
+	for _, query := range req.GetQueries() {
+		// the client has gone or the deadline is exceeded
+		if err := ctx.Err(); err != nil {
+			return nil, toStatus(err)
+		}
+
+		result := &geospacepb.LookupResult{Query: query}
+
+		city, err := s.lookup(ctx, query)
+		if err != nil {
+			result.Result = &geospacepb.LookupResult_Error{Error: toLookupError(err)}
+		} else {
+			result.Result = &geospacepb.LookupResult_City{City: city}
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+// lookup performs one query of the batch.
+func (s *Server) lookup(ctx context.Context, query *geospacepb.LookupQuery) (*geospacepb.CityNearby, error) {
+	switch q := query.GetQuery().(type) {
+	case *geospacepb.LookupQuery_Name:
+		if err := requireName("name", q.Name); err != nil {
+			return nil, err
+		}
+
+		city, err := s.db.ResolveCity(ctx, q.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		return &geospacepb.CityNearby{City: toCity(city)}, nil
+	case *geospacepb.LookupQuery_Point:
+		if err := requirePoint(q.Point); err != nil {
+			return nil, err
+		}
+
+		return s.reverse(ctx, q.Point)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "name or point is required")
+	}
+}
+
+// reverse returns the city nearest to the point.
+func (s *Server) reverse(ctx context.Context, point *geospacepb.Point) (*geospacepb.CityNearby, error) {
+	city, err := s.db.FindNearestCity(ctx, point.GetLatitude(), point.GetLongitude())
+	if err != nil {
+		return nil, err
+	}
+
+	return toCityNearby(city, point.GetLatitude(), point.GetLongitude()), nil
+}
+
+// requireName checks that the name is not empty.
+func requireName(field, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return status.Errorf(codes.InvalidArgument, "%s cannot be empty", field)
+	}
+
+	return nil
+}
+
+// requireDistance checks that the radius of the search is positive.
+func requireDistance(dist int32) error {
+	if dist <= 0 {
+		return status.Error(codes.InvalidArgument, "distance must be a positive number of km")
+	}
+
+	return nil
+}
+
+// requirePoint checks that the point is set and its coordinates are in range.
+func requirePoint(p *geospacepb.Point) error {
+	switch {
+	case p == nil:
+		return status.Error(codes.InvalidArgument, "point is required")
+	case p.GetLatitude() < -90 || p.GetLatitude() > 90:
+		return status.Error(codes.InvalidArgument, "latitude must be in range [-90, 90]")
+	case p.GetLongitude() < -180 || p.GetLongitude() > 180:
+		return status.Error(codes.InvalidArgument, "longitude must be in range [-180, 180]")
+	}
+
+	return nil
+}
+
+// toLookupError converts the error of the lookup to the message.
+func toLookupError(err error) *geospacepb.LookupError {
+	st, _ := status.FromError(toStatus(err))
+	lookupErr := &geospacepb.LookupError{
+		Code:    codeName(st.Code()),
+		Message: st.Message(),
+	}
+
+	var ambiguous *database.AmbiguousCityError
+	if errors.As(err, &ambiguous) {
+		for _, city := range ambiguous.Candidates {
+			lookupErr.Candidates = append(lookupErr.Candidates, toCity(city))
+		}
+	}
+
+	return lookupErr
+}
+
+// codeName returns the name of the code as in the gRPC specification, e.g. NOT_FOUND.
+func codeName(code codes.Code) string {
+	var b strings.Builder
+
+	for i, r := range code.String() {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return strings.ToUpper(b.String())
+}
+
+// toCity converts the city to the message.
+func toCity(city models.City) *geospacepb.City {
+	return &geospacepb.City{
+		Id:          int64(city.ID),
+		Name:        city.Name,
+		NameAscii:   city.NameASCII,
+		CountryCode: city.CountryCode,
+		Country:     city.Country,
+		Timezone:    city.Timezone,
+		Location:    &geospacepb.Point{Latitude: city.Latitude, Longitude: city.Longitude},
+	}
+}
+
+// toCityNearby converts the city to the message with the distance from the point.
+func toCityNearby(city models.City, lat, lon float64) *geospacepb.CityNearby {
+	return &geospacepb.CityNearby{
+		City:     toCity(city),
+		Distance: int32(distance.CalcGreatCircle(lat, lon, city.Latitude, city.Longitude)),
+	}
+}
@@ -0,0 +1,160 @@
+// Package rpc implements the gRPC API of the application. It uses
+// the same database and authentication as the REST API.
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/alaleks/geospace/pkg/geospacepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// keys of the metadata
+const (
+	MetadataAuthorization = "authorization"
+	MetadataAPIKey        = "x-api-key"
+)
+
+// Server implements the Geospace service.
+type Server struct {
+	geospacepb.UnimplementedGeospaceServer
+	db   database.Cities
+	auth *authentication.Auth
+}
+
+// uidKey is the key of the id of the user in the context,
+// calls authenticated by the API key have no user.
+type uidKey struct{}
+
+// New creates a new gRPC server with registered Geospace service,
+// every call of the service must be authenticated.
+func New(db database.Cities, auth *authentication.Auth, opts ...grpc.ServerOption) *grpc.Server {
+	s := &Server{
+		db:   db,
+		auth: auth,
+	}
+
+	opts = append(opts,
+		grpc.UnaryInterceptor(s.unaryAuth),
+		grpc.StreamInterceptor(s.streamAuth),
+	)
+
+	srv := grpc.NewServer(opts...)
+	geospacepb.RegisterGeospaceServer(srv, s)
+
+	return srv
+}
+
+// UserID returns the id of the authenticated user, false if the call
+// is authenticated by the API key.
+func UserID(ctx context.Context) (int, bool) {
+	uid, ok := ctx.Value(uidKey{}).(int)
+	return uid, ok
+}
+
+// unaryAuth authenticates unary calls.
+func (s *Server) unaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// streamAuth authenticates streaming calls.
+func (s *Server) streamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate checks the JWT of the verified user in the metadata "authorization"
+// or the API key of the service in the metadata "x-api-key".
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get(MetadataAuthorization); len(values) > 0 {
+		token := strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer "))
+
+		// the tokens issued before the last change of the password are rejected
+		user, err := s.auth.CheckTokenUser(ctx, token)
+		if err != nil {
+			metrics.AuthFailure(metrics.AuthInvalidToken)
+			return ctx, status.Error(codes.Unauthenticated, "token is invalid")
+		}
+
+		// the users have the data only after the confirmation of the email as in /v1/api
+		if !user.Verified {
+			metrics.AuthFailure(metrics.AuthUnverified)
+			return ctx, status.Error(codes.PermissionDenied, "email is not verified")
+		}
+
+		return context.WithValue(ctx, uidKey{}, user.UID), nil
+	}
+
+	if values := md.Get(MetadataAPIKey); len(values) > 0 {
+		if err := s.auth.CheckAPIKey(values[0]); err != nil {
+			metrics.AuthFailure(metrics.AuthInvalidAPIKey)
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return ctx, nil
+	}
+
+	metrics.AuthFailure(metrics.AuthMissingToken)
+
+	return ctx, status.Error(codes.Unauthenticated, "token or API key is required")
+}
+
+// authStream replaces the context of the stream with the authenticated context.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// toStatus converts the error to the gRPC status. Messages of the errors
+// unknown to the application are not sent to clients.
+func toStatus(err error) error {
+	var ambiguous *database.AmbiguousCityError
+
+	switch {
+	case err == nil:
+		return nil
+	case status.Code(err) != codes.Unknown:
+		return err
+	case errors.As(err, &ambiguous):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, database.ErrCityNotFound), errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, routing.ErrNotAvailable), errors.Is(err, routing.ErrEmptyResults):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
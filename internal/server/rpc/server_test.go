@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/app/authentication"
+	"github.com/alaleks/geospace/internal/server/config"
+	"github.com/alaleks/geospace/internal/server/database"
+	"github.com/alaleks/geospace/internal/server/database/memory"
+	"github.com/alaleks/geospace/internal/server/database/models"
+	"github.com/alaleks/geospace/internal/server/routing"
+	"github.com/alaleks/geospace/pkg/geospacepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial starts the server with the verified user 1 and the unverified user 2
+// in memory and returns the client.
+func dial(t *testing.T) (geospacepb.GeospaceClient, *authentication.Auth) {
+	t.Helper()
+
+	store := memory.New()
+	store.AddUser(models.User{Name: "user", Email: "user@example.com", Verified: true})
+	store.AddUser(models.User{Name: "unverified", Email: "unverified@example.com"})
+
+	auth := authentication.Init(store, config.Secure{
+		SecretJWT: "c2VjcmV0", Key: "a2V5", IV: "MTIzNDU2Nzg=", APIKeys: []string{"service-key"},
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	srv := New(nil, auth)
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return geospacepb.NewGeospaceClient(conn), auth
+}
+
+func TestAuthentication(t *testing.T) {
+	client, auth := dial(t)
+
+	token, err := auth.GetTokenJWT(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unverified, err := auth.GetTokenJWT(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{name: "No credentials", md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "Invalid token", md: metadata.Pairs(MetadataAuthorization, "Bearer invalid"), code: codes.Unauthenticated},
+		{name: "Invalid API key", md: metadata.Pairs(MetadataAPIKey, "invalid"), code: codes.Unauthenticated},
+		// the request is empty, so the authenticated call fails on validation
+		{name: "Token", md: metadata.Pairs(MetadataAuthorization, "Bearer "+token), code: codes.InvalidArgument},
+		{name: "API key", md: metadata.Pairs(MetadataAPIKey, "service-key"), code: codes.InvalidArgument},
+		{name: "Unverified", md: metadata.Pairs(MetadataAuthorization, "Bearer "+unverified), code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+
+			_, err := client.Reverse(ctx, &geospacepb.ReverseRequest{})
+			if status.Code(err) != tt.code {
+				t.Errorf("unary: expected %s, got %v", tt.code, err)
+			}
+
+			stream, err := client.StreamNearby(ctx, &geospacepb.StreamNearbyRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+
+			if status.Code(err) != tt.code {
+				t.Errorf("stream: expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	client, _ := dial(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataAPIKey, "service-key")
+
+	_, err := client.NearbyByCoord(ctx, &geospacepb.NearbyByCoordRequest{
+		Point: &geospacepb.Point{Latitude: 91}, Distance: 10,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("latitude out of range: expected InvalidArgument, got %v", err)
+	}
+
+	_, err = client.NearbyByName(ctx, &geospacepb.NearbyByNameRequest{Departure: "Moscow"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("zero distance: expected InvalidArgument, got %v", err)
+	}
+
+	_, err = client.BatchLookup(ctx, &geospacepb.BatchLookupRequest{
+		Queries: make([]*geospacepb.LookupQuery, MaxBatchSize+1),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("large batch: expected InvalidArgument, got %v", err)
+	}
+
+	// invalid queries fail one by one without failing the batch
+	resp, err := client.BatchLookup(ctx, &geospacepb.BatchLookupRequest{
+		Queries: []*geospacepb.LookupQuery{
+			{Query: &geospacepb.LookupQuery_Name{Name: " "}},
+			{Query: &geospacepb.LookupQuery_Point{Point: &geospacepb.Point{Longitude: 181}}},
+			{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, result := range resp.GetResults() {
+		if result.GetError().GetCode() != "INVALID_ARGUMENT" {
+			t.Errorf("query %d: expected INVALID_ARGUMENT, got %v", i, result)
+		}
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{err: fmt.Errorf("departure: %w", database.ErrCityNotFound), code: codes.NotFound},
+		{err: &database.AmbiguousCityError{Candidates: []models.City{{}, {}}}, code: codes.InvalidArgument},
+		{err: routing.ErrNotAvailable, code: codes.Unavailable},
+		{err: status.Error(codes.PermissionDenied, "denied"), code: codes.PermissionDenied, msg: "denied"},
+		{err: errors.New("connection refused"), code: codes.Internal, msg: "internal error"},
+	}
+
+	for _, tt := range tests {
+		st, _ := status.FromError(toStatus(tt.err))
+		if st.Code() != tt.code || (tt.msg != "" && st.Message() != tt.msg) {
+			t.Errorf("%v: expected %s %q, got %s %q", tt.err, tt.code, tt.msg, st.Code(), st.Message())
+		}
+	}
+
+	if codeName(codes.InvalidArgument) != "INVALID_ARGUMENT" {
+		t.Errorf("unexpected name of the code: %s", codeName(codes.InvalidArgument))
+	}
+}
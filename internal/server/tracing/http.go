@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"github.com/alaleks/geospace/internal/server/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts the span of the request continuing the trace of the client
+// from the header traceparent. The span is named by the pattern of the route.
+func Middleware(c *fiber.Ctx) error {
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{&c.Request().Header})
+	method := utils.CopyString(c.Method())
+
+	ctx, span := Start(ctx, "HTTP "+method, trace.SpanKindServer,
+		semconv.HTTPMethod(method), semconv.URLPath(utils.CopyString(c.Path())))
+	defer span.End()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+
+	route, status := metrics.RequestRoute(c, err)
+	span.SetName(method + " " + route)
+	span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPStatusCode(status))
+
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+	}
+
+	return err
+}
+
+// headerCarrier adapts the headers of the request to the propagators.
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+// Get returns the value of the header.
+func (hc headerCarrier) Get(key string) string {
+	return string(hc.header.Peek(key))
+}
+
+// Set sets the value of the header.
+func (hc headerCarrier) Set(key, value string) {
+	hc.header.Set(key, value)
+}
+
+// Keys returns the names of the headers.
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, hc.header.Len())
+	hc.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
@@ -0,0 +1,78 @@
+package tracing_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alaleks/geospace/internal/server/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID = "00f067aa0ba902b7"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core).Sugar()
+
+	app := fiber.New()
+	app.Use(tracing.Middleware)
+	app.Get("/cities/:id", func(c *fiber.Ctx) error {
+		_, span := tracing.Start(c.UserContext(), "db.get_city", trace.SpanKindClient)
+		span.End()
+
+		tracing.Logger(c.UserContext(), logger).Error("city is not available")
+
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/cities/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	query, server := spans[0], spans[1]
+
+	if server.Name() != "GET /cities/:id" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected server span %q %v", server.Name(), server.SpanKind())
+	}
+
+	if server.SpanContext().TraceID().String() != traceID || server.Parent().SpanID().String() != parentID {
+		t.Errorf("trace of the client is not continued: %s, parent %s",
+			server.SpanContext().TraceID(), server.Parent().SpanID())
+	}
+
+	if query.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("span of the query is not the child of the request")
+	}
+
+	// lines of the log have the ids of the trace
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].ContextMap()["trace_id"] != traceID ||
+		entries[0].ContextMap()["span_id"] != server.SpanContext().SpanID().String() {
+		t.Errorf("unexpected log %+v", entries)
+	}
+}
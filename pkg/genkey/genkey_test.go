@@ -1,6 +1,9 @@
 package genkey_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 	"testing"
 
@@ -120,3 +123,35 @@ func BenchmarkCreateWithoutCrypto(b *testing.B) {
 		})
 	}
 }
+
+func TestCreateSigningKeys(t *testing.T) {
+	rsaKey, err := genkey.CreateRSA(genkey.SizeRSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey, err := genkey.CreateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, der := range map[string][]byte{"RSA": rsaKey, "Ed25519": edKey} {
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			if name != "RSA" || key.N.BitLen() != genkey.SizeRSA {
+				t.Errorf("%s: unexpected key of RSA of %d bits", name, key.N.BitLen())
+			}
+		case ed25519.PrivateKey:
+			if name != "Ed25519" {
+				t.Errorf("%s: unexpected key of Ed25519", name)
+			}
+		default:
+			t.Errorf("%s: unexpected type of the key %T", name, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package genkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+)
+
+// SizeRSA is the size of the key of RSA in bits.
+const SizeRSA = 2048
+
+// CreateRSA performs a generation of the private key of RSA specified size in bits,
+// the key is encoded by PKCS #8.
+func CreateRSA(bits int) ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+// CreateEd25519 performs a generation of the private key of Ed25519,
+// the key is encoded by PKCS #8.
+func CreateEd25519() ([]byte, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.MarshalPKCS8PrivateKey(key)
+}
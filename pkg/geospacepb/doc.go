@@ -0,0 +1,5 @@
+// Package geospacepb contains the protobuf messages and the gRPC client and server
+// of the Geospace service generated from proto/geospace/v1/geospace.proto.
+package geospacepb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=github.com/alaleks/geospace/pkg/geospacepb --go-grpc_out=. --go-grpc_opt=module=github.com/alaleks/geospace/pkg/geospacepb geospace/v1/geospace.proto
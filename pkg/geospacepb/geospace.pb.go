@@ -0,0 +1,1500 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: geospace/v1/geospace.proto
+
+package geospacepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Point struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Latitude  float64 `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64 `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Point) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Point) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type City struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	NameAscii   string `protobuf:"bytes,3,opt,name=name_ascii,json=nameAscii,proto3" json:"name_ascii,omitempty"`
+	CountryCode string `protobuf:"bytes,4,opt,name=country_code,json=countryCode,proto3" json:"country_code,omitempty"`
+	Country     string `protobuf:"bytes,5,opt,name=country,proto3" json:"country,omitempty"`
+	Timezone    string `protobuf:"bytes,6,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	Location    *Point `protobuf:"bytes,7,opt,name=location,proto3" json:"location,omitempty"`
+}
+
+func (x *City) Reset() {
+	*x = City{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *City) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*City) ProtoMessage() {}
+
+func (x *City) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use City.ProtoReflect.Descriptor instead.
+func (*City) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *City) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *City) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *City) GetNameAscii() string {
+	if x != nil {
+		return x.NameAscii
+	}
+	return ""
+}
+
+func (x *City) GetCountryCode() string {
+	if x != nil {
+		return x.CountryCode
+	}
+	return ""
+}
+
+func (x *City) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *City) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *City) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+type CityNearby struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	City *City `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	// distance from the origin in km
+	Distance int32 `protobuf:"varint,2,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *CityNearby) Reset() {
+	*x = CityNearby{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CityNearby) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CityNearby) ProtoMessage() {}
+
+func (x *CityNearby) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CityNearby.ProtoReflect.Descriptor instead.
+func (*CityNearby) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CityNearby) GetCity() *City {
+	if x != nil {
+		return x.City
+	}
+	return nil
+}
+
+func (x *CityNearby) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+type DistanceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// city of departure as "city, country"
+	Departure string `protobuf:"bytes,1,opt,name=departure,proto3" json:"departure,omitempty"`
+	// city of destination as "city, country"
+	Destination string `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	// fail with UNAVAILABLE if the distance by road cannot be calculated
+	RequireRoad bool `protobuf:"varint,3,opt,name=require_road,json=requireRoad,proto3" json:"require_road,omitempty"`
+}
+
+func (x *DistanceRequest) Reset() {
+	*x = DistanceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DistanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistanceRequest) ProtoMessage() {}
+
+func (x *DistanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistanceRequest.ProtoReflect.Descriptor instead.
+func (*DistanceRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DistanceRequest) GetDeparture() string {
+	if x != nil {
+		return x.Departure
+	}
+	return ""
+}
+
+func (x *DistanceRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *DistanceRequest) GetRequireRoad() bool {
+	if x != nil {
+		return x.RequireRoad
+	}
+	return false
+}
+
+type DistanceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Departure   *City `protobuf:"bytes,1,opt,name=departure,proto3" json:"departure,omitempty"`
+	Destination *City `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	// distance by straight line in km
+	DistanceStraight int32 `protobuf:"varint,3,opt,name=distance_straight,json=distanceStraight,proto3" json:"distance_straight,omitempty"`
+	// distance by road in km, 0 if it is not available
+	DistanceRoad int32 `protobuf:"varint,4,opt,name=distance_road,json=distanceRoad,proto3" json:"distance_road,omitempty"`
+}
+
+func (x *DistanceResponse) Reset() {
+	*x = DistanceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DistanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistanceResponse) ProtoMessage() {}
+
+func (x *DistanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistanceResponse.ProtoReflect.Descriptor instead.
+func (*DistanceResponse) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DistanceResponse) GetDeparture() *City {
+	if x != nil {
+		return x.Departure
+	}
+	return nil
+}
+
+func (x *DistanceResponse) GetDestination() *City {
+	if x != nil {
+		return x.Destination
+	}
+	return nil
+}
+
+func (x *DistanceResponse) GetDistanceStraight() int32 {
+	if x != nil {
+		return x.DistanceStraight
+	}
+	return 0
+}
+
+func (x *DistanceResponse) GetDistanceRoad() int32 {
+	if x != nil {
+		return x.DistanceRoad
+	}
+	return 0
+}
+
+type NearbyByNameRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// city of departure as "city, country"
+	Departure string `protobuf:"bytes,1,opt,name=departure,proto3" json:"departure,omitempty"`
+	// radius of the search in km
+	Distance int32 `protobuf:"varint,2,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *NearbyByNameRequest) Reset() {
+	*x = NearbyByNameRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NearbyByNameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyByNameRequest) ProtoMessage() {}
+
+func (x *NearbyByNameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyByNameRequest.ProtoReflect.Descriptor instead.
+func (*NearbyByNameRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NearbyByNameRequest) GetDeparture() string {
+	if x != nil {
+		return x.Departure
+	}
+	return ""
+}
+
+func (x *NearbyByNameRequest) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+type NearbyByCoordRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Point *Point `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+	// radius of the search in km
+	Distance int32 `protobuf:"varint,2,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *NearbyByCoordRequest) Reset() {
+	*x = NearbyByCoordRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NearbyByCoordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyByCoordRequest) ProtoMessage() {}
+
+func (x *NearbyByCoordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyByCoordRequest.ProtoReflect.Descriptor instead.
+func (*NearbyByCoordRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *NearbyByCoordRequest) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *NearbyByCoordRequest) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+type NearbyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// set only for the search by name
+	Departure *City         `protobuf:"bytes,1,opt,name=departure,proto3" json:"departure,omitempty"`
+	Cities    []*CityNearby `protobuf:"bytes,2,rep,name=cities,proto3" json:"cities,omitempty"`
+}
+
+func (x *NearbyResponse) Reset() {
+	*x = NearbyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NearbyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyResponse) ProtoMessage() {}
+
+func (x *NearbyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyResponse.ProtoReflect.Descriptor instead.
+func (*NearbyResponse) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *NearbyResponse) GetDeparture() *City {
+	if x != nil {
+		return x.Departure
+	}
+	return nil
+}
+
+func (x *NearbyResponse) GetCities() []*CityNearby {
+	if x != nil {
+		return x.Cities
+	}
+	return nil
+}
+
+type StreamNearbyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Origin:
+	//	*StreamNearbyRequest_Departure
+	//	*StreamNearbyRequest_Point
+	Origin isStreamNearbyRequest_Origin `protobuf_oneof:"origin"`
+	// radius of the search in km
+	Distance int32 `protobuf:"varint,3,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *StreamNearbyRequest) Reset() {
+	*x = StreamNearbyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamNearbyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamNearbyRequest) ProtoMessage() {}
+
+func (x *StreamNearbyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamNearbyRequest.ProtoReflect.Descriptor instead.
+func (*StreamNearbyRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{8}
+}
+
+func (m *StreamNearbyRequest) GetOrigin() isStreamNearbyRequest_Origin {
+	if m != nil {
+		return m.Origin
+	}
+	return nil
+}
+
+func (x *StreamNearbyRequest) GetDeparture() string {
+	if x, ok := x.GetOrigin().(*StreamNearbyRequest_Departure); ok {
+		return x.Departure
+	}
+	return ""
+}
+
+func (x *StreamNearbyRequest) GetPoint() *Point {
+	if x, ok := x.GetOrigin().(*StreamNearbyRequest_Point); ok {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *StreamNearbyRequest) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+type isStreamNearbyRequest_Origin interface {
+	isStreamNearbyRequest_Origin()
+}
+
+type StreamNearbyRequest_Departure struct {
+	// city of departure as "city, country"
+	Departure string `protobuf:"bytes,1,opt,name=departure,proto3,oneof"`
+}
+
+type StreamNearbyRequest_Point struct {
+	Point *Point `protobuf:"bytes,2,opt,name=point,proto3,oneof"`
+}
+
+func (*StreamNearbyRequest_Departure) isStreamNearbyRequest_Origin() {}
+
+func (*StreamNearbyRequest_Point) isStreamNearbyRequest_Origin() {}
+
+type ReverseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Point *Point `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+}
+
+func (x *ReverseRequest) Reset() {
+	*x = ReverseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReverseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseRequest) ProtoMessage() {}
+
+func (x *ReverseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseRequest.ProtoReflect.Descriptor instead.
+func (*ReverseRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReverseRequest) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+type ReverseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nearest *CityNearby `protobuf:"bytes,1,opt,name=nearest,proto3" json:"nearest,omitempty"`
+}
+
+func (x *ReverseResponse) Reset() {
+	*x = ReverseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReverseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseResponse) ProtoMessage() {}
+
+func (x *ReverseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseResponse.ProtoReflect.Descriptor instead.
+func (*ReverseResponse) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReverseResponse) GetNearest() *CityNearby {
+	if x != nil {
+		return x.Nearest
+	}
+	return nil
+}
+
+type LookupQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Query:
+	//	*LookupQuery_Name
+	//	*LookupQuery_Point
+	Query isLookupQuery_Query `protobuf_oneof:"query"`
+}
+
+func (x *LookupQuery) Reset() {
+	*x = LookupQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LookupQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupQuery) ProtoMessage() {}
+
+func (x *LookupQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupQuery.ProtoReflect.Descriptor instead.
+func (*LookupQuery) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{11}
+}
+
+func (m *LookupQuery) GetQuery() isLookupQuery_Query {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
+func (x *LookupQuery) GetName() string {
+	if x, ok := x.GetQuery().(*LookupQuery_Name); ok {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LookupQuery) GetPoint() *Point {
+	if x, ok := x.GetQuery().(*LookupQuery_Point); ok {
+		return x.Point
+	}
+	return nil
+}
+
+type isLookupQuery_Query interface {
+	isLookupQuery_Query()
+}
+
+type LookupQuery_Name struct {
+	// name of the city as "city, country"
+	Name string `protobuf:"bytes,1,opt,name=name,proto3,oneof"`
+}
+
+type LookupQuery_Point struct {
+	// coordinates for reverse geocoding
+	Point *Point `protobuf:"bytes,2,opt,name=point,proto3,oneof"`
+}
+
+func (*LookupQuery_Name) isLookupQuery_Query() {}
+
+func (*LookupQuery_Point) isLookupQuery_Query() {}
+
+type LookupResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query *LookupQuery `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Types that are assignable to Result:
+	//	*LookupResult_City
+	//	*LookupResult_Error
+	Result isLookupResult_Result `protobuf_oneof:"result"`
+}
+
+func (x *LookupResult) Reset() {
+	*x = LookupResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LookupResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupResult) ProtoMessage() {}
+
+func (x *LookupResult) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupResult.ProtoReflect.Descriptor instead.
+func (*LookupResult) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LookupResult) GetQuery() *LookupQuery {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (m *LookupResult) GetResult() isLookupResult_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *LookupResult) GetCity() *CityNearby {
+	if x, ok := x.GetResult().(*LookupResult_City); ok {
+		return x.City
+	}
+	return nil
+}
+
+func (x *LookupResult) GetError() *LookupError {
+	if x, ok := x.GetResult().(*LookupResult_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+type isLookupResult_Result interface {
+	isLookupResult_Result()
+}
+
+type LookupResult_City struct {
+	City *CityNearby `protobuf:"bytes,2,opt,name=city,proto3,oneof"`
+}
+
+type LookupResult_Error struct {
+	Error *LookupError `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*LookupResult_City) isLookupResult_Result() {}
+
+func (*LookupResult_Error) isLookupResult_Result() {}
+
+type LookupError struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name of the gRPC status code, e.g. NOT_FOUND
+	Code    string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// cities matching the ambiguous name
+	Candidates []*City `protobuf:"bytes,3,rep,name=candidates,proto3" json:"candidates,omitempty"`
+}
+
+func (x *LookupError) Reset() {
+	*x = LookupError{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LookupError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupError) ProtoMessage() {}
+
+func (x *LookupError) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupError.ProtoReflect.Descriptor instead.
+func (*LookupError) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LookupError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *LookupError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LookupError) GetCandidates() []*City {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+type BatchLookupRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Queries []*LookupQuery `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+}
+
+func (x *BatchLookupRequest) Reset() {
+	*x = BatchLookupRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupRequest) ProtoMessage() {}
+
+func (x *BatchLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupRequest.ProtoReflect.Descriptor instead.
+func (*BatchLookupRequest) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchLookupRequest) GetQueries() []*LookupQuery {
+	if x != nil {
+		return x.Queries
+	}
+	return nil
+}
+
+type BatchLookupResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// results in the order of the queries
+	Results []*LookupResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *BatchLookupResponse) Reset() {
+	*x = BatchLookupResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_geospace_v1_geospace_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupResponse) ProtoMessage() {}
+
+func (x *BatchLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geospace_v1_geospace_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupResponse.ProtoReflect.Descriptor instead.
+func (*BatchLookupResponse) Descriptor() ([]byte, []int) {
+	return file_geospace_v1_geospace_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BatchLookupResponse) GetResults() []*LookupResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_geospace_v1_geospace_proto protoreflect.FileDescriptor
+
+var file_geospace_v1_geospace_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x67, 0x65,
+	0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x67, 0x65,
+	0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x22, 0x41, 0x0a, 0x05, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0xd2, 0x01, 0x0a,
+	0x04, 0x43, 0x69, 0x74, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6e, 0x61, 0x6d,
+	0x65, 0x5f, 0x61, 0x73, 0x63, 0x69, 0x69, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e,
+	0x61, 0x6d, 0x65, 0x41, 0x73, 0x63, 0x69, 0x69, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e,
+	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e,
+	0x65, 0x12, 0x2e, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0x4f, 0x0a, 0x0a, 0x43, 0x69, 0x74, 0x79, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x12,
+	0x25, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79,
+	0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x22, 0x74, 0x0a, 0x0f, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75,
+	0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74,
+	0x75, 0x72, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65,
+	0x5f, 0x72, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x72, 0x65, 0x71,
+	0x75, 0x69, 0x72, 0x65, 0x52, 0x6f, 0x61, 0x64, 0x22, 0xca, 0x01, 0x0a, 0x10, 0x44, 0x69, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a,
+	0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x69, 0x74, 0x79, 0x52, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x12, 0x33,
+	0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x69, 0x74, 0x79, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x2b, 0x0a, 0x11, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f,
+	0x73, 0x74, 0x72, 0x61, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10,
+	0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x72, 0x61, 0x69, 0x67, 0x68, 0x74,
+	0x12, 0x23, 0x0a, 0x0d, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x72, 0x6f, 0x61,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x52, 0x6f, 0x61, 0x64, 0x22, 0x4f, 0x0a, 0x13, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x42,
+	0x79, 0x4e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09,
+	0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x64, 0x69,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x5c, 0x0a, 0x14, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79,
+	0x42, 0x79, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x28,
+	0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e,
+	0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x52, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x22, 0x72, 0x0a, 0x0e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74,
+	0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x65, 0x6f, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79, 0x52, 0x09, 0x64, 0x65,
+	0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2f, 0x0a, 0x06, 0x63, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79,
+	0x52, 0x06, 0x63, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x87, 0x01, 0x0a, 0x13, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1e, 0x0a, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x64, 0x65, 0x70, 0x61, 0x72, 0x74, 0x75, 0x72, 0x65,
+	0x12, 0x2a, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f,
+	0x69, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67,
+	0x69, 0x6e, 0x22, 0x3a, 0x0a, 0x0e, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x44,
+	0x0a, 0x0f, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x31, 0x0a, 0x07, 0x6e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x69, 0x74, 0x79, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x07, 0x6e, 0x65, 0x61,
+	0x72, 0x65, 0x73, 0x74, 0x22, 0x58, 0x0a, 0x0b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x51, 0x75,
+	0x65, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x22, 0xa9,
+	0x01, 0x0a, 0x0c, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x2e, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f,
+	0x6b, 0x75, 0x70, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12,
+	0x2d, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79,
+	0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x48, 0x00, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x12, 0x30,
+	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b,
+	0x75, 0x70, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x6e, 0x0a, 0x0b, 0x4c, 0x6f,
+	0x6f, 0x6b, 0x75, 0x70, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x31, 0x0a, 0x0a, 0x63, 0x61, 0x6e, 0x64, 0x69,
+	0x64, 0x61, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x65,
+	0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79, 0x52, 0x0a,
+	0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x73, 0x22, 0x48, 0x0a, 0x12, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x32, 0x0a, 0x07, 0x71, 0x75, 0x65, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x07, 0x71, 0x75, 0x65,
+	0x72, 0x69, 0x65, 0x73, 0x22, 0x4a, 0x0a, 0x13, 0x42, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x6f, 0x6f,
+	0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67,
+	0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75,
+	0x70, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x32, 0xd8, 0x03, 0x0a, 0x08, 0x47, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x47, 0x0a,
+	0x08, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x2e, 0x67, 0x65, 0x6f, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4d, 0x0a, 0x0c, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79,
+	0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x42, 0x79, 0x4e, 0x61, 0x6d,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0d, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x42,
+	0x79, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x12, 0x21, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x42, 0x79, 0x43, 0x6f, 0x6f,
+	0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x65, 0x6f, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a, 0x0c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x12, 0x20, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4e, 0x65, 0x61, 0x72, 0x62,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x69, 0x74, 0x79, 0x4e, 0x65, 0x61, 0x72, 0x62,
+	0x79, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x07, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x12, 0x1b,
+	0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x76,
+	0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x65,
+	0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0b, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x12, 0x1f, 0x2e, 0x67, 0x65, 0x6f, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x6f, 0x6f, 0x6b,
+	0x75, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x67, 0x65, 0x6f, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x6f, 0x6f,
+	0x6b, 0x75, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x37, 0x5a, 0x35, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x6c, 0x61, 0x6c, 0x65, 0x6b,
+	0x73, 0x2f, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x67,
+	0x65, 0x6f, 0x73, 0x70, 0x61, 0x63, 0x65, 0x70, 0x62, 0x3b, 0x67, 0x65, 0x6f, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_geospace_v1_geospace_proto_rawDescOnce sync.Once
+	file_geospace_v1_geospace_proto_rawDescData = file_geospace_v1_geospace_proto_rawDesc
+)
+
+func file_geospace_v1_geospace_proto_rawDescGZIP() []byte {
+	file_geospace_v1_geospace_proto_rawDescOnce.Do(func() {
+		file_geospace_v1_geospace_proto_rawDescData = protoimpl.X.CompressGZIP(file_geospace_v1_geospace_proto_rawDescData)
+	})
+	return file_geospace_v1_geospace_proto_rawDescData
+}
+
+var file_geospace_v1_geospace_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_geospace_v1_geospace_proto_goTypes = []interface{}{
+	(*Point)(nil),                // 0: geospace.v1.Point
+	(*City)(nil),                 // 1: geospace.v1.City
+	(*CityNearby)(nil),           // 2: geospace.v1.CityNearby
+	(*DistanceRequest)(nil),      // 3: geospace.v1.DistanceRequest
+	(*DistanceResponse)(nil),     // 4: geospace.v1.DistanceResponse
+	(*NearbyByNameRequest)(nil),  // 5: geospace.v1.NearbyByNameRequest
+	(*NearbyByCoordRequest)(nil), // 6: geospace.v1.NearbyByCoordRequest
+	(*NearbyResponse)(nil),       // 7: geospace.v1.NearbyResponse
+	(*StreamNearbyRequest)(nil),  // 8: geospace.v1.StreamNearbyRequest
+	(*ReverseRequest)(nil),       // 9: geospace.v1.ReverseRequest
+	(*ReverseResponse)(nil),      // 10: geospace.v1.ReverseResponse
+	(*LookupQuery)(nil),          // 11: geospace.v1.LookupQuery
+	(*LookupResult)(nil),         // 12: geospace.v1.LookupResult
+	(*LookupError)(nil),          // 13: geospace.v1.LookupError
+	(*BatchLookupRequest)(nil),   // 14: geospace.v1.BatchLookupRequest
+	(*BatchLookupResponse)(nil),  // 15: geospace.v1.BatchLookupResponse
+}
+var file_geospace_v1_geospace_proto_depIdxs = []int32{
+	0,  // 0: geospace.v1.City.location:type_name -> geospace.v1.Point
+	1,  // 1: geospace.v1.CityNearby.city:type_name -> geospace.v1.City
+	1,  // 2: geospace.v1.DistanceResponse.departure:type_name -> geospace.v1.City
+	1,  // 3: geospace.v1.DistanceResponse.destination:type_name -> geospace.v1.City
+	0,  // 4: geospace.v1.NearbyByCoordRequest.point:type_name -> geospace.v1.Point
+	1,  // 5: geospace.v1.NearbyResponse.departure:type_name -> geospace.v1.City
+	2,  // 6: geospace.v1.NearbyResponse.cities:type_name -> geospace.v1.CityNearby
+	0,  // 7: geospace.v1.StreamNearbyRequest.point:type_name -> geospace.v1.Point
+	0,  // 8: geospace.v1.ReverseRequest.point:type_name -> geospace.v1.Point
+	2,  // 9: geospace.v1.ReverseResponse.nearest:type_name -> geospace.v1.CityNearby
+	0,  // 10: geospace.v1.LookupQuery.point:type_name -> geospace.v1.Point
+	11, // 11: geospace.v1.LookupResult.query:type_name -> geospace.v1.LookupQuery
+	2,  // 12: geospace.v1.LookupResult.city:type_name -> geospace.v1.CityNearby
+	13, // 13: geospace.v1.LookupResult.error:type_name -> geospace.v1.LookupError
+	1,  // 14: geospace.v1.LookupError.candidates:type_name -> geospace.v1.City
+	11, // 15: geospace.v1.BatchLookupRequest.queries:type_name -> geospace.v1.LookupQuery
+	12, // 16: geospace.v1.BatchLookupResponse.results:type_name -> geospace.v1.LookupResult
+	3,  // 17: geospace.v1.Geospace.Distance:input_type -> geospace.v1.DistanceRequest
+	5,  // 18: geospace.v1.Geospace.NearbyByName:input_type -> geospace.v1.NearbyByNameRequest
+	6,  // 19: geospace.v1.Geospace.NearbyByCoord:input_type -> geospace.v1.NearbyByCoordRequest
+	8,  // 20: geospace.v1.Geospace.StreamNearby:input_type -> geospace.v1.StreamNearbyRequest
+	9,  // 21: geospace.v1.Geospace.Reverse:input_type -> geospace.v1.ReverseRequest
+	14, // 22: geospace.v1.Geospace.BatchLookup:input_type -> geospace.v1.BatchLookupRequest
+	4,  // 23: geospace.v1.Geospace.Distance:output_type -> geospace.v1.DistanceResponse
+	7,  // 24: geospace.v1.Geospace.NearbyByName:output_type -> geospace.v1.NearbyResponse
+	7,  // 25: geospace.v1.Geospace.NearbyByCoord:output_type -> geospace.v1.NearbyResponse
+	2,  // 26: geospace.v1.Geospace.StreamNearby:output_type -> geospace.v1.CityNearby
+	10, // 27: geospace.v1.Geospace.Reverse:output_type -> geospace.v1.ReverseResponse
+	15, // 28: geospace.v1.Geospace.BatchLookup:output_type -> geospace.v1.BatchLookupResponse
+	23, // [23:29] is the sub-list for method output_type
+	17, // [17:23] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_geospace_v1_geospace_proto_init() }
+func file_geospace_v1_geospace_proto_init() {
+	if File_geospace_v1_geospace_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_geospace_v1_geospace_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*City); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CityNearby); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DistanceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DistanceResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NearbyByNameRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NearbyByCoordRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NearbyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamNearbyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReverseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReverseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LookupQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LookupResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LookupError); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchLookupRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_geospace_v1_geospace_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchLookupResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_geospace_v1_geospace_proto_msgTypes[8].OneofWrappers = []interface{}{
+		(*StreamNearbyRequest_Departure)(nil),
+		(*StreamNearbyRequest_Point)(nil),
+	}
+	file_geospace_v1_geospace_proto_msgTypes[11].OneofWrappers = []interface{}{
+		(*LookupQuery_Name)(nil),
+		(*LookupQuery_Point)(nil),
+	}
+	file_geospace_v1_geospace_proto_msgTypes[12].OneofWrappers = []interface{}{
+		(*LookupResult_City)(nil),
+		(*LookupResult_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_geospace_v1_geospace_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_geospace_v1_geospace_proto_goTypes,
+		DependencyIndexes: file_geospace_v1_geospace_proto_depIdxs,
+		MessageInfos:      file_geospace_v1_geospace_proto_msgTypes,
+	}.Build()
+	File_geospace_v1_geospace_proto = out.File
+	file_geospace_v1_geospace_proto_rawDesc = nil
+	file_geospace_v1_geospace_proto_goTypes = nil
+	file_geospace_v1_geospace_proto_depIdxs = nil
+}
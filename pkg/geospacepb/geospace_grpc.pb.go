@@ -0,0 +1,338 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: geospace/v1/geospace.proto
+
+package geospacepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Geospace_Distance_FullMethodName      = "/geospace.v1.Geospace/Distance"
+	Geospace_NearbyByName_FullMethodName  = "/geospace.v1.Geospace/NearbyByName"
+	Geospace_NearbyByCoord_FullMethodName = "/geospace.v1.Geospace/NearbyByCoord"
+	Geospace_StreamNearby_FullMethodName  = "/geospace.v1.Geospace/StreamNearby"
+	Geospace_Reverse_FullMethodName       = "/geospace.v1.Geospace/Reverse"
+	Geospace_BatchLookup_FullMethodName   = "/geospace.v1.Geospace/BatchLookup"
+)
+
+// GeospaceClient is the client API for Geospace service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GeospaceClient interface {
+	// Distance returns the distance between two cities.
+	Distance(ctx context.Context, in *DistanceRequest, opts ...grpc.CallOption) (*DistanceResponse, error)
+	// NearbyByName returns the cities near the city of departure.
+	NearbyByName(ctx context.Context, in *NearbyByNameRequest, opts ...grpc.CallOption) (*NearbyResponse, error)
+	// NearbyByCoord returns the cities near the coordinates.
+	NearbyByCoord(ctx context.Context, in *NearbyByCoordRequest, opts ...grpc.CallOption) (*NearbyResponse, error)
+	// StreamNearby streams the cities near the city or the coordinates one by one,
+	// it is intended for large radiuses.
+	StreamNearby(ctx context.Context, in *StreamNearbyRequest, opts ...grpc.CallOption) (Geospace_StreamNearbyClient, error)
+	// Reverse returns the city nearest to the coordinates.
+	Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*ReverseResponse, error)
+	// BatchLookup resolves several names of the cities and coordinates at once.
+	// Failed lookups do not fail the call, their errors are returned in the results.
+	BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error)
+}
+
+type geospaceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeospaceClient(cc grpc.ClientConnInterface) GeospaceClient {
+	return &geospaceClient{cc}
+}
+
+func (c *geospaceClient) Distance(ctx context.Context, in *DistanceRequest, opts ...grpc.CallOption) (*DistanceResponse, error) {
+	out := new(DistanceResponse)
+	err := c.cc.Invoke(ctx, Geospace_Distance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geospaceClient) NearbyByName(ctx context.Context, in *NearbyByNameRequest, opts ...grpc.CallOption) (*NearbyResponse, error) {
+	out := new(NearbyResponse)
+	err := c.cc.Invoke(ctx, Geospace_NearbyByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geospaceClient) NearbyByCoord(ctx context.Context, in *NearbyByCoordRequest, opts ...grpc.CallOption) (*NearbyResponse, error) {
+	out := new(NearbyResponse)
+	err := c.cc.Invoke(ctx, Geospace_NearbyByCoord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geospaceClient) StreamNearby(ctx context.Context, in *StreamNearbyRequest, opts ...grpc.CallOption) (Geospace_StreamNearbyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Geospace_ServiceDesc.Streams[0], Geospace_StreamNearby_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &geospaceStreamNearbyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Geospace_StreamNearbyClient interface {
+	Recv() (*CityNearby, error)
+	grpc.ClientStream
+}
+
+type geospaceStreamNearbyClient struct {
+	grpc.ClientStream
+}
+
+func (x *geospaceStreamNearbyClient) Recv() (*CityNearby, error) {
+	m := new(CityNearby)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *geospaceClient) Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*ReverseResponse, error) {
+	out := new(ReverseResponse)
+	err := c.cc.Invoke(ctx, Geospace_Reverse_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geospaceClient) BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error) {
+	out := new(BatchLookupResponse)
+	err := c.cc.Invoke(ctx, Geospace_BatchLookup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GeospaceServer is the server API for Geospace service.
+// All implementations must embed UnimplementedGeospaceServer
+// for forward compatibility
+type GeospaceServer interface {
+	// Distance returns the distance between two cities.
+	Distance(context.Context, *DistanceRequest) (*DistanceResponse, error)
+	// NearbyByName returns the cities near the city of departure.
+	NearbyByName(context.Context, *NearbyByNameRequest) (*NearbyResponse, error)
+	// NearbyByCoord returns the cities near the coordinates.
+	NearbyByCoord(context.Context, *NearbyByCoordRequest) (*NearbyResponse, error)
+	// StreamNearby streams the cities near the city or the coordinates one by one,
+	// it is intended for large radiuses.
+	StreamNearby(*StreamNearbyRequest, Geospace_StreamNearbyServer) error
+	// Reverse returns the city nearest to the coordinates.
+	Reverse(context.Context, *ReverseRequest) (*ReverseResponse, error)
+	// BatchLookup resolves several names of the cities and coordinates at once.
+	// Failed lookups do not fail the call, their errors are returned in the results.
+	BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error)
+	mustEmbedUnimplementedGeospaceServer()
+}
+
+// UnimplementedGeospaceServer must be embedded to have forward compatible implementations.
+type UnimplementedGeospaceServer struct {
+}
+
+func (UnimplementedGeospaceServer) Distance(context.Context, *DistanceRequest) (*DistanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Distance not implemented")
+}
+func (UnimplementedGeospaceServer) NearbyByName(context.Context, *NearbyByNameRequest) (*NearbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NearbyByName not implemented")
+}
+func (UnimplementedGeospaceServer) NearbyByCoord(context.Context, *NearbyByCoordRequest) (*NearbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NearbyByCoord not implemented")
+}
+func (UnimplementedGeospaceServer) StreamNearby(*StreamNearbyRequest, Geospace_StreamNearbyServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamNearby not implemented")
+}
+func (UnimplementedGeospaceServer) Reverse(context.Context, *ReverseRequest) (*ReverseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reverse not implemented")
+}
+func (UnimplementedGeospaceServer) BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedGeospaceServer) mustEmbedUnimplementedGeospaceServer() {}
+
+// UnsafeGeospaceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GeospaceServer will
+// result in compilation errors.
+type UnsafeGeospaceServer interface {
+	mustEmbedUnimplementedGeospaceServer()
+}
+
+func RegisterGeospaceServer(s grpc.ServiceRegistrar, srv GeospaceServer) {
+	s.RegisterService(&Geospace_ServiceDesc, srv)
+}
+
+func _Geospace_Distance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DistanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeospaceServer).Distance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geospace_Distance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeospaceServer).Distance(ctx, req.(*DistanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geospace_NearbyByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NearbyByNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeospaceServer).NearbyByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geospace_NearbyByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeospaceServer).NearbyByName(ctx, req.(*NearbyByNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geospace_NearbyByCoord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NearbyByCoordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeospaceServer).NearbyByCoord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geospace_NearbyByCoord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeospaceServer).NearbyByCoord(ctx, req.(*NearbyByCoordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geospace_StreamNearby_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamNearbyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GeospaceServer).StreamNearby(m, &geospaceStreamNearbyServer{stream})
+}
+
+type Geospace_StreamNearbyServer interface {
+	Send(*CityNearby) error
+	grpc.ServerStream
+}
+
+type geospaceStreamNearbyServer struct {
+	grpc.ServerStream
+}
+
+func (x *geospaceStreamNearbyServer) Send(m *CityNearby) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Geospace_Reverse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeospaceServer).Reverse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geospace_Reverse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeospaceServer).Reverse(ctx, req.(*ReverseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geospace_BatchLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeospaceServer).BatchLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geospace_BatchLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeospaceServer).BatchLookup(ctx, req.(*BatchLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Geospace_ServiceDesc is the grpc.ServiceDesc for Geospace service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Geospace_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "geospace.v1.Geospace",
+	HandlerType: (*GeospaceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Distance",
+			Handler:    _Geospace_Distance_Handler,
+		},
+		{
+			MethodName: "NearbyByName",
+			Handler:    _Geospace_NearbyByName_Handler,
+		},
+		{
+			MethodName: "NearbyByCoord",
+			Handler:    _Geospace_NearbyByCoord_Handler,
+		},
+		{
+			MethodName: "Reverse",
+			Handler:    _Geospace_Reverse_Handler,
+		},
+		{
+			MethodName: "BatchLookup",
+			Handler:    _Geospace_BatchLookup_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamNearby",
+			Handler:       _Geospace_StreamNearby_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "geospace/v1/geospace.proto",
+}